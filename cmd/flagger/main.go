@@ -13,8 +13,12 @@ import (
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/leaderelection"
@@ -46,7 +50,11 @@ var (
 	slackURL                 string
 	slackUser                string
 	slackChannel             string
+	alertsThrottleWindow     time.Duration
+	alertsThrottleLimit      int
+	alertsDigestInterval     time.Duration
 	eventWebhook             string
+	eventWebhookFormat       string
 	threadiness              int
 	zapReplaceGlobals        bool
 	zapEncoding              string
@@ -56,9 +64,11 @@ var (
 	ingressAnnotationsPrefix string
 	enableLeaderElection     bool
 	leaderElectionNamespace  string
+	clusterSecretsNamespace  string
 	enableConfigTracking     bool
 	ver                      bool
 	kubeconfigServiceMesh    string
+	serverSideApply          bool
 )
 
 func init() {
@@ -72,7 +82,11 @@ func init() {
 	flag.StringVar(&slackUser, "slack-user", "flagger", "Slack user name.")
 	flag.StringVar(&slackChannel, "slack-channel", "", "Slack channel.")
 	flag.StringVar(&eventWebhook, "event-webhook", "", "Webhook for publishing flagger events")
+	flag.StringVar(&eventWebhookFormat, "event-webhook-format", "json", "Event webhook payload format, can be: json, cloudevents (structured mode) or cloudevents-binary.")
 	flag.StringVar(&msteamsURL, "msteams-url", "", "MS Teams incoming webhook URL.")
+	flag.DurationVar(&alertsThrottleWindow, "alerts-throttle-window", 0, "Window during which the identical Slack or MS Teams alerts of a canary revision are sent once.")
+	flag.IntVar(&alertsThrottleLimit, "alerts-throttle-limit", 0, "Maximum number of Slack or MS Teams alerts sent per canary revision within the throttle window.")
+	flag.DurationVar(&alertsDigestInterval, "alerts-digest-interval", 0, "Interval at which the suppressed Slack or MS Teams alerts are sent as a single digest.")
 	flag.IntVar(&threadiness, "threadiness", 2, "Worker concurrency.")
 	flag.BoolVar(&zapReplaceGlobals, "zap-replace-globals", false, "Whether to change the logging level of the global zap logger.")
 	flag.StringVar(&zapEncoding, "zap-encoding", "json", "Zap logger encoding.")
@@ -82,9 +96,11 @@ func init() {
 	flag.StringVar(&ingressAnnotationsPrefix, "ingress-annotations-prefix", "nginx.ingress.kubernetes.io", "Annotations prefix for ingresses.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false, "Enable leader election.")
 	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "kube-system", "Namespace used to create the leader election config map.")
+	flag.StringVar(&clusterSecretsNamespace, "cluster-secrets-namespace", fromEnv("POD_NAMESPACE", "kube-system"), "Namespace used to look up the secrets of cluster metric templates and alert providers, defaults to the Flagger namespace.")
 	flag.BoolVar(&enableConfigTracking, "enable-config-tracking", true, "Enable secrets and configmaps tracking.")
 	flag.BoolVar(&ver, "version", false, "Print version")
 	flag.StringVar(&kubeconfigServiceMesh, "kubeconfig-service-mesh", "", "Path to a kubeconfig for the service mesh control plane cluster.")
+	flag.BoolVar(&serverSideApply, "server-side-apply", false, "Apply the routing objects server-side and report the changes made by others.")
 }
 
 func main() {
@@ -140,7 +156,7 @@ func main() {
 
 	verifyCRDs(flaggerClient, logger)
 	verifyKubernetesVersion(kubeClient, logger)
-	infos := startInformers(flaggerClient, logger, stopCh)
+	infos := startInformers(kubeClient, flaggerClient, logger, stopCh)
 
 	labels := strings.Split(selectorLabels, ",")
 	if len(labels) < 1 {
@@ -151,6 +167,10 @@ func main() {
 		logger.Infof("Watching namespace %s", namespace)
 	}
 
+	if !controller.IsEventWebhookFormat(eventWebhookFormat) {
+		logger.Fatalf("Event webhook format %s is not supported", eventWebhookFormat)
+	}
+
 	observerFactory, err := observers.NewFactory(metricsServer)
 	if err != nil {
 		logger.Fatalf("Error building prometheus client: %s", err.Error())
@@ -169,7 +189,20 @@ func main() {
 	// start HTTP server
 	go server.ListenAndServe(port, 3*time.Second, logger, stopCh)
 
-	routerFactory := router.NewFactory(cfg, kubeClient, flaggerClient, ingressAnnotationsPrefix, logger, meshClient)
+	// the routing objects of the service mesh are managed in the service mesh cluster
+	meshDynamicClient := dynamic.NewForConfigOrDie(cfgHost)
+
+	// apply the routing objects server-side if supported by the service mesh cluster
+	var applyClient dynamic.Interface
+	if serverSideApply {
+		if supportsServerSideApply(cfgHost, logger) {
+			applyClient = meshDynamicClient
+		} else {
+			logger.Warnf("Server-side apply requires Kubernetes 1.16 or newer, the routing objects will be updated")
+		}
+	}
+
+	routerFactory := router.NewFactory(cfg, kubeClient, flaggerClient, ingressAnnotationsPrefix, logger, meshClient, meshDynamicClient, applyClient)
 
 	var configTracker canary.Tracker
 	if enableConfigTracking {
@@ -182,7 +215,7 @@ func main() {
 		configTracker = &canary.NopTracker{}
 	}
 
-	canaryFactory := canary.NewFactory(kubeClient, flaggerClient, configTracker, labels, logger)
+	canaryFactory := canary.NewFactory(kubeClient, flaggerClient, configTracker, labels, logger, dynamic.NewForConfigOrDie(cfg))
 
 	c := controller.NewController(
 		kubeClient,
@@ -191,12 +224,19 @@ func main() {
 		controlLoopInterval,
 		logger,
 		notifierClient,
+		notifier.ThrottleOptions{
+			Window:         alertsThrottleWindow,
+			Limit:          alertsThrottleLimit,
+			DigestInterval: alertsDigestInterval,
+		},
 		canaryFactory,
 		routerFactory,
 		observerFactory,
 		meshProvider,
 		version.VERSION,
 		fromEnv("EVENT_WEBHOOK_URL", eventWebhook),
+		eventWebhookFormat,
+		clusterSecretsNamespace,
 	)
 
 	// leader election context
@@ -231,8 +271,9 @@ func main() {
 	}
 }
 
-func startInformers(flaggerClient clientset.Interface, logger *zap.SugaredLogger, stopCh <-chan struct{}) controller.Informers {
+func startInformers(kubeClient kubernetes.Interface, flaggerClient clientset.Interface, logger *zap.SugaredLogger, stopCh <-chan struct{}) controller.Informers {
 	flaggerInformerFactory := informers.NewSharedInformerFactoryWithOptions(flaggerClient, time.Second*30, informers.WithNamespace(namespace))
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, time.Second*30, kubeinformers.WithNamespace(namespace))
 
 	logger.Info("Waiting for canary informer cache to sync")
 	canaryInformer := flaggerInformerFactory.Flagger().V1beta1().Canaries()
@@ -255,10 +296,34 @@ func startInformers(flaggerClient clientset.Interface, logger *zap.SugaredLogger
 		logger.Fatalf("failed to wait for cache to sync")
 	}
 
+	logger.Info("Waiting for cluster metric template informer cache to sync")
+	clusterMetricInformer := flaggerInformerFactory.Flagger().V1beta1().ClusterMetricTemplates()
+	go clusterMetricInformer.Informer().Run(stopCh)
+	if ok := cache.WaitForNamedCacheSync("flagger", stopCh, clusterMetricInformer.Informer().HasSynced); !ok {
+		logger.Fatalf("failed to wait for cache to sync")
+	}
+
+	logger.Info("Waiting for cluster alert provider informer cache to sync")
+	clusterAlertInformer := flaggerInformerFactory.Flagger().V1beta1().ClusterAlertProviders()
+	go clusterAlertInformer.Informer().Run(stopCh)
+	if ok := cache.WaitForNamedCacheSync("flagger", stopCh, clusterAlertInformer.Informer().HasSynced); !ok {
+		logger.Fatalf("failed to wait for cache to sync")
+	}
+
+	logger.Info("Waiting for secret informer cache to sync")
+	secretInformer := kubeInformerFactory.Core().V1().Secrets()
+	go secretInformer.Informer().Run(stopCh)
+	if ok := cache.WaitForNamedCacheSync("flagger", stopCh, secretInformer.Informer().HasSynced); !ok {
+		logger.Fatalf("failed to wait for cache to sync")
+	}
+
 	return controller.Informers{
-		CanaryInformer: canaryInformer,
-		MetricInformer: metricInformer,
-		AlertInformer:  alertInformer,
+		CanaryInformer:        canaryInformer,
+		MetricInformer:        metricInformer,
+		AlertInformer:         alertInformer,
+		ClusterMetricInformer: clusterMetricInformer,
+		ClusterAlertInformer:  clusterAlertInformer,
+		SecretInformer:        secretInformer,
 	}
 }
 
@@ -350,6 +415,40 @@ func verifyCRDs(flaggerClient clientset.Interface, logger *zap.SugaredLogger) {
 	if err != nil {
 		logger.Fatalf("AlertProvider CRD is not registered %v", err)
 	}
+
+	_, err = flaggerClient.FlaggerV1beta1().ClusterMetricTemplates().List(metav1.ListOptions{Limit: 1})
+	if err != nil {
+		logger.Fatalf("ClusterMetricTemplate CRD is not registered %v", err)
+	}
+
+	_, err = flaggerClient.FlaggerV1beta1().ClusterAlertProviders().List(metav1.ListOptions{Limit: 1})
+	if err != nil {
+		logger.Fatalf("ClusterAlertProvider CRD is not registered %v", err)
+	}
+}
+
+func supportsServerSideApply(cfg *rest.Config, logger *zap.SugaredLogger) bool {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		logger.Fatalf("Error building discovery client: %v", err)
+	}
+
+	ver, err := discoveryClient.ServerVersion()
+	if err != nil {
+		logger.Fatalf("Error calling Kubernetes API: %v", err)
+	}
+
+	semverConstraint, err := semver.NewConstraint(">=1.16.0-alpha.1")
+	if err != nil {
+		logger.Fatalf("Error parsing kubernetes version constraint: %v", err)
+	}
+
+	k8sSemver, err := semver.NewVersion(ver.GitVersion)
+	if err != nil {
+		logger.Fatalf("Error parsing kubernetes version as a semantic version: %v", err)
+	}
+
+	return semverConstraint.Check(k8sSemver)
 }
 
 func verifyKubernetesVersion(kubeClient kubernetes.Interface, logger *zap.SugaredLogger) {
@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"log"
+	"os"
 	"time"
 
 	"github.com/weaveworks/flagger/pkg/loadtester"
@@ -18,6 +19,7 @@ var (
 	timeout           time.Duration
 	zapReplaceGlobals bool
 	zapEncoding       string
+	signingKey        string
 )
 
 func init() {
@@ -26,6 +28,7 @@ func init() {
 	flag.DurationVar(&timeout, "timeout", time.Hour, "Load test exec timeout.")
 	flag.BoolVar(&zapReplaceGlobals, "zap-replace-globals", false, "Whether to change the logging level of the global zap logger.")
 	flag.StringVar(&zapEncoding, "zap-encoding", "json", "Zap logger encoding.")
+	flag.StringVar(&signingKey, "webhook-signing-key", os.Getenv("WEBHOOK_SIGNING_KEY"), "HMAC key used to verify the webhook signatures, verification is disabled when empty.")
 }
 
 func main() {
@@ -50,5 +53,5 @@ func main() {
 	logger.Infof("Starting load tester v%s API on port %s", VERSION, port)
 
 	gateStorage := loadtester.NewGateStorage("in-memory")
-	loadtester.ListenAndServe(port, time.Minute, logger, taskRunner, gateStorage, stopCh)
+	loadtester.ListenAndServe(port, time.Minute, logger, taskRunner, gateStorage, []byte(signingKey), stopCh)
 }
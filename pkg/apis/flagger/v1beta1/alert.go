@@ -22,7 +22,8 @@ import (
 )
 
 const (
-	AlertProviderKind = "AlertProvider"
+	AlertProviderKind        = "AlertProvider"
+	ClusterAlertProviderKind = "ClusterAlertProvider"
 )
 
 // +genclient
@@ -47,6 +48,29 @@ type AlertProviderList struct {
 	Items []AlertProvider `json:"items"`
 }
 
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterAlertProvider is the cluster-wide configuration of alerting for a specific provider
+type ClusterAlertProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AlertProviderSpec   `json:"spec"`
+	Status AlertProviderStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterAlertProviderList is a list of cluster alert provider resources
+type ClusterAlertProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ClusterAlertProvider `json:"items"`
+}
+
 // AlertProviderSpec is the specification of the desired behavior of the AlertProvider
 type AlertProviderSpec struct {
 	// Type of provider
@@ -67,6 +91,57 @@ type AlertProviderSpec struct {
 	// Secret reference containing the provider webhook URL
 	// +optional
 	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// HTTP method of the generic provider requests, defaults to POST
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// Go template of the generic provider request body
+	// +optional
+	Template string `json:"template,omitempty"`
+
+	// ConfigMap key containing the Go template of the generic provider request body
+	// +optional
+	TemplateRef *corev1.ConfigMapKeySelector `json:"templateRef,omitempty"`
+
+	// HTTP status codes accepted from the generic provider, defaults to any 2xx code
+	// +optional
+	ExpectedStatusCodes []int `json:"expectedStatusCodes,omitempty"`
+
+	// Throttle suppresses the repeated alerts of a canary revision
+	// +optional
+	Throttle *AlertProviderThrottle `json:"throttle,omitempty"`
+
+	// DefaultAlert subscribes the canaries without alerts from the provider namespace
+	// +optional
+	DefaultAlert *AlertProviderDefault `json:"defaultAlert,omitempty"`
+}
+
+// AlertProviderDefault defines the alert of the canaries that don't specify any alerts
+type AlertProviderDefault struct {
+	// Severity level: info, warn, error (default info)
+	// +optional
+	Severity AlertSeverity `json:"severity,omitempty"`
+
+	// Event types this alert subscribes to, when specified the severity is ignored
+	// +optional
+	Events []AlertEvent `json:"events,omitempty"`
+}
+
+// AlertProviderThrottle defines how repeated alerts are suppressed,
+// phase transitions are always sent
+type AlertProviderThrottle struct {
+	// Window during which identical alerts are sent once
+	// +optional
+	Window string `json:"window,omitempty"`
+
+	// Maximum number of alerts sent within the window
+	// +optional
+	Limit int `json:"limit,omitempty"`
+
+	// Interval at which the suppressed alerts are sent as a single digest
+	// +optional
+	DigestInterval string `json:"digestInterval,omitempty"`
 }
 
 type AlertProviderStatus struct {
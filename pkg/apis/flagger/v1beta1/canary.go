@@ -19,9 +19,11 @@ package v1beta1
 import (
 	"fmt"
 	"github.com/weaveworks/flagger/pkg/apis/edas/v1alpha1/route"
+	"strings"
 	"time"
 
 	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -31,6 +33,13 @@ const (
 	ProgressDeadlineSeconds = 600
 	AnalysisInterval        = 60 * time.Second
 	MetricInterval          = "1m"
+
+	// KnativeServiceKind is the target kind of the Knative Serving services
+	KnativeServiceKind = "Service.serving.knative.dev"
+	// KnativePrimaryRevisionAnnotation holds the last promoted revision of a Knative service
+	KnativePrimaryRevisionAnnotation = "flagger.app/primary-revision"
+	// KnativeRevisionMetricLabel is the label holding the revision name in the Knative queue-proxy metrics
+	KnativeRevisionMetricLabel = "revision_name"
 )
 
 // +genclient
@@ -101,8 +110,24 @@ type CanarySpec struct {
 	// revert canary mutation on deletion of canary resource
 	// +optional
 	RevertOnDeletion bool `json:"revertOnDeletion,omitempty"`
+
+	// DriftPolicy decides if the routing objects fields changed by others are re-applied
+	// Defaults to Reassert
+	// +optional
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
 }
 
+// DriftPolicy is applied when the fields of a routing object managed by Flagger
+// have been changed by another field manager
+type DriftPolicy string
+
+const (
+	// DriftPolicyReassert reports the drift and applies the Flagger fields again
+	DriftPolicyReassert DriftPolicy = "Reassert"
+	// DriftPolicyReport reports the drift and halts the canary until the fields are reverted
+	DriftPolicyReport DriftPolicy = "Report"
+)
+
 // CanaryService defines how ClusterIP services, service mesh or ingress routing objects are generated
 type CanaryService struct {
 	// Name of the Kubernetes service generated by Flagger
@@ -126,6 +151,11 @@ type CanaryService struct {
 	// PortDiscovery adds all container ports to the generated Kubernetes service
 	PortDiscovery bool `json:"portDiscovery"`
 
+	// AppProtocol of the generated service: http, tcp or tls
+	// Defaults to http
+	// +optional
+	AppProtocol AppProtocol `json:"appProtocol,omitempty"`
+
 	// Timeout of the HTTP or gRPC request
 	// +optional
 	Timeout string `json:"timeout,omitempty"`
@@ -164,6 +194,16 @@ type CanaryService struct {
 	// +optional
 	CorsPolicy *istiov1alpha3.CorsPolicy `json:"corsPolicy,omitempty"`
 
+	// Delegation generates an Istio delegate virtual service without hosts and gateways,
+	// to be referenced from a root virtual service
+	// +optional
+	Delegation bool `json:"delegation,omitempty"`
+
+	// VirtualServiceRef selects a named HTTP route of an existing Istio virtual service
+	// to be managed by Flagger instead of generating a virtual service
+	// +optional
+	VirtualServiceRef *VirtualServiceRouteRef `json:"virtualServiceRef,omitempty"`
+
 	// Mesh name of the generated App Mesh virtual nodes and virtual service
 	// +optional
 	MeshName string `json:"meshName,omitempty"`
@@ -173,6 +213,15 @@ type CanaryService struct {
 	Backends []string `json:"backends,omitempty"`
 }
 
+// VirtualServiceRouteRef is a reference to a HTTP route of an Istio virtual service
+type VirtualServiceRouteRef struct {
+	// Name of the virtual service in the canary namespace
+	Name string `json:"name"`
+
+	// RouteName is the name of the HTTP route that points to the apex service
+	RouteName string `json:"routeName"`
+}
+
 // CanaryAnalysis is used to describe how the analysis should be done
 type CanaryAnalysis struct {
 	// Schedule interval for this canary analysis
@@ -204,6 +253,10 @@ type CanaryAnalysis struct {
 	// Alert list for this canary analysis
 	Alerts []CanaryAlert `json:"alerts,omitempty"`
 
+	// Dashboard URL template linked in the alerts of this canary
+	// +optional
+	DashboardURL string `json:"dashboardURL,omitempty"`
+
 	// Metric check list for this canary analysis
 	// +optional
 	Metrics []CanaryMetric `json:"metrics,omitempty"`
@@ -264,6 +317,14 @@ type CanaryMetric struct {
 	// TemplateRef references a metric template object
 	// +optional
 	TemplateRef *CrossNamespaceObjectReference `json:"templateRef,omitempty"`
+
+	// TemplateVariables are key-value pairs made available to the metric template query
+	// +optional
+	TemplateVariables map[string]string `json:"templateVariables,omitempty"`
+
+	// GrpcErrorCodes are the gRPC status codes counted as failures by the grpc-success-rate builtin
+	// +optional
+	GrpcErrorCodes []string `json:"grpcErrorCodes,omitempty"`
 }
 
 // CanaryThresholdRange defines the range used for metrics validation
@@ -277,6 +338,15 @@ type CanaryThresholdRange struct {
 	Max *float64 `json:"max,omitempty"`
 }
 
+// AppProtocol is the protocol used by the canary service
+type AppProtocol string
+
+const (
+	AppProtocolHTTP AppProtocol = "http"
+	AppProtocolTCP  AppProtocol = "tcp"
+	AppProtocolTLS  AppProtocol = "tls"
+)
+
 // AlertSeverity defines alert filtering based on severity levels
 type AlertSeverity string
 
@@ -294,10 +364,28 @@ type CanaryAlert struct {
 	// Severity level: info, warn, error (default info)
 	Severity AlertSeverity `json:"severity,omitempty"`
 
+	// Event types this alert subscribes to, when specified the severity is ignored
+	// +optional
+	Events []AlertEvent `json:"events,omitempty"`
+
 	// Alert provider reference
 	ProviderRef CrossNamespaceObjectReference `json:"providerRef"`
 }
 
+// AlertEvent is a canary lifecycle event an alert can subscribe to
+type AlertEvent string
+
+const (
+	AlertEventInitialized   AlertEvent = "initialized"
+	AlertEventStarted       AlertEvent = "started"
+	AlertEventStepAdvanced  AlertEvent = "step-advanced"
+	AlertEventHalted        AlertEvent = "halted"
+	AlertEventWaiting       AlertEvent = "waiting-approval"
+	AlertEventPromoted      AlertEvent = "promoted"
+	AlertEventRolledBack    AlertEvent = "rolled-back"
+	AlertEventWebhookFailed AlertEvent = "webhook-failed"
+)
+
 // HookType can be pre, post or during rollout
 type HookType string
 
@@ -335,6 +423,35 @@ type CanaryWebhook struct {
 	// Metadata (key-value pairs) for this webhook
 	// +optional
 	Metadata *map[string]string `json:"metadata,omitempty"`
+
+	// Secret reference containing the request credentials: the hmacKey used to sign
+	// the requests, the bearer token and the other keys sent as HTTP headers
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// Secret reference containing the client certificate (tls.crt, tls.key)
+	// and the CA certificate (ca.crt) of the webhook server
+	// +optional
+	TLSSecretRef *corev1.LocalObjectReference `json:"tlsSecretRef,omitempty"`
+
+	// Retry policy for this webhook
+	// +optional
+	Retry *CanaryWebhookRetry `json:"retry,omitempty"`
+}
+
+// CanaryWebhookRetry holds the retry policy of a webhook,
+// connection errors, timeouts and the retryable status codes are retried
+type CanaryWebhookRetry struct {
+	// Number of attempts including the first request, at most 5
+	Attempts int `json:"attempts"`
+
+	// Wait time before the first retry, doubled after each attempt up to 30s
+	// +optional
+	Backoff string `json:"backoff,omitempty"`
+
+	// HTTP 5xx status codes that are retried, defaults to 502, 503 and 504
+	// +optional
+	StatusCodes []int `json:"statusCodes,omitempty"`
 }
 
 // CanaryWebhookPayload holds the deployment info and metadata sent to webhooks
@@ -348,6 +465,30 @@ type CanaryWebhookPayload struct {
 	// Phase of the canary analysis
 	Phase CanaryPhase `json:"phase"`
 
+	// Type of the webhook
+	// +optional
+	Type HookType `json:"type,omitempty"`
+
+	// Traffic weight routed to the canary
+	// +optional
+	Weight int `json:"weight,omitempty"`
+
+	// Iterations of the canary analysis
+	// +optional
+	Iterations int `json:"iterations,omitempty"`
+
+	// Failed checks of the canary analysis
+	// +optional
+	FailedChecks int `json:"failedChecks,omitempty"`
+
+	// Checksum of the canary target spec
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Container images of the canary target
+	// +optional
+	Images map[string]string `json:"images,omitempty"`
+
 	// Metadata (key-value pairs) for this webhook
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
@@ -383,6 +524,29 @@ func (c *Canary) GetServiceNames() (apexName, primaryName, canaryName string) {
 	return
 }
 
+// GetTargetKind returns the kind of the canary target,
+// Knative services are qualified with their API group to tell them apart from Kubernetes services
+func (c *Canary) GetTargetKind() string {
+	if c.Spec.TargetRef.Kind == "Service" && strings.HasPrefix(c.Spec.TargetRef.APIVersion, "serving.knative.dev/") {
+		return KnativeServiceKind
+	}
+	return c.Spec.TargetRef.Kind
+}
+
+// GetAppProtocol returns the protocol of the canary service (default http)
+func (c *Canary) GetAppProtocol() AppProtocol {
+	if c.Spec.Service.AppProtocol == "" {
+		return AppProtocolHTTP
+	}
+	return c.Spec.Service.AppProtocol
+}
+
+// IsL4 returns true if the canary service carries opaque TCP or TLS traffic
+func (c *Canary) IsL4() bool {
+	p := c.GetAppProtocol()
+	return p == AppProtocolTCP || p == AppProtocolTLS
+}
+
 // GetProgressDeadlineSeconds returns the progress deadline (default 600s)
 func (c *Canary) GetProgressDeadlineSeconds() int {
 	if c.Spec.ProgressDeadlineSeconds != nil {
@@ -17,6 +17,10 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"text/template"
 
 	corev1 "k8s.io/api/core/v1"
@@ -24,7 +28,8 @@ import (
 )
 
 const (
-	MetricTemplateKind = "MetricTemplate"
+	MetricTemplateKind        = "MetricTemplate"
+	ClusterMetricTemplateKind = "ClusterMetricTemplate"
 )
 
 // +genclient
@@ -49,6 +54,29 @@ type MetricTemplateList struct {
 	Items []MetricTemplate `json:"items"`
 }
 
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterMetricTemplate is a cluster-wide specification for a canary analysis metric
+type ClusterMetricTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MetricTemplateSpec   `json:"spec"`
+	Status MetricTemplateStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterMetricTemplateList is a list of cluster metric template resources
+type ClusterMetricTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ClusterMetricTemplate `json:"items"`
+}
+
 // MetricTemplateSpec is the spec for a metric template resource
 type MetricTemplateSpec struct {
 	// Provider of this metric
@@ -76,26 +104,83 @@ type MetricTemplateProvider struct {
 	Region string `json:"region,omitempty"`
 }
 
+// invalidLabelChars matches the characters not allowed in Prometheus label names
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
 // MetricTemplateModel is the query template model
 type MetricTemplateModel struct {
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
-	Target    string `json:"target"`
-	Service   string `json:"service"`
-	Ingress   string `json:"ingress"`
-	Interval  string `json:"interval"`
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	Target          string            `json:"target"`
+	Primary         string            `json:"primary"`
+	Service         string            `json:"service"`
+	Ingress         string            `json:"ingress"`
+	Interval        string            `json:"interval"`
+	Protocol        string            `json:"protocol,omitempty"`
+	CanaryWeight    int               `json:"canaryWeight"`
+	Iterations      int               `json:"iterations"`
+	CanarySelector  map[string]string `json:"canarySelector,omitempty"`
+	PrimarySelector map[string]string `json:"primarySelector,omitempty"`
+	Images          map[string]string `json:"images,omitempty"`
+	Variables       map[string]string `json:"variables,omitempty"`
 }
 
 // TemplateFunctions returns a map of functions, one for each model field
 func (mtm *MetricTemplateModel) TemplateFunctions() template.FuncMap {
 	return template.FuncMap{
-		"name":      func() string { return mtm.Name },
-		"namespace": func() string { return mtm.Namespace },
-		"target":    func() string { return mtm.Target },
-		"service":   func() string { return mtm.Service },
-		"ingress":   func() string { return mtm.Ingress },
-		"interval":  func() string { return mtm.Interval },
+		"name":            func() string { return mtm.Name },
+		"namespace":       func() string { return mtm.Namespace },
+		"target":          func() string { return mtm.Target },
+		"primary":         func() string { return mtm.Primary },
+		"service":         func() string { return mtm.Service },
+		"ingress":         func() string { return mtm.Ingress },
+		"interval":        func() string { return mtm.Interval },
+		"protocol":        func() string { return mtm.Protocol },
+		"canaryWeight":    func() int { return mtm.CanaryWeight },
+		"iterations":      func() int { return mtm.Iterations },
+		"canarySelector":  func(prefix ...string) string { return promSelector(mtm.CanarySelector, prefix...) },
+		"primarySelector": func(prefix ...string) string { return promSelector(mtm.PrimarySelector, prefix...) },
+		"image":           func(container string) string { return mtm.Images[container] },
+		"imageTag":        func(container string) string { return imageTag(mtm.Images[container]) },
+		"variable":        func(key string) string { return mtm.Variables[key] },
+	}
+}
+
+// promSelector formats the pod labels as Prometheus label matchers, the label keys are
+// converted to label names as Prometheus relabeling and kube-state-metrics do
+// e.g. app.kubernetes.io/name becomes app_kubernetes_io_name or label_app_kubernetes_io_name with the label_ prefix
+func promSelector(labels map[string]string, prefix ...string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	matchers := make([]string, 0, len(keys))
+	for _, k := range keys {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", promLabelName(strings.Join(prefix, "")+k), labels[k]))
+	}
+	return strings.Join(matchers, ",")
+}
+
+// promLabelName replaces the characters not allowed in Prometheus label names with underscores
+func promLabelName(key string) string {
+	name := invalidLabelChars.ReplaceAllString(key, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// imageTag returns the tag or digest of a container image reference
+func imageTag(image string) string {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		return image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
 	}
+	return "latest"
 }
 
 type MetricTemplateStatus struct {
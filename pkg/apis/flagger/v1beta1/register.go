@@ -51,6 +51,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&MetricTemplateList{},
 		&AlertProvider{},
 		&AlertProviderList{},
+		&ClusterMetricTemplate{},
+		&ClusterMetricTemplateList{},
+		&ClusterAlertProvider{},
+		&ClusterAlertProviderList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -11,6 +11,9 @@ type CanaryConditionType string
 const (
 	// PromotedType refers to the result of the last canary analysis
 	PromotedType CanaryConditionType = "Promoted"
+
+	// DriftedType refers to the changes made by others to the routing objects managed by Flagger
+	DriftedType CanaryConditionType = "Drifted"
 )
 
 // CanaryCondition is a status condition for a Canary
@@ -82,4 +85,48 @@ type CanaryStatus struct {
 	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 	// +optional
 	Conditions []CanaryCondition `json:"conditions,omitempty"`
+	// +optional
+	AlertThreads []CanaryAlertThread `json:"alertThreads,omitempty"`
+}
+
+// CanaryAlertThread is the parent message of the threaded alerts
+// sent to a provider for a canary revision
+type CanaryAlertThread struct {
+	// Provider is the alert provider name and namespace
+	Provider string `json:"provider"`
+
+	// Revision is the canary spec hash the thread belongs to
+	Revision string `json:"revision"`
+
+	// Channel is the channel ID of the parent message
+	Channel string `json:"channel,omitempty"`
+
+	// Timestamp is the ID of the parent message
+	Timestamp string `json:"timestamp"`
+}
+
+// SetAlertThread replaces the alert thread of the same provider,
+// the thread of a previous revision is dropped
+func (s *CanaryStatus) SetAlertThread(thread CanaryAlertThread) {
+	threads := make([]CanaryAlertThread, 0, len(s.AlertThreads)+1)
+	for _, t := range s.AlertThreads {
+		if t.Provider != thread.Provider {
+			threads = append(threads, t)
+		}
+	}
+	s.AlertThreads = append(threads, thread)
+}
+
+// SetCondition replaces the condition of the same type,
+// the transition time is kept if the condition status didn't change
+func (s *CanaryStatus) SetCondition(condition CanaryCondition) {
+	conditions := make([]CanaryCondition, 0, len(s.Conditions)+1)
+	for _, c := range s.Conditions {
+		if c.Type != condition.Type {
+			conditions = append(conditions, c)
+		} else if c.Status == condition.Status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+	}
+	s.Conditions = append(conditions, condition)
 }
@@ -72,6 +72,27 @@ func (in *AlertProviderCondition) DeepCopy() *AlertProviderCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertProviderDefault) DeepCopyInto(out *AlertProviderDefault) {
+	*out = *in
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]AlertEvent, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertProviderDefault.
+func (in *AlertProviderDefault) DeepCopy() *AlertProviderDefault {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertProviderDefault)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AlertProviderList) DeepCopyInto(out *AlertProviderList) {
 	*out = *in
@@ -113,6 +134,26 @@ func (in *AlertProviderSpec) DeepCopyInto(out *AlertProviderSpec) {
 		*out = new(v1.LocalObjectReference)
 		**out = **in
 	}
+	if in.TemplateRef != nil {
+		in, out := &in.TemplateRef, &out.TemplateRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExpectedStatusCodes != nil {
+		in, out := &in.ExpectedStatusCodes, &out.ExpectedStatusCodes
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.Throttle != nil {
+		in, out := &in.Throttle, &out.Throttle
+		*out = new(AlertProviderThrottle)
+		**out = **in
+	}
+	if in.DefaultAlert != nil {
+		in, out := &in.DefaultAlert, &out.DefaultAlert
+		*out = new(AlertProviderDefault)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -149,6 +190,22 @@ func (in *AlertProviderStatus) DeepCopy() *AlertProviderStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertProviderThrottle) DeepCopyInto(out *AlertProviderThrottle) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertProviderThrottle.
+func (in *AlertProviderThrottle) DeepCopy() *AlertProviderThrottle {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertProviderThrottle)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Canary) DeepCopyInto(out *Canary) {
 	*out = *in
@@ -180,6 +237,11 @@ func (in *Canary) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryAlert) DeepCopyInto(out *CanaryAlert) {
 	*out = *in
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]AlertEvent, len(*in))
+		copy(*out, *in)
+	}
 	out.ProviderRef = in.ProviderRef
 	return
 }
@@ -194,13 +256,31 @@ func (in *CanaryAlert) DeepCopy() *CanaryAlert {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryAlertThread) DeepCopyInto(out *CanaryAlertThread) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryAlertThread.
+func (in *CanaryAlertThread) DeepCopy() *CanaryAlertThread {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryAlertThread)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryAnalysis) DeepCopyInto(out *CanaryAnalysis) {
 	*out = *in
 	if in.Alerts != nil {
 		in, out := &in.Alerts, &out.Alerts
 		*out = make([]CanaryAlert, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Metrics != nil {
 		in, out := &in.Metrics, &out.Metrics
@@ -300,6 +380,18 @@ func (in *CanaryMetric) DeepCopyInto(out *CanaryMetric) {
 		*out = new(CrossNamespaceObjectReference)
 		**out = **in
 	}
+	if in.TemplateVariables != nil {
+		in, out := &in.TemplateVariables, &out.TemplateVariables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.GrpcErrorCodes != nil {
+		in, out := &in.GrpcErrorCodes, &out.GrpcErrorCodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -359,6 +451,11 @@ func (in *CanaryService) DeepCopyInto(out *CanaryService) {
 		*out = new(v1alpha3.CorsPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.VirtualServiceRef != nil {
+		in, out := &in.VirtualServiceRef, &out.VirtualServiceRef
+		*out = new(VirtualServiceRouteRef)
+		**out = **in
+	}
 	if in.Backends != nil {
 		in, out := &in.Backends, &out.Backends
 		*out = make([]string, len(*in))
@@ -380,6 +477,7 @@ func (in *CanaryService) DeepCopy() *CanaryService {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanarySpec) DeepCopyInto(out *CanarySpec) {
 	*out = *in
+	out.SourceRef = in.SourceRef
 	out.TargetRef = in.TargetRef
 	if in.AutoscalerRef != nil {
 		in, out := &in.AutoscalerRef, &out.AutoscalerRef
@@ -442,6 +540,11 @@ func (in *CanaryStatus) DeepCopyInto(out *CanaryStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AlertThreads != nil {
+		in, out := &in.AlertThreads, &out.AlertThreads
+		*out = make([]CanaryAlertThread, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -495,6 +598,21 @@ func (in *CanaryWebhook) DeepCopyInto(out *CanaryWebhook) {
 			}
 		}
 	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.TLSSecretRef != nil {
+		in, out := &in.TLSSecretRef, &out.TLSSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(CanaryWebhookRetry)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -511,6 +629,13 @@ func (in *CanaryWebhook) DeepCopy() *CanaryWebhook {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CanaryWebhookPayload) DeepCopyInto(out *CanaryWebhookPayload) {
 	*out = *in
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Metadata != nil {
 		in, out := &in.Metadata, &out.Metadata
 		*out = make(map[string]string, len(*in))
@@ -531,6 +656,149 @@ func (in *CanaryWebhookPayload) DeepCopy() *CanaryWebhookPayload {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryWebhookRetry) DeepCopyInto(out *CanaryWebhookRetry) {
+	*out = *in
+	if in.StatusCodes != nil {
+		in, out := &in.StatusCodes, &out.StatusCodes
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryWebhookRetry.
+func (in *CanaryWebhookRetry) DeepCopy() *CanaryWebhookRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryWebhookRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAlertProvider) DeepCopyInto(out *ClusterAlertProvider) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAlertProvider.
+func (in *ClusterAlertProvider) DeepCopy() *ClusterAlertProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAlertProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterAlertProvider) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAlertProviderList) DeepCopyInto(out *ClusterAlertProviderList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterAlertProvider, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAlertProviderList.
+func (in *ClusterAlertProviderList) DeepCopy() *ClusterAlertProviderList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAlertProviderList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterAlertProviderList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterMetricTemplate) DeepCopyInto(out *ClusterMetricTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterMetricTemplate.
+func (in *ClusterMetricTemplate) DeepCopy() *ClusterMetricTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterMetricTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterMetricTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterMetricTemplateList) DeepCopyInto(out *ClusterMetricTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterMetricTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterMetricTemplateList.
+func (in *ClusterMetricTemplateList) DeepCopy() *ClusterMetricTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterMetricTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterMetricTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CrossNamespaceObjectReference) DeepCopyInto(out *CrossNamespaceObjectReference) {
 	*out = *in
@@ -629,6 +897,34 @@ func (in *MetricTemplateList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetricTemplateModel) DeepCopyInto(out *MetricTemplateModel) {
 	*out = *in
+	if in.CanarySelector != nil {
+		in, out := &in.CanarySelector, &out.CanarySelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PrimarySelector != nil {
+		in, out := &in.PrimarySelector, &out.PrimarySelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -702,3 +998,19 @@ func (in *MetricTemplateStatus) DeepCopy() *MetricTemplateStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualServiceRouteRef) DeepCopyInto(out *VirtualServiceRouteRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualServiceRouteRef.
+func (in *VirtualServiceRouteRef) DeepCopy() *VirtualServiceRouteRef {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualServiceRouteRef)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -126,6 +126,15 @@ type VirtualServiceSpec struct {
 	// be applied to any port that is not a HTTP or TLS port. The first rule
 	// matching an incoming request is used.
 	Tcp []TCPRoute `json:"tcp,omitempty"`
+
+	// An ordered list of route rules for non-terminated TLS & HTTPS
+	// traffic. Routing is typically performed using the SNI value presented
+	// by the ClientHello message. TLS routes will be applied to platform
+	// service ports named 'https-*', 'tls-*', unterminated gateway ports using
+	// HTTPS/TLS protocols (i.e. with "passthrough" TLS mode) and service entry
+	// ports using HTTPS/TLS protocols. The first rule matching an incoming
+	// request is used.
+	Tls []TLSRoute `json:"tls,omitempty"`
 }
 
 // Destination indicates the network addressable service to which the
@@ -286,6 +295,12 @@ type Destination struct {
 // Describes match conditions and actions for routing HTTP/1.1, HTTP2, and
 // gRPC traffic. See VirtualService for usage examples.
 type HTTPRoute struct {
+	// The name assigned to the route for debugging purposes. The
+	// route's name will be concatenated with the match's name and will
+	// be logged in the access logs for requests matching this
+	// route/match.
+	Name string `json:"name,omitempty"`
+
 	// Match conditions to be satisfied for the rule to be
 	// activated. All conditions inside a single match block have AND
 	// semantics, while the list of match blocks have OR semantics. The rule
@@ -298,6 +313,12 @@ type HTTPRoute struct {
 	// service version determine the proportion of traffic it receives.
 	Route []DestinationWeight `json:"route,omitempty"`
 
+	// Delegate is used to specify the particular VirtualService which
+	// can be used to define delegate HTTPRoute. It can be set only when
+	// `Route` and `Redirect` are empty, and the route rules of the
+	// delegate VirtualService will be merged with that in the current one.
+	Delegate *Delegate `json:"delegate,omitempty"`
+
 	// A http rule can either redirect or forward (default) traffic. If
 	// traffic passthrough option is specified in the rule,
 	// route/redirect will be ignored. The redirect primitive can be used to
@@ -339,6 +360,18 @@ type HTTPRoute struct {
 	Headers *Headers `json:"headers,omitempty"`
 }
 
+// Delegate describes the delegate VirtualService. The delegate
+// VirtualService must not have hosts and gateways, only the root
+// VirtualService can be bound to gateways and hosts.
+type Delegate struct {
+	// Name specifies the name of the delegate VirtualService.
+	Name string `json:"name,omitempty"`
+
+	// Namespace specifies the namespace where the delegate VirtualService
+	// resides. By default, it is same to the root's.
+	Namespace string `json:"namespace,omitempty"`
+}
+
 // Percent specifies a percentage in the range of [0.0, 100.0].
 type Percent struct {
 	Value float64 `json:"value,omitempty"`
@@ -452,6 +485,13 @@ type HTTPMatchRequest struct {
 	// **Note:** The keys `uri`, `scheme`, `method`, and `authority` will be ignored.
 	Headers map[string]v1alpha1.StringMatch `json:"headers,omitempty"`
 
+	// Query parameters for matching.
+	//
+	// Ex:
+	// - For a query parameter like "?key=true", the map key would be "key" and
+	//   the string match could be defined as `exact: "true"`.
+	QueryParams map[string]v1alpha1.StringMatch `json:"queryParams,omitempty"`
+
 	// Specifies the ports on the host that is being addressed. Many services
 	// only expose a single port or label ports with the protocols they support,
 	// in these cases it is not required to explicitly select the port.
@@ -523,11 +563,75 @@ type TCPRoute struct {
 	// is matched if any one of the match blocks succeed.
 	Match []L4MatchAttributes `json:"match,omitempty"`
 
-	// The destination to which the connection should be forwarded to.
-	// Currently, only one destination is allowed for TCP services. When TCP
-	// weighted routing support is introduced in Envoy, multiple destinations
-	// with weights can be specified.
-	Route DestinationWeight `json:"route"`
+	// The destinations to which the connection should be forwarded to.
+	// Weights must add up to 100.
+	Route []DestinationWeight `json:"route"`
+}
+
+// Describes match conditions and actions for routing unterminated TLS
+// traffic (TLS/HTTPS). The following routing rule forwards unterminated
+// TLS traffic arriving at port 443 of gateway called "mygateway" to
+// internal services in the mesh based on the SNI value.
+//
+// ```yaml
+// apiVersion: networking.istio.io/v1alpha3
+// kind: VirtualService
+// metadata:
+//   name: bookinfo-sni
+// spec:
+//   hosts:
+//   - "*.bookinfo.com"
+//   gateways:
+//   - mygateway
+//   tls:
+//   - match:
+//     - port: 443
+//       sniHosts:
+//       - login.bookinfo.com
+//     route:
+//     - destination:
+//         host: login.prod.svc.cluster.local
+// ```
+type TLSRoute struct {
+	// REQUIRED. Match conditions to be satisfied for the rule to be
+	// activated. All conditions inside a single match block have AND
+	// semantics, while the list of match blocks have OR semantics. The rule
+	// is matched if any one of the match blocks succeed.
+	Match []TLSMatchAttributes `json:"match"`
+
+	// The destinations to which the connection should be forwarded to.
+	// Weights must add up to 100.
+	Route []DestinationWeight `json:"route"`
+}
+
+// TLS connection match attributes.
+type TLSMatchAttributes struct {
+	// REQUIRED. SNI (server name indicator) to match on. Wildcard prefixes
+	// can be used in the SNI value, e.g., *.com will match foo.example.com
+	// as well as example.com. An SNI value must be a subset (i.e., fall
+	// within the domain) of the corresponding virtual serivce's hosts.
+	SniHosts []string `json:"sniHosts"`
+
+	// IPv4 or IPv6 ip addresses of destination with optional subnet.  E.g.,
+	// a.b.c.d/xx form or just a.b.c.d.
+	DestinationSubnets []string `json:"destinationSubnets,omitempty"`
+
+	// Specifies the port on the host that is being addressed. Many services
+	// only expose a single port or label ports with the protocols they
+	// support, in these cases it is not required to explicitly select the
+	// port.
+	Port int `json:"port,omitempty"`
+
+	// One or more labels that constrain the applicability of a rule to
+	// workloads with the given labels. If the VirtualService has a list of
+	// gateways specified at the top, it should include the reserved gateway
+	// `mesh` in order for this field to be applicable.
+	SourceLabel map[string]string `json:"sourceLabel,omitempty"`
+
+	// Names of gateways where the rule should be applied to. Gateway names
+	// at the top of the VirtualService (if any) are overridden. The gateway
+	// match is independent of sourceLabels.
+	Gateways []string `json:"gateways,omitempty"`
 }
 
 // L4 connection match attributes. Note that L4 connection matching support
@@ -108,6 +108,22 @@ func (in *CorsPolicy) DeepCopy() *CorsPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Delegate) DeepCopyInto(out *Delegate) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Delegate.
+func (in *Delegate) DeepCopy() *Delegate {
+	if in == nil {
+		return nil
+	}
+	out := new(Delegate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Destination) DeepCopyInto(out *Destination) {
 	*out = *in
@@ -306,6 +322,13 @@ func (in *HTTPMatchRequest) DeepCopyInto(out *HTTPMatchRequest) {
 			(*out)[key] = val
 		}
 	}
+	if in.QueryParams != nil {
+		in, out := &in.QueryParams, &out.QueryParams
+		*out = make(map[string]v1alpha1.StringMatch, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.SourceLabels != nil {
 		in, out := &in.SourceLabels, &out.SourceLabels
 		*out = make(map[string]string, len(*in))
@@ -396,6 +419,11 @@ func (in *HTTPRoute) DeepCopyInto(out *HTTPRoute) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Delegate != nil {
+		in, out := &in.Delegate, &out.Delegate
+		*out = new(Delegate)
+		**out = **in
+	}
 	if in.Redirect != nil {
 		in, out := &in.Redirect, &out.Redirect
 		*out = new(HTTPRedirect)
@@ -740,7 +768,13 @@ func (in *TCPRoute) DeepCopyInto(out *TCPRoute) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	in.Route.DeepCopyInto(&out.Route)
+	if in.Route != nil {
+		in, out := &in.Route, &out.Route
+		*out = make([]DestinationWeight, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -770,6 +804,74 @@ func (in *TCPSettings) DeepCopy() *TCPSettings {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSMatchAttributes) DeepCopyInto(out *TLSMatchAttributes) {
+	*out = *in
+	if in.SniHosts != nil {
+		in, out := &in.SniHosts, &out.SniHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DestinationSubnets != nil {
+		in, out := &in.DestinationSubnets, &out.DestinationSubnets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SourceLabel != nil {
+		in, out := &in.SourceLabel, &out.SourceLabel
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSMatchAttributes.
+func (in *TLSMatchAttributes) DeepCopy() *TLSMatchAttributes {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSMatchAttributes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSRoute) DeepCopyInto(out *TLSRoute) {
+	*out = *in
+	if in.Match != nil {
+		in, out := &in.Match, &out.Match
+		*out = make([]TLSMatchAttributes, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Route != nil {
+		in, out := &in.Route, &out.Route
+		*out = make([]DestinationWeight, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSRoute.
+func (in *TLSRoute) DeepCopy() *TLSRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLSSettings) DeepCopyInto(out *TLSSettings) {
 	*out = *in
@@ -921,6 +1023,13 @@ func (in *VirtualServiceSpec) DeepCopyInto(out *VirtualServiceSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Tls != nil {
+		in, out := &in.Tls, &out.Tls
+		*out = make([]TLSRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -8,11 +8,14 @@ type Controller interface {
 	IsPrimaryReady(canary *flaggerv1.Canary) error
 	IsCanaryReady(canary *flaggerv1.Canary) (bool, error)
 	GetMetadata(canary *flaggerv1.Canary) (string, map[string]int32, error)
+	GetTargetMetadata(canary *flaggerv1.Canary) (*TargetMetadata, error)
 	SyncStatus(canary *flaggerv1.Canary, status flaggerv1.CanaryStatus) error
 	SetStatusFailedChecks(canary *flaggerv1.Canary, val int) error
 	SetStatusWeight(canary *flaggerv1.Canary, val int) error
 	SetStatusIterations(canary *flaggerv1.Canary, val int) error
 	SetStatusPhase(canary *flaggerv1.Canary, phase flaggerv1.CanaryPhase) error
+	SetStatusAlertThread(canary *flaggerv1.Canary, thread flaggerv1.CanaryAlertThread) error
+	SetStatusCondition(canary *flaggerv1.Canary, condition flaggerv1.CanaryCondition) error
 	Initialize(canary *flaggerv1.Canary) error
 	Promote(canary *flaggerv1.Canary) error
 	HasTargetChanged(canary *flaggerv1.Canary) (bool, error)
@@ -21,3 +24,19 @@ type Controller interface {
 	ScaleFromZero(canary *flaggerv1.Canary) error
 	Finalize(canary *flaggerv1.Canary) error
 }
+
+// TargetMetadata holds the pod selectors and container images
+// of the canary and primary workloads
+type TargetMetadata struct {
+	// CanarySelector is the pod label selector of the canary workload
+	CanarySelector map[string]string
+
+	// PrimarySelector is the pod label selector of the primary workload
+	PrimarySelector map[string]string
+
+	// CanaryImages maps the canary container names to their images
+	CanaryImages map[string]string
+
+	// PrimaryImages maps the primary container names to their images
+	PrimaryImages map[string]string
+}
@@ -13,6 +13,7 @@ import (
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
 	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
+	"github.com/weaveworks/flagger/pkg/internal"
 )
 
 var (
@@ -198,6 +199,34 @@ func (c *DaemonSetController) GetMetadata(cd *flaggerv1.Canary) (string, map[str
 	return label, ports, nil
 }
 
+// GetTargetMetadata returns the pod selectors and container images of the canary and primary daemonsets
+func (c *DaemonSetController) GetTargetMetadata(cd *flaggerv1.Canary) (*TargetMetadata, error) {
+	targetName := cd.Spec.TargetRef.Name
+	primaryName := internal.GetSourceName(cd)
+
+	canaryDae, err := c.kubeClient.AppsV1().DaemonSets(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("daemonset %s.%s get query error: %w", targetName, cd.Namespace, err)
+	}
+
+	metadata := &TargetMetadata{
+		CanarySelector: canaryDae.Spec.Selector.MatchLabels,
+		CanaryImages:   getImages(canaryDae.Spec.Template.Spec.Containers),
+	}
+
+	// the primary daemonset is missing until the canary is initialized
+	primaryDae, err := c.kubeClient.AppsV1().DaemonSets(cd.Namespace).Get(primaryName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("daemonset %s.%s get query error: %w", primaryName, cd.Namespace, err)
+	}
+	if err == nil {
+		metadata.PrimarySelector = primaryDae.Spec.Selector.MatchLabels
+		metadata.PrimaryImages = getImages(primaryDae.Spec.Template.Spec.Containers)
+	}
+
+	return metadata, nil
+}
+
 func (c *DaemonSetController) createPrimaryDaemonSet(cd *flaggerv1.Canary) error {
 	targetName := cd.Spec.TargetRef.Name
 	primaryName := fmt.Sprintf("%s-primary", cd.Spec.TargetRef.Name)
@@ -54,3 +54,13 @@ func (c *DaemonSetController) SetStatusIterations(cd *flaggerv1.Canary, val int)
 func (c *DaemonSetController) SetStatusPhase(cd *flaggerv1.Canary, phase flaggerv1.CanaryPhase) error {
 	return setStatusPhase(c.flaggerClient, cd, phase)
 }
+
+// SetStatusAlertThread updates the canary status alert thread of a provider
+func (c *DaemonSetController) SetStatusAlertThread(cd *flaggerv1.Canary, thread flaggerv1.CanaryAlertThread) error {
+	return setStatusAlertThread(c.flaggerClient, cd, thread)
+}
+
+// SetStatusCondition replaces the canary status condition of the same type
+func (c *DaemonSetController) SetStatusCondition(cd *flaggerv1.Canary, condition flaggerv1.CanaryCondition) error {
+	return setStatusCondition(c.flaggerClient, cd, condition)
+}
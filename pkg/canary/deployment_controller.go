@@ -15,6 +15,7 @@ import (
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
 	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
+	"github.com/weaveworks/flagger/pkg/internal"
 )
 
 // DeploymentController is managing the operations for Kubernetes Deployment kind
@@ -200,6 +201,35 @@ func (c *DeploymentController) GetMetadata(cd *flaggerv1.Canary) (string, map[st
 
 	return label, ports, nil
 }
+
+// GetTargetMetadata returns the pod selectors and container images of the canary and primary deployments
+func (c *DeploymentController) GetTargetMetadata(cd *flaggerv1.Canary) (*TargetMetadata, error) {
+	targetName := cd.Spec.TargetRef.Name
+	primaryName := internal.GetSourceName(cd)
+
+	canaryDep, err := c.kubeClient.AppsV1().Deployments(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("deployment %s.%s get query error: %w", targetName, cd.Namespace, err)
+	}
+
+	metadata := &TargetMetadata{
+		CanarySelector: canaryDep.Spec.Selector.MatchLabels,
+		CanaryImages:   getImages(canaryDep.Spec.Template.Spec.Containers),
+	}
+
+	// the primary deployment is missing until the canary is initialized
+	primaryDep, err := c.kubeClient.AppsV1().Deployments(cd.Namespace).Get(primaryName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("deployment %s.%s get query error: %w", primaryName, cd.Namespace, err)
+	}
+	if err == nil {
+		metadata.PrimarySelector = primaryDep.Spec.Selector.MatchLabels
+		metadata.PrimaryImages = getImages(primaryDep.Spec.Template.Spec.Containers)
+	}
+
+	return metadata, nil
+}
+
 func (c *DeploymentController) createPrimaryDeployment(cd *flaggerv1.Canary) error {
 	targetName := cd.Spec.TargetRef.Name
 	primaryName := fmt.Sprintf("%s-primary", cd.Spec.TargetRef.Name)
@@ -208,3 +208,20 @@ func TestDeploymentController_Finalize(t *testing.T) {
 		require.Equal(t, int32(1), *c.Spec.Replicas)
 	}
 }
+
+func TestDeploymentController_GetTargetMetadata(t *testing.T) {
+	mocks := newDeploymentFixture()
+	mocks.initializeCanary(t)
+
+	dep2 := newDeploymentControllerTestV2()
+	_, err := mocks.kubeClient.AppsV1().Deployments("default").Update(dep2)
+	require.NoError(t, err)
+
+	metadata, err := mocks.controller.GetTargetMetadata(mocks.canary)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"name": "podinfo"}, metadata.CanarySelector)
+	assert.Equal(t, map[string]string{"name": "podinfo-primary"}, metadata.PrimarySelector)
+	assert.Equal(t, "quay.io/stefanprodan/podinfo:1.2.1", metadata.CanaryImages["podinfo"])
+	assert.Equal(t, "quay.io/stefanprodan/podinfo:1.2.0", metadata.PrimaryImages["podinfo"])
+}
@@ -2,8 +2,10 @@ package canary
 
 import (
 	"go.uber.org/zap"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
 	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
 )
 
@@ -13,22 +15,30 @@ type Factory struct {
 	logger        *zap.SugaredLogger
 	configTracker Tracker
 	labels        []string
+	knativeClient dynamic.Interface
 }
 
 func NewFactory(kubeClient kubernetes.Interface,
 	flaggerClient clientset.Interface,
 	configTracker Tracker,
 	labels []string,
-	logger *zap.SugaredLogger) *Factory {
+	logger *zap.SugaredLogger,
+	knativeClient dynamic.Interface) *Factory {
 	return &Factory{
 		kubeClient:    kubeClient,
 		flaggerClient: flaggerClient,
 		logger:        logger,
 		configTracker: configTracker,
 		labels:        labels,
+		knativeClient: knativeClient,
 	}
 }
 
+// ConfigTracker returns the tracker of the ConfigMaps and Secrets referenced by the canary targets
+func (factory *Factory) ConfigTracker() Tracker {
+	return factory.configTracker
+}
+
 func (factory *Factory) Controller(kind string) Controller {
 	deploymentCtrl := &DeploymentController{
 		logger:        factory.logger,
@@ -49,6 +59,11 @@ func (factory *Factory) Controller(kind string) Controller {
 		kubeClient:    factory.kubeClient,
 		flaggerClient: factory.flaggerClient,
 	}
+	knativeCtrl := &KnativeController{
+		logger:        factory.logger,
+		flaggerClient: factory.flaggerClient,
+		knativeClient: factory.knativeClient,
+	}
 	extDeploymentController :=  &ExtDeploymentController{
 		deploymentCtrl,
 	}
@@ -59,6 +74,8 @@ func (factory *Factory) Controller(kind string) Controller {
 		return extDeploymentController
 	case "Service":
 		return serviceCtrl
+	case flaggerv1.KnativeServiceKind:
+		return knativeCtrl
 	default:
 		return deploymentCtrl
 	}
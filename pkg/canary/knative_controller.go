@@ -0,0 +1,325 @@
+package canary
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
+)
+
+// knativeServiceLabel is the label set by Knative Serving on the revisions of a service
+const knativeServiceLabel = "serving.knative.dev/service"
+
+var (
+	knativeServiceGVR  = schema.GroupVersionResource{Group: "serving.knative.dev", Version: "v1", Resource: "services"}
+	knativeRevisionGVR = schema.GroupVersionResource{Group: "serving.knative.dev", Version: "v1", Resource: "revisions"}
+)
+
+// KnativeController is managing the operations for Knative Serving services,
+// the primary is the last promoted revision and the canary is the revision under analysis
+type KnativeController struct {
+	flaggerClient clientset.Interface
+	knativeClient dynamic.Interface
+	logger        *zap.SugaredLogger
+}
+
+// SetStatusFailedChecks updates the canary failed checks counter
+func (c *KnativeController) SetStatusFailedChecks(cd *flaggerv1.Canary, val int) error {
+	return setStatusFailedChecks(c.flaggerClient, cd, val)
+}
+
+// SetStatusWeight updates the canary status weight value
+func (c *KnativeController) SetStatusWeight(cd *flaggerv1.Canary, val int) error {
+	return setStatusWeight(c.flaggerClient, cd, val)
+}
+
+// SetStatusIterations updates the canary status iterations value
+func (c *KnativeController) SetStatusIterations(cd *flaggerv1.Canary, val int) error {
+	return setStatusIterations(c.flaggerClient, cd, val)
+}
+
+// SetStatusPhase updates the canary status phase
+func (c *KnativeController) SetStatusPhase(cd *flaggerv1.Canary, phase flaggerv1.CanaryPhase) error {
+	return setStatusPhase(c.flaggerClient, cd, phase)
+}
+
+// SetStatusAlertThread updates the canary status alert thread of a provider
+func (c *KnativeController) SetStatusAlertThread(cd *flaggerv1.Canary, thread flaggerv1.CanaryAlertThread) error {
+	return setStatusAlertThread(c.flaggerClient, cd, thread)
+}
+
+// SetStatusCondition replaces the canary status condition of the same type
+func (c *KnativeController) SetStatusCondition(cd *flaggerv1.Canary, condition flaggerv1.CanaryCondition) error {
+	return setStatusCondition(c.flaggerClient, cd, condition)
+}
+
+// GetMetadata returns no pod label selector and ports, the Knative services are not fronted by Flagger
+func (c *KnativeController) GetMetadata(_ *flaggerv1.Canary) (string, map[string]int32, error) {
+	return "", nil, nil
+}
+
+// GetTargetMetadata returns the revision selectors and the container images of the primary and canary revisions,
+// the selectors match the revision_name label of the Knative queue-proxy metrics
+func (c *KnativeController) GetTargetMetadata(cd *flaggerv1.Canary) (*TargetMetadata, error) {
+	svc, err := c.getService(cd)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &TargetMetadata{}
+	canaryRevision, err := c.analyzedRevision(cd, svc)
+	if err != nil {
+		return nil, err
+	}
+	if canaryRevision != "" {
+		revision, err := c.getRevision(cd, canaryRevision)
+		if err != nil {
+			return nil, err
+		}
+		metadata.CanarySelector = map[string]string{flaggerv1.KnativeRevisionMetricLabel: canaryRevision}
+		metadata.CanaryImages = revisionImages(revision)
+	}
+	if primaryRevision := svc.GetAnnotations()[flaggerv1.KnativePrimaryRevisionAnnotation]; primaryRevision != "" {
+		revision, err := c.getRevision(cd, primaryRevision)
+		if err != nil {
+			return nil, err
+		}
+		metadata.PrimarySelector = map[string]string{flaggerv1.KnativeRevisionMetricLabel: primaryRevision}
+		metadata.PrimaryImages = revisionImages(revision)
+	}
+	return metadata, nil
+}
+
+// Initialize marks the latest created revision as primary if the service has no primary revision
+func (c *KnativeController) Initialize(cd *flaggerv1.Canary) error {
+	svc, err := c.getService(cd)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := svc.GetAnnotations()[flaggerv1.KnativePrimaryRevisionAnnotation]; ok {
+		return nil
+	}
+
+	if err := c.setPrimaryRevision(cd, svc); err != nil {
+		return err
+	}
+
+	c.logger.With("canary", fmt.Sprintf("%s.%s", cd.Name, cd.Namespace)).
+		Infof("Knative service %s.%s primary revision set to %s", svc.GetName(), cd.Namespace,
+			svc.GetAnnotations()[flaggerv1.KnativePrimaryRevisionAnnotation])
+	return nil
+}
+
+// Promote marks the analyzed revision as primary
+func (c *KnativeController) Promote(cd *flaggerv1.Canary) error {
+	svc, err := c.getService(cd)
+	if err != nil {
+		return err
+	}
+	return c.setPrimaryRevision(cd, svc)
+}
+
+func (c *KnativeController) setPrimaryRevision(cd *flaggerv1.Canary, svc *unstructured.Unstructured) error {
+	revision, err := c.analyzedRevision(cd, svc)
+	if err != nil {
+		return err
+	}
+	if revision == "" {
+		return fmt.Errorf("knative service %s.%s has no revision", svc.GetName(), cd.Namespace)
+	}
+
+	annotations := svc.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[flaggerv1.KnativePrimaryRevisionAnnotation] = revision
+	svc.SetAnnotations(annotations)
+
+	_, err = c.knativeClient.Resource(knativeServiceGVR).Namespace(cd.Namespace).Update(svc, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("knative service %s.%s update error: %w", svc.GetName(), cd.Namespace, err)
+	}
+	return nil
+}
+
+// analyzedRevision returns the revision recorded in the canary status by the last sync,
+// Knative may have created a newer revision since the analysis started
+func (c *KnativeController) analyzedRevision(cd *flaggerv1.Canary, svc *unstructured.Unstructured) (string, error) {
+	latest, _, _ := unstructured.NestedString(svc.Object, "status", "latestCreatedRevisionName")
+	if cd.Status.LastAppliedSpec == "" || computeHash(latest) == cd.Status.LastAppliedSpec {
+		return latest, nil
+	}
+
+	revisions, err := c.knativeClient.Resource(knativeRevisionGVR).Namespace(cd.Namespace).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", knativeServiceLabel, svc.GetName()),
+	})
+	if err != nil {
+		return "", fmt.Errorf("knative service %s.%s revisions list query error: %w", svc.GetName(), cd.Namespace, err)
+	}
+	for _, revision := range revisions.Items {
+		if computeHash(revision.GetName()) == cd.Status.LastAppliedSpec {
+			return revision.GetName(), nil
+		}
+	}
+	return "", fmt.Errorf("knative service %s.%s analyzed revision not found", svc.GetName(), cd.Namespace)
+}
+
+// HasTargetChanged returns true if Knative created a revision since the last analysis
+func (c *KnativeController) HasTargetChanged(cd *flaggerv1.Canary) (bool, error) {
+	svc, err := c.getService(cd)
+	if err != nil {
+		return false, err
+	}
+	revision, _, _ := unstructured.NestedString(svc.Object, "status", "latestCreatedRevisionName")
+	return hasSpecChanged(cd, revision)
+}
+
+// HaveDependenciesChanged returns false, the config of a revision is immutable
+func (c *KnativeController) HaveDependenciesChanged(_ *flaggerv1.Canary) (bool, error) {
+	return false, nil
+}
+
+// ScaleToZero does nothing, Knative scales the revisions without traffic to zero
+func (c *KnativeController) ScaleToZero(_ *flaggerv1.Canary) error {
+	return nil
+}
+
+func (c *KnativeController) ScaleFromZero(_ *flaggerv1.Canary) error {
+	return nil
+}
+
+// SyncStatus encodes the latest created revision name and updates the canary status
+func (c *KnativeController) SyncStatus(cd *flaggerv1.Canary, status flaggerv1.CanaryStatus) error {
+	svc, err := c.getService(cd)
+	if err != nil {
+		return err
+	}
+	revision, _, _ := unstructured.NestedString(svc.Object, "status", "latestCreatedRevisionName")
+	return syncCanaryStatus(c.flaggerClient, cd, status, revision, func(cdCopy *flaggerv1.Canary) {})
+}
+
+// IsPrimaryReady checks the ready condition of the primary revision
+func (c *KnativeController) IsPrimaryReady(cd *flaggerv1.Canary) error {
+	svc, err := c.getService(cd)
+	if err != nil {
+		return err
+	}
+
+	name := svc.GetAnnotations()[flaggerv1.KnativePrimaryRevisionAnnotation]
+	if name == "" {
+		return fmt.Errorf("knative service %s.%s has no primary revision", svc.GetName(), cd.Namespace)
+	}
+
+	revision, err := c.getRevision(cd, name)
+	if err != nil {
+		return err
+	}
+	if ready, _, message := revisionReady(revision); ready != "True" {
+		return fmt.Errorf("primary revision %s.%s not ready: %s", name, cd.Namespace, message)
+	}
+	return nil
+}
+
+// IsCanaryReady checks the ready condition of the latest created revision,
+// it returns a non retriable error if Knative failed to deploy the revision
+func (c *KnativeController) IsCanaryReady(cd *flaggerv1.Canary) (bool, error) {
+	svc, err := c.getService(cd)
+	if err != nil {
+		return true, err
+	}
+
+	name, _, _ := unstructured.NestedString(svc.Object, "status", "latestCreatedRevisionName")
+	if name == "" {
+		return true, fmt.Errorf("knative service %s.%s has no revision", svc.GetName(), cd.Namespace)
+	}
+
+	revision, err := c.getRevision(cd, name)
+	if err != nil {
+		return true, err
+	}
+	switch ready, reason, message := revisionReady(revision); ready {
+	case "True":
+		return true, nil
+	case "False":
+		return false, fmt.Errorf("canary revision %s.%s failed: %s %s", name, cd.Namespace, reason, message)
+	default:
+		return true, fmt.Errorf("canary revision %s.%s not ready: waiting for the revision to become ready", name, cd.Namespace)
+	}
+}
+
+// Finalize removes the primary revision annotation from the Knative service
+func (c *KnativeController) Finalize(cd *flaggerv1.Canary) error {
+	svc, err := c.getService(cd)
+	if err != nil {
+		return err
+	}
+
+	annotations := svc.GetAnnotations()
+	if _, ok := annotations[flaggerv1.KnativePrimaryRevisionAnnotation]; !ok {
+		return nil
+	}
+	delete(annotations, flaggerv1.KnativePrimaryRevisionAnnotation)
+	svc.SetAnnotations(annotations)
+
+	_, err = c.knativeClient.Resource(knativeServiceGVR).Namespace(cd.Namespace).Update(svc, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("knative service %s.%s update error: %w", svc.GetName(), cd.Namespace, err)
+	}
+	return nil
+}
+
+func (c *KnativeController) getService(cd *flaggerv1.Canary) (*unstructured.Unstructured, error) {
+	targetName := cd.Spec.TargetRef.Name
+	svc, err := c.knativeClient.Resource(knativeServiceGVR).Namespace(cd.Namespace).Get(targetName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("knative service %s.%s get query error: %w", targetName, cd.Namespace, err)
+	}
+	return svc, nil
+}
+
+func (c *KnativeController) getRevision(cd *flaggerv1.Canary, name string) (*unstructured.Unstructured, error) {
+	revision, err := c.knativeClient.Resource(knativeRevisionGVR).Namespace(cd.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("revision %s.%s get query error: %w", name, cd.Namespace, err)
+	}
+	return revision, nil
+}
+
+// revisionReady returns the status, reason and message of the revision ready condition
+func revisionReady(revision *unstructured.Unstructured) (status, reason, message string) {
+	conditions, _, _ := unstructured.NestedSlice(revision.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		status, _, _ = unstructured.NestedString(condition, "status")
+		reason, _, _ = unstructured.NestedString(condition, "reason")
+		message, _, _ = unstructured.NestedString(condition, "message")
+		return
+	}
+	return "Unknown", "", ""
+}
+
+// revisionImages maps the revision container names to their images
+func revisionImages(revision *unstructured.Unstructured) map[string]string {
+	images := make(map[string]string)
+	containers, _, _ := unstructured.NestedSlice(revision.Object, "spec", "containers")
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(container, "name")
+		image, _, _ := unstructured.NestedString(container, "image")
+		images[name] = image
+	}
+	return images
+}
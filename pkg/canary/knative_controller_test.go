@@ -0,0 +1,217 @@
+package canary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	fakeFlagger "github.com/weaveworks/flagger/pkg/client/clientset/versioned/fake"
+	"github.com/weaveworks/flagger/pkg/logger"
+)
+
+func newKnativeTestCanary() *flaggerv1.Canary {
+	return &flaggerv1.Canary{
+		TypeMeta: metav1.TypeMeta{APIVersion: flaggerv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "podinfo",
+		},
+		Spec: flaggerv1.CanarySpec{
+			Provider: "knative",
+			TargetRef: flaggerv1.CrossNamespaceObjectReference{
+				Name:       "podinfo",
+				APIVersion: "serving.knative.dev/v1",
+				Kind:       "Service",
+			},
+			Analysis: &flaggerv1.CanaryAnalysis{
+				Threshold:  10,
+				StepWeight: 10,
+				MaxWeight:  50,
+			},
+		},
+	}
+}
+
+func newKnativeTestService(latestCreated, latestReady string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "serving.knative.dev/v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name":      "podinfo",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"image": "ghcr.io/stefanprodan/podinfo:6.0.1"},
+						},
+					},
+				},
+			},
+			"status": map[string]interface{}{
+				"latestCreatedRevisionName": latestCreated,
+				"latestReadyRevisionName":   latestReady,
+			},
+		},
+	}
+}
+
+func newKnativeTestRevision(name, image, ready string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "serving.knative.dev/v1",
+			"kind":       "Revision",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "default",
+				"labels":    map[string]interface{}{knativeServiceLabel: "podinfo"},
+			},
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"name": "user-container", "image": image},
+				},
+			},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": ready, "reason": "ProgressDeadlineExceeded"},
+				},
+			},
+		},
+	}
+}
+
+func newKnativeTestController(objs ...runtime.Object) (*KnativeController, *flaggerv1.Canary) {
+	canary := newKnativeTestCanary()
+	log, _ := logger.NewLogger("debug")
+	return &KnativeController{
+		flaggerClient: fakeFlagger.NewSimpleClientset(canary),
+		knativeClient: fakeDynamic.NewSimpleDynamicClient(runtime.NewScheme(), objs...),
+		logger:        log,
+	}, canary
+}
+
+func TestFactory_KnativeController(t *testing.T) {
+	factory := NewFactory(nil, nil, nil, nil, nil, nil)
+	canary := newKnativeTestCanary()
+	assert.IsType(t, &KnativeController{}, factory.Controller(canary.GetTargetKind()))
+
+	canary.Spec.TargetRef.APIVersion = "v1"
+	assert.IsType(t, &ServiceController{}, factory.Controller(canary.GetTargetKind()))
+}
+
+func TestKnativeController_Promote(t *testing.T) {
+	ctrl, canary := newKnativeTestController(
+		newKnativeTestService("podinfo-00001", "podinfo-00001"),
+		newKnativeTestRevision("podinfo-00001", "ghcr.io/stefanprodan/podinfo:6.0.0", "True"),
+		newKnativeTestRevision("podinfo-00002", "ghcr.io/stefanprodan/podinfo:6.0.1", "True"),
+	)
+
+	err := ctrl.Initialize(canary)
+	require.NoError(t, err)
+	require.NoError(t, ctrl.IsPrimaryReady(canary))
+
+	svc, err := ctrl.getService(canary)
+	require.NoError(t, err)
+	assert.Equal(t, "podinfo-00001", svc.GetAnnotations()[flaggerv1.KnativePrimaryRevisionAnnotation])
+
+	err = ctrl.SyncStatus(canary, flaggerv1.CanaryStatus{Phase: flaggerv1.CanaryPhaseInitialized})
+	require.NoError(t, err)
+	canary, err = ctrl.flaggerClient.FlaggerV1beta1().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	changed, err := ctrl.HasTargetChanged(canary)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// a new revision is created and becomes ready
+	err = unstructured.SetNestedField(svc.Object, "podinfo-00002", "status", "latestCreatedRevisionName")
+	require.NoError(t, err)
+	err = unstructured.SetNestedField(svc.Object, "podinfo-00002", "status", "latestReadyRevisionName")
+	require.NoError(t, err)
+	_, err = ctrl.knativeClient.Resource(knativeServiceGVR).Namespace("default").Update(svc, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	changed, err = ctrl.HasTargetChanged(canary)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	// the new revision is recorded in the status when the analysis starts
+	err = ctrl.SyncStatus(canary, flaggerv1.CanaryStatus{Phase: flaggerv1.CanaryPhaseProgressing})
+	require.NoError(t, err)
+	canary, err = ctrl.flaggerClient.FlaggerV1beta1().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	// a newer revision is created during the analysis
+	_, err = ctrl.knativeClient.Resource(knativeRevisionGVR).Namespace("default").Create(
+		newKnativeTestRevision("podinfo-00003", "ghcr.io/stefanprodan/podinfo:6.0.2", "True"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	svc, err = ctrl.getService(canary)
+	require.NoError(t, err)
+	err = unstructured.SetNestedField(svc.Object, "podinfo-00003", "status", "latestCreatedRevisionName")
+	require.NoError(t, err)
+	err = unstructured.SetNestedField(svc.Object, "podinfo-00003", "status", "latestReadyRevisionName")
+	require.NoError(t, err)
+	_, err = ctrl.knativeClient.Resource(knativeServiceGVR).Namespace("default").Update(svc, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	metadata, err := ctrl.GetTargetMetadata(canary)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{flaggerv1.KnativeRevisionMetricLabel: "podinfo-00002"}, metadata.CanarySelector)
+	assert.Equal(t, map[string]string{flaggerv1.KnativeRevisionMetricLabel: "podinfo-00001"}, metadata.PrimarySelector)
+	assert.Equal(t, "ghcr.io/stefanprodan/podinfo:6.0.1", metadata.CanaryImages["user-container"])
+	assert.Equal(t, "ghcr.io/stefanprodan/podinfo:6.0.0", metadata.PrimaryImages["user-container"])
+
+	err = ctrl.Promote(canary)
+	require.NoError(t, err)
+
+	// the analyzed revision is promoted instead of the latest one
+	svc, err = ctrl.getService(canary)
+	require.NoError(t, err)
+	assert.Equal(t, "podinfo-00002", svc.GetAnnotations()[flaggerv1.KnativePrimaryRevisionAnnotation])
+
+	// the annotation is removed on finalization
+	err = ctrl.Finalize(canary)
+	require.NoError(t, err)
+
+	svc, err = ctrl.getService(canary)
+	require.NoError(t, err)
+	assert.NotContains(t, svc.GetAnnotations(), flaggerv1.KnativePrimaryRevisionAnnotation)
+}
+
+func TestKnativeController_IsCanaryReady(t *testing.T) {
+	ctrl, canary := newKnativeTestController(
+		newKnativeTestService("podinfo-00002", "podinfo-00001"),
+		newKnativeTestRevision("podinfo-00001", "ghcr.io/stefanprodan/podinfo:6.0.0", "True"),
+		newKnativeTestRevision("podinfo-00002", "ghcr.io/stefanprodan/podinfo:6.0.1", "Unknown"),
+	)
+
+	retriable, err := ctrl.IsCanaryReady(canary)
+	require.Error(t, err)
+	assert.True(t, retriable)
+
+	// Knative gave up on deploying the revision
+	_, err = ctrl.knativeClient.Resource(knativeRevisionGVR).Namespace("default").
+		Update(newKnativeTestRevision("podinfo-00002", "ghcr.io/stefanprodan/podinfo:6.0.1", "False"), metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	retriable, err = ctrl.IsCanaryReady(canary)
+	require.Error(t, err)
+	assert.False(t, retriable)
+	assert.Contains(t, err.Error(), "ProgressDeadlineExceeded")
+
+	_, err = ctrl.knativeClient.Resource(knativeRevisionGVR).Namespace("default").
+		Update(newKnativeTestRevision("podinfo-00002", "ghcr.io/stefanprodan/podinfo:6.0.1", "True"), metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	retriable, err = ctrl.IsCanaryReady(canary)
+	require.NoError(t, err)
+	assert.True(t, retriable)
+}
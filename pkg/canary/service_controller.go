@@ -41,11 +41,26 @@ func (c *ServiceController) SetStatusPhase(cd *flaggerv1.Canary, phase flaggerv1
 	return setStatusPhase(c.flaggerClient, cd, phase)
 }
 
+// SetStatusAlertThread updates the canary status alert thread of a provider
+func (c *ServiceController) SetStatusAlertThread(cd *flaggerv1.Canary, thread flaggerv1.CanaryAlertThread) error {
+	return setStatusAlertThread(c.flaggerClient, cd, thread)
+}
+
+// SetStatusCondition replaces the canary status condition of the same type
+func (c *ServiceController) SetStatusCondition(cd *flaggerv1.Canary, condition flaggerv1.CanaryCondition) error {
+	return setStatusCondition(c.flaggerClient, cd, condition)
+}
+
 // GetMetadata returns the pod label selector and svc ports
 func (c *ServiceController) GetMetadata(_ *flaggerv1.Canary) (string, map[string]int32, error) {
 	return "", nil, nil
 }
 
+// GetTargetMetadata returns empty metadata, services have no pod template
+func (c *ServiceController) GetTargetMetadata(_ *flaggerv1.Canary) (*TargetMetadata, error) {
+	return &TargetMetadata{}, nil
+}
+
 // Initialize creates or updates the primary and canary services to prepare for the canary release process targeted on the K8s service
 func (c *ServiceController) Initialize(cd *flaggerv1.Canary) (err error) {
 	targetName := cd.Spec.TargetRef.Name
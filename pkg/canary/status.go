@@ -172,6 +172,58 @@ func setStatusPhase(flaggerClient clientset.Interface, cd *flaggerv1.Canary, pha
 	return nil
 }
 
+func setStatusAlertThread(flaggerClient clientset.Interface, cd *flaggerv1.Canary, thread flaggerv1.CanaryAlertThread) error {
+	firstTry := true
+	name, ns := cd.GetName(), cd.GetNamespace()
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		if !firstTry {
+			cd, err = flaggerClient.FlaggerV1beta1().Canaries(ns).Get(name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("canary %s.%s get query failed: %w", name, ns, err)
+			}
+		}
+
+		cdCopy := cd.DeepCopy()
+		cdCopy.Status.SetAlertThread(thread)
+
+		if err = updateStatusWithUpgrade(flaggerClient, cdCopy); err != nil {
+			return fmt.Errorf("updateStatusWithUpgrade failed: %w", err)
+		}
+		firstTry = false
+		return
+	})
+	if err != nil {
+		return fmt.Errorf("failed after retries: %w", err)
+	}
+	return nil
+}
+
+func setStatusCondition(flaggerClient clientset.Interface, cd *flaggerv1.Canary, condition flaggerv1.CanaryCondition) error {
+	firstTry := true
+	name, ns := cd.GetName(), cd.GetNamespace()
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		if !firstTry {
+			cd, err = flaggerClient.FlaggerV1beta1().Canaries(ns).Get(name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("canary %s.%s get query failed: %w", name, ns, err)
+			}
+		}
+
+		cdCopy := cd.DeepCopy()
+		cdCopy.Status.SetCondition(condition)
+
+		if err = updateStatusWithUpgrade(flaggerClient, cdCopy); err != nil {
+			return fmt.Errorf("updateStatusWithUpgrade failed: %w", err)
+		}
+		firstTry = false
+		return
+	})
+	if err != nil {
+		return fmt.Errorf("failed after retries: %w", err)
+	}
+	return nil
+}
+
 // getStatusCondition returns a condition based on type
 func getStatusCondition(status flaggerv1.CanaryStatus, conditionType flaggerv1.CanaryConditionType) *flaggerv1.CanaryCondition {
 	for i := range status.Conditions {
@@ -188,15 +240,15 @@ func MakeStatusConditions(cd *flaggerv1.Canary,
 	phase flaggerv1.CanaryPhase) (bool, []flaggerv1.CanaryCondition) {
 	currentCondition := getStatusCondition(cd.Status, flaggerv1.PromotedType)
 
-	message := fmt.Sprintf("New %s detected, starting initialization.", cd.Spec.TargetRef.Kind)
+	message := fmt.Sprintf("New %s detected, starting initialization.", cd.GetTargetKind())
 	status := corev1.ConditionUnknown
 	switch phase {
 	case flaggerv1.CanaryPhaseInitializing:
 		status = corev1.ConditionUnknown
-		message = fmt.Sprintf("New %s detected, starting initialization.", cd.Spec.TargetRef.Kind)
+		message = fmt.Sprintf("New %s detected, starting initialization.", cd.GetTargetKind())
 	case flaggerv1.CanaryPhaseInitialized:
 		status = corev1.ConditionTrue
-		message = fmt.Sprintf("%s initialization completed.", cd.Spec.TargetRef.Kind)
+		message = fmt.Sprintf("%s initialization completed.", cd.GetTargetKind())
 	case flaggerv1.CanaryPhaseWaiting:
 		status = corev1.ConditionUnknown
 		message = "Waiting for approval."
@@ -214,7 +266,7 @@ func MakeStatusConditions(cd *flaggerv1.Canary,
 		message = "Canary analysis completed successfully, promotion finished."
 	case flaggerv1.CanaryPhaseFailed:
 		status = corev1.ConditionFalse
-		message = fmt.Sprintf("Canary analysis failed, %s scaled to zero.", cd.Spec.TargetRef.Kind)
+		message = fmt.Sprintf("Canary analysis failed, %s scaled to zero.", cd.GetTargetKind())
 	}
 
 	newCondition := &flaggerv1.CanaryCondition{
@@ -236,7 +288,15 @@ func MakeStatusConditions(cd *flaggerv1.Canary,
 		newCondition.LastTransitionTime = currentCondition.LastTransitionTime
 	}
 
-	return true, []flaggerv1.CanaryCondition{*newCondition}
+	// keep the conditions of other types like Drifted
+	conditions := []flaggerv1.CanaryCondition{*newCondition}
+	for _, c := range cd.Status.Conditions {
+		if c.Type != flaggerv1.PromotedType {
+			conditions = append(conditions, c)
+		}
+	}
+
+	return true, conditions
 }
 
 // updateStatusWithUpgrade tries to update the status sub-resource
@@ -98,3 +98,11 @@ func int32Default(i *int32) int32 {
 
 	return *i
 }
+
+func getImages(cs []corev1.Container) map[string]string {
+	images := make(map[string]string, len(cs))
+	for _, container := range cs {
+		images[container.Name] = container.Image
+	}
+	return images
+}
@@ -36,6 +36,14 @@ func (c *FakeFlaggerV1beta1) Canaries(namespace string) v1beta1.CanaryInterface
 	return &FakeCanaries{c, namespace}
 }
 
+func (c *FakeFlaggerV1beta1) ClusterAlertProviders() v1beta1.ClusterAlertProviderInterface {
+	return &FakeClusterAlertProviders{c}
+}
+
+func (c *FakeFlaggerV1beta1) ClusterMetricTemplates() v1beta1.ClusterMetricTemplateInterface {
+	return &FakeClusterMetricTemplates{c}
+}
+
 func (c *FakeFlaggerV1beta1) MetricTemplates(namespace string) v1beta1.MetricTemplateInterface {
 	return &FakeMetricTemplates{c, namespace}
 }
@@ -28,6 +28,8 @@ type FlaggerV1beta1Interface interface {
 	RESTClient() rest.Interface
 	AlertProvidersGetter
 	CanariesGetter
+	ClusterAlertProvidersGetter
+	ClusterMetricTemplatesGetter
 	MetricTemplatesGetter
 }
 
@@ -44,6 +46,14 @@ func (c *FlaggerV1beta1Client) Canaries(namespace string) CanaryInterface {
 	return newCanaries(c, namespace)
 }
 
+func (c *FlaggerV1beta1Client) ClusterAlertProviders() ClusterAlertProviderInterface {
+	return newClusterAlertProviders(c)
+}
+
+func (c *FlaggerV1beta1Client) ClusterMetricTemplates() ClusterMetricTemplateInterface {
+	return newClusterMetricTemplates(c)
+}
+
 func (c *FlaggerV1beta1Client) MetricTemplates(namespace string) MetricTemplateInterface {
 	return newMetricTemplates(c, namespace)
 }
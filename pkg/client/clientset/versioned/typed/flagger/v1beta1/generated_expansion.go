@@ -22,4 +22,8 @@ type AlertProviderExpansion interface{}
 
 type CanaryExpansion interface{}
 
+type ClusterAlertProviderExpansion interface{}
+
+type ClusterMetricTemplateExpansion interface{}
+
 type MetricTemplateExpansion interface{}
@@ -26,6 +26,7 @@ import (
 	versioned "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
 	appmesh "github.com/weaveworks/flagger/pkg/client/informers/externalversions/appmesh"
 	flagger "github.com/weaveworks/flagger/pkg/client/informers/externalversions/flagger"
+	gatewayapi "github.com/weaveworks/flagger/pkg/client/informers/externalversions/gatewayapi"
 	gloo "github.com/weaveworks/flagger/pkg/client/informers/externalversions/gloo"
 	internalinterfaces "github.com/weaveworks/flagger/pkg/client/informers/externalversions/internalinterfaces"
 	istio "github.com/weaveworks/flagger/pkg/client/informers/externalversions/istio"
@@ -179,6 +180,7 @@ type SharedInformerFactory interface {
 
 	Appmesh() appmesh.Interface
 	Flagger() flagger.Interface
+	Gateway() gatewayapi.Interface
 	Gloo() gloo.Interface
 	Networking() istio.Interface
 	Projectcontour() projectcontour.Interface
@@ -193,6 +195,10 @@ func (f *sharedInformerFactory) Flagger() flagger.Interface {
 	return flagger.New(f, f.namespace, f.tweakListOptions)
 }
 
+func (f *sharedInformerFactory) Gateway() gatewayapi.Interface {
+	return gatewayapi.New(f, f.namespace, f.tweakListOptions)
+}
+
 func (f *sharedInformerFactory) Gloo() gloo.Interface {
 	return gloo.New(f, f.namespace, f.tweakListOptions)
 }
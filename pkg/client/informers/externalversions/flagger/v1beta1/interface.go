@@ -28,6 +28,10 @@ type Interface interface {
 	AlertProviders() AlertProviderInformer
 	// Canaries returns a CanaryInformer.
 	Canaries() CanaryInformer
+	// ClusterAlertProviders returns a ClusterAlertProviderInformer.
+	ClusterAlertProviders() ClusterAlertProviderInformer
+	// ClusterMetricTemplates returns a ClusterMetricTemplateInformer.
+	ClusterMetricTemplates() ClusterMetricTemplateInformer
 	// MetricTemplates returns a MetricTemplateInformer.
 	MetricTemplates() MetricTemplateInformer
 }
@@ -53,6 +57,16 @@ func (v *version) Canaries() CanaryInformer {
 	return &canaryInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// ClusterAlertProviders returns a ClusterAlertProviderInformer.
+func (v *version) ClusterAlertProviders() ClusterAlertProviderInformer {
+	return &clusterAlertProviderInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// ClusterMetricTemplates returns a ClusterMetricTemplateInformer.
+func (v *version) ClusterMetricTemplates() ClusterMetricTemplateInformer {
+	return &clusterMetricTemplateInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // MetricTemplates returns a MetricTemplateInformer.
 func (v *version) MetricTemplates() MetricTemplateInformer {
 	return &metricTemplateInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -23,6 +23,7 @@ import (
 
 	v1beta1 "github.com/weaveworks/flagger/pkg/apis/appmesh/v1beta1"
 	flaggerv1beta1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	gatewayapiv1beta1 "github.com/weaveworks/flagger/pkg/apis/gatewayapi/v1beta1"
 	v1 "github.com/weaveworks/flagger/pkg/apis/gloo/v1"
 	v1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
 	projectcontourv1 "github.com/weaveworks/flagger/pkg/apis/projectcontour/v1"
@@ -71,9 +72,17 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Flagger().V1beta1().AlertProviders().Informer()}, nil
 	case flaggerv1beta1.SchemeGroupVersion.WithResource("canaries"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Flagger().V1beta1().Canaries().Informer()}, nil
+	case flaggerv1beta1.SchemeGroupVersion.WithResource("clusteralertproviders"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Flagger().V1beta1().ClusterAlertProviders().Informer()}, nil
+	case flaggerv1beta1.SchemeGroupVersion.WithResource("clustermetrictemplates"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Flagger().V1beta1().ClusterMetricTemplates().Informer()}, nil
 	case flaggerv1beta1.SchemeGroupVersion.WithResource("metrictemplates"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Flagger().V1beta1().MetricTemplates().Informer()}, nil
 
+		// Group=gateway.networking.k8s.io, Version=v1beta1
+	case gatewayapiv1beta1.SchemeGroupVersion.WithResource("httproutes"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Gateway().V1beta1().HTTPRoutes().Informer()}, nil
+
 		// Group=gloo.solo.io, Version=v1
 	case v1.SchemeGroupVersion.WithResource("upstreamgroups"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Gloo().V1().UpstreamGroups().Informer()}, nil
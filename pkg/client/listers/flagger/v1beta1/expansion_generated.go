@@ -34,6 +34,14 @@ type CanaryListerExpansion interface{}
 // CanaryNamespaceLister.
 type CanaryNamespaceListerExpansion interface{}
 
+// ClusterAlertProviderListerExpansion allows custom methods to be added to
+// ClusterAlertProviderLister.
+type ClusterAlertProviderListerExpansion interface{}
+
+// ClusterMetricTemplateListerExpansion allows custom methods to be added to
+// ClusterMetricTemplateLister.
+type ClusterMetricTemplateListerExpansion interface{}
+
 // MetricTemplateListerExpansion allows custom methods to be added to
 // MetricTemplateLister.
 type MetricTemplateListerExpansion interface{}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+)
+
+// Event webhook payload formats
+const (
+	EventWebhookFormatJSON              = "json"
+	EventWebhookFormatCloudEvents       = "cloudevents"
+	EventWebhookFormatCloudEventsBinary = "cloudevents-binary"
+)
+
+// CloudEvents types of the canary events
+const (
+	CloudEventInitialized = "flagger.canary.initialized"
+	CloudEventProgressing = "flagger.canary.progressing"
+	CloudEventPromoting   = "flagger.canary.promoting"
+	CloudEventPromoted    = "flagger.canary.promoted"
+	CloudEventRolledBack  = "flagger.canary.rolledback"
+	CloudEventHalted      = "flagger.canary.halted"
+	CloudEventError       = "flagger.canary.error"
+	CloudEventTerminated  = "flagger.canary.terminated"
+)
+
+// cloudEventType returns the CloudEvent type of the informational events based on the canary phase
+func cloudEventType(phase flaggerv1.CanaryPhase) string {
+	switch phase {
+	case "", flaggerv1.CanaryPhaseInitializing, flaggerv1.CanaryPhaseInitialized:
+		return CloudEventInitialized
+	case flaggerv1.CanaryPhasePromoting, flaggerv1.CanaryPhaseFinalising:
+		return CloudEventPromoting
+	case flaggerv1.CanaryPhaseSucceeded:
+		return CloudEventPromoted
+	case flaggerv1.CanaryPhaseFailed:
+		return CloudEventRolledBack
+	case flaggerv1.CanaryPhaseTerminating, flaggerv1.CanaryPhaseTerminated:
+		return CloudEventTerminated
+	default:
+		return CloudEventProgressing
+	}
+}
+
+// IsEventWebhookFormat returns true if the event webhook payload format is supported
+func IsEventWebhookFormat(format string) bool {
+	switch format {
+	case "", EventWebhookFormatJSON, EventWebhookFormatCloudEvents, EventWebhookFormatCloudEventsBinary:
+		return true
+	}
+	return false
+}
+
+// CloudEvent is a CloudEvents 1.0 event in the structured content mode
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject"`
+	Time            string          `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            CanaryEventData `json:"data"`
+}
+
+// CanaryEventData is the data of the canary CloudEvents
+type CanaryEventData struct {
+	Name         string                `json:"name"`
+	Namespace    string                `json:"namespace"`
+	Phase        flaggerv1.CanaryPhase `json:"phase"`
+	Message      string                `json:"message"`
+	EventType    string                `json:"eventType"`
+	Weight       int                   `json:"weight"`
+	Iterations   int                   `json:"iterations"`
+	FailedChecks int                   `json:"failedChecks"`
+	Revision     string                `json:"revision,omitempty"`
+}
+
+// NewCloudEvent returns a CloudEvent with the canary as subject and its status as data
+func NewCloudEvent(r *flaggerv1.Canary, ceType, message, eventtype string) CloudEvent {
+	return CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              string(uuid.NewUUID()),
+		Source:          fmt.Sprintf("/apis/flagger.app/v1beta1/namespaces/%s/canaries", r.Namespace),
+		Type:            ceType,
+		Subject:         r.Name,
+		Time:            time.Now().UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data: CanaryEventData{
+			Name:         r.Name,
+			Namespace:    r.Namespace,
+			Phase:        r.Status.Phase,
+			Message:      message,
+			EventType:    eventtype,
+			Weight:       r.Status.CanaryWeight,
+			Iterations:   r.Status.Iterations,
+			FailedChecks: r.Status.FailedChecks,
+			Revision:     r.Status.LastAppliedSpec,
+		},
+	}
+}
+
+// CallCloudEventWebhook posts the canary event as a CloudEvent,
+// in binary mode the event attributes are sent as ce- headers and the data as body
+func CallCloudEventWebhook(r *flaggerv1.Canary, webhook, ceType, message, eventtype string, binary bool) error {
+	event := NewCloudEvent(r, ceType, message, eventtype)
+	if !binary {
+		headers := map[string]string{"Content-Type": "application/cloudevents+json"}
+		return postWebhook(webhook, event, webhookOptions{headers: headers}, "5s")
+	}
+
+	headers := map[string]string{
+		"Content-Type":   event.DataContentType,
+		"ce-specversion": event.SpecVersion,
+		"ce-id":          event.ID,
+		"ce-source":      event.Source,
+		"ce-type":        event.Type,
+		"ce-subject":     event.Subject,
+		"ce-time":        event.Time,
+	}
+	return postWebhook(webhook, event.Data, webhookOptions{headers: headers}, "5s")
+}
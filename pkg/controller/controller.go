@@ -12,6 +12,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -34,29 +35,40 @@ const controllerAgentName = "flagger"
 
 // Controller is managing the canary objects and schedules canary deployments
 type Controller struct {
-	kubeClient       kubernetes.Interface
-	flaggerClient    clientset.Interface
-	flaggerInformers Informers
-	flaggerSynced    cache.InformerSynced
-	flaggerWindow    time.Duration
-	workqueue        workqueue.RateLimitingInterface
-	eventRecorder    record.EventRecorder
-	logger           *zap.SugaredLogger
-	canaries         *sync.Map
-	jobs             map[string]CanaryJob
-	recorder         metrics.Recorder
-	notifier         notifier.Interface
-	canaryFactory    *canary.Factory
-	routerFactory    *router.Factory
-	observerFactory  *observers.Factory
-	meshProvider     string
-	eventWebhook     string
+	kubeClient         kubernetes.Interface
+	flaggerClient      clientset.Interface
+	flaggerInformers   Informers
+	flaggerSynced      cache.InformerSynced
+	flaggerWindow      time.Duration
+	workqueue          workqueue.RateLimitingInterface
+	eventRecorder      record.EventRecorder
+	logger             *zap.SugaredLogger
+	canaries           *sync.Map
+	failedChecks       *sync.Map
+	webhookAlerts      *sync.Map
+	jobs               map[string]CanaryJob
+	recorder           metrics.Recorder
+	notifier           notifier.Interface
+	notifierThrottle   notifier.ThrottleOptions
+	alertThrottler     *notifier.Throttler
+	webhookClients     *sync.Map
+	stopCh             <-chan struct{}
+	canaryFactory      *canary.Factory
+	routerFactory      *router.Factory
+	observerFactory    *observers.Factory
+	meshProvider       string
+	eventWebhook       string
+	eventWebhookFormat string
+	clusterNamespace   string
 }
 
 type Informers struct {
-	CanaryInformer flaggerinformers.CanaryInformer
-	MetricInformer flaggerinformers.MetricTemplateInformer
-	AlertInformer  flaggerinformers.AlertProviderInformer
+	CanaryInformer        flaggerinformers.CanaryInformer
+	MetricInformer        flaggerinformers.MetricTemplateInformer
+	AlertInformer         flaggerinformers.AlertProviderInformer
+	ClusterMetricInformer flaggerinformers.ClusterMetricTemplateInformer
+	ClusterAlertInformer  flaggerinformers.ClusterAlertProviderInformer
+	SecretInformer        coreinformers.SecretInformer
 }
 
 func NewController(
@@ -65,13 +77,16 @@ func NewController(
 	flaggerInformers Informers,
 	flaggerWindow time.Duration,
 	logger *zap.SugaredLogger,
-	notifier notifier.Interface,
+	notifierClient notifier.Interface,
+	notifierThrottle notifier.ThrottleOptions,
 	canaryFactory *canary.Factory,
 	routerFactory *router.Factory,
 	observerFactory *observers.Factory,
 	meshProvider string,
 	version string,
 	eventWebhook string,
+	eventWebhookFormat string,
+	clusterNamespace string,
 ) *Controller {
 	logger.Debug("Creating event broadcaster")
 	flaggerscheme.AddToScheme(scheme.Scheme)
@@ -86,23 +101,30 @@ func NewController(
 	recorder.SetInfo(version, meshProvider)
 
 	ctrl := &Controller{
-		kubeClient:       kubeClient,
-		flaggerClient:    flaggerClient,
-		flaggerInformers: flaggerInformers,
-		flaggerSynced:    flaggerInformers.CanaryInformer.Informer().HasSynced,
-		workqueue:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerAgentName),
-		eventRecorder:    eventRecorder,
-		logger:           logger,
-		canaries:         new(sync.Map),
-		jobs:             map[string]CanaryJob{},
-		flaggerWindow:    flaggerWindow,
-		observerFactory:  observerFactory,
-		recorder:         recorder,
-		notifier:         notifier,
-		canaryFactory:    canaryFactory,
-		routerFactory:    routerFactory,
-		meshProvider:     meshProvider,
-		eventWebhook:     eventWebhook,
+		kubeClient:         kubeClient,
+		flaggerClient:      flaggerClient,
+		flaggerInformers:   flaggerInformers,
+		flaggerSynced:      flaggerInformers.CanaryInformer.Informer().HasSynced,
+		workqueue:          workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerAgentName),
+		eventRecorder:      eventRecorder,
+		logger:             logger,
+		canaries:           new(sync.Map),
+		failedChecks:       new(sync.Map),
+		webhookAlerts:      new(sync.Map),
+		jobs:               map[string]CanaryJob{},
+		flaggerWindow:      flaggerWindow,
+		observerFactory:    observerFactory,
+		recorder:           recorder,
+		notifier:           notifierClient,
+		notifierThrottle:   notifierThrottle,
+		alertThrottler:     notifier.NewThrottler(),
+		webhookClients:     new(sync.Map),
+		canaryFactory:      canaryFactory,
+		routerFactory:      routerFactory,
+		meshProvider:       meshProvider,
+		eventWebhook:       eventWebhook,
+		eventWebhookFormat: eventWebhookFormat,
+		clusterNamespace:   clusterNamespace,
 	}
 
 	flaggerInformers.CanaryInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -163,6 +185,8 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	defer c.workqueue.ShutDown()
 
 	c.logger.Info("Starting operator")
+	// the webhook retries are cancelled on shutdown
+	c.stopCh = stopCh
 
 	for i := 0; i < threadiness; i++ {
 		go wait.Until(func() {
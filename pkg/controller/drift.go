@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/canary"
+	"github.com/weaveworks/flagger/pkg/router"
+)
+
+// syncDriftCondition sets the Drifted condition when the routing objects have been changed by others
+// and clears it once the routing objects are in sync
+func (c *Controller) syncDriftCondition(cd *flaggerv1.Canary, canaryController canary.Controller, err error) {
+	var drift *router.DriftError
+	if !errors.As(err, &drift) {
+		if err == nil && isDrifted(cd) {
+			c.setDriftCondition(cd, canaryController, corev1.ConditionFalse, "Synced", "Routing objects are in sync.")
+		}
+		return
+	}
+
+	reason := "Reported"
+	if drift.Reasserted {
+		reason = "Reasserted"
+		c.recordEventWarningf(cd, "%v", err)
+	}
+	c.setDriftCondition(cd, canaryController, corev1.ConditionTrue, reason, drift.Error())
+}
+
+func (c *Controller) setDriftCondition(cd *flaggerv1.Canary, canaryController canary.Controller,
+	status corev1.ConditionStatus, reason string, message string) {
+	condition := flaggerv1.CanaryCondition{
+		Type:               flaggerv1.DriftedType,
+		Status:             status,
+		LastUpdateTime:     metav1.Now(),
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+	if err := canaryController.SetStatusCondition(cd, condition); err != nil {
+		c.recordEventErrorf(cd, "%v", err)
+	}
+}
+
+// isDrifted returns true if the canary has a Drifted condition set to true
+func isDrifted(cd *flaggerv1.Canary) bool {
+	for _, condition := range cd.Status.Conditions {
+		if condition.Type == flaggerv1.DriftedType {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
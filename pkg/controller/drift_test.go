@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/router"
+)
+
+func getCondition(cd *flaggerv1.Canary, conditionType flaggerv1.CanaryConditionType) *flaggerv1.CanaryCondition {
+	for _, c := range cd.Status.Conditions {
+		if c.Type == conditionType {
+			return &c
+		}
+	}
+	return nil
+}
+
+func TestController_SyncDriftCondition(t *testing.T) {
+	mocks := newDeploymentFixture(nil)
+
+	// initializing
+	mocks.ctrl.advanceCanary("podinfo", "default")
+
+	cd, err := mocks.flaggerClient.FlaggerV1beta1().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	drift := &router.DriftError{
+		Kind:       "VirtualService",
+		Name:       "podinfo",
+		Namespace:  "default",
+		Conflicts:  []string{`.spec.http conflict with "kubectl-edit"`},
+		Reasserted: true,
+	}
+	mocks.ctrl.syncDriftCondition(cd, mocks.deployer, drift)
+
+	cd, err = mocks.flaggerClient.FlaggerV1beta1().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	condition := getCondition(cd, flaggerv1.DriftedType)
+	require.NotNil(t, condition)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Equal(t, "Reasserted", condition.Reason)
+	assert.Contains(t, condition.Message, "kubectl-edit")
+
+	// the Drifted condition is kept on phase changes
+	err = mocks.deployer.SetStatusPhase(cd, flaggerv1.CanaryPhaseProgressing)
+	require.NoError(t, err)
+
+	cd, err = mocks.flaggerClient.FlaggerV1beta1().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, getCondition(cd, flaggerv1.DriftedType))
+	assert.Equal(t, string(flaggerv1.CanaryPhaseProgressing), getCondition(cd, flaggerv1.PromotedType).Reason)
+
+	// the condition is cleared once the routing objects are in sync
+	mocks.ctrl.syncDriftCondition(cd, mocks.deployer, nil)
+
+	cd, err = mocks.flaggerClient.FlaggerV1beta1().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	condition = getCondition(cd, flaggerv1.DriftedType)
+	require.NotNil(t, condition)
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+}
@@ -2,39 +2,62 @@ package controller
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	corev1 "k8s.io/api/core/v1"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/metrics/observers"
 	"github.com/weaveworks/flagger/pkg/notifier"
 )
 
 func (c *Controller) recordEventInfof(r *flaggerv1.Canary, template string, args ...interface{}) {
 	c.logger.With("canary", fmt.Sprintf("%s.%s", r.Name, r.Namespace)).Infof(template, args...)
 	c.eventRecorder.Event(r, corev1.EventTypeNormal, "Synced", fmt.Sprintf(template, args...))
-	c.sendEventToWebhook(r, corev1.EventTypeNormal, template, args)
+	c.sendEventToWebhook(r, corev1.EventTypeNormal, cloudEventType(r.Status.Phase), template, args)
 }
 
 func (c *Controller) recordEventErrorf(r *flaggerv1.Canary, template string, args ...interface{}) {
 	c.logger.With("canary", fmt.Sprintf("%s.%s", r.Name, r.Namespace)).Errorf(template, args...)
 	c.eventRecorder.Event(r, corev1.EventTypeWarning, "Synced", fmt.Sprintf(template, args...))
-	c.sendEventToWebhook(r, corev1.EventTypeWarning, template, args)
+	c.sendEventToWebhook(r, corev1.EventTypeWarning, CloudEventError, template, args)
 }
 
 func (c *Controller) recordEventWarningf(r *flaggerv1.Canary, template string, args ...interface{}) {
 	c.logger.With("canary", fmt.Sprintf("%s.%s", r.Name, r.Namespace)).Infof(template, args...)
 	c.eventRecorder.Event(r, corev1.EventTypeWarning, "Synced", fmt.Sprintf(template, args...))
-	c.sendEventToWebhook(r, corev1.EventTypeWarning, template, args)
+	c.sendEventToWebhook(r, corev1.EventTypeWarning, cloudEventType(r.Status.Phase), template, args)
 }
 
-func (c *Controller) sendEventToWebhook(r *flaggerv1.Canary, eventType, template string, args []interface{}) {
+func (c *Controller) recordEventHaltf(r *flaggerv1.Canary, template string, args ...interface{}) {
+	c.logger.With("canary", fmt.Sprintf("%s.%s", r.Name, r.Namespace)).Infof(template, args...)
+	c.eventRecorder.Event(r, corev1.EventTypeWarning, "Synced", fmt.Sprintf(template, args...))
+	c.sendEventToWebhook(r, corev1.EventTypeWarning, CloudEventHalted, template, args)
+}
+
+func (c *Controller) recordEventPromotedf(r *flaggerv1.Canary, template string, args ...interface{}) {
+	c.logger.With("canary", fmt.Sprintf("%s.%s", r.Name, r.Namespace)).Infof(template, args...)
+	c.eventRecorder.Event(r, corev1.EventTypeNormal, "Synced", fmt.Sprintf(template, args...))
+	c.sendEventToWebhook(r, corev1.EventTypeNormal, CloudEventPromoted, template, args)
+}
+
+func (c *Controller) recordEventRolledBackf(r *flaggerv1.Canary, template string, args ...interface{}) {
+	c.logger.With("canary", fmt.Sprintf("%s.%s", r.Name, r.Namespace)).Infof(template, args...)
+	c.eventRecorder.Event(r, corev1.EventTypeWarning, "Synced", fmt.Sprintf(template, args...))
+	c.sendEventToWebhook(r, corev1.EventTypeWarning, CloudEventRolledBack, template, args)
+}
+
+func (c *Controller) sendEventToWebhook(r *flaggerv1.Canary, eventType, ceType, template string, args []interface{}) {
 	webhookOverride := false
 	for _, canaryWebhook := range r.GetAnalysis().Webhooks {
 		if canaryWebhook.Type == flaggerv1.EventHook {
 			webhookOverride = true
-			err := CallEventWebhook(r, canaryWebhook.URL, fmt.Sprintf(template, args...), eventType)
+			err := c.callEventWebhook(r, canaryWebhook.URL, fmt.Sprintf(template, args...), eventType, ceType)
 			if err != nil {
 				c.logger.With("canary", fmt.Sprintf("%s.%s", r.Name, r.Namespace)).Errorf("error sending event to webhook: %s", err)
 			}
@@ -42,22 +65,52 @@ func (c *Controller) sendEventToWebhook(r *flaggerv1.Canary, eventType, template
 	}
 
 	if c.eventWebhook != "" && !webhookOverride {
-		err := CallEventWebhook(r, c.eventWebhook, fmt.Sprintf(template, args...), eventType)
+		err := c.callEventWebhook(r, c.eventWebhook, fmt.Sprintf(template, args...), eventType, ceType)
 		if err != nil {
 			c.logger.With("canary", fmt.Sprintf("%s.%s", r.Name, r.Namespace)).Errorf("error sending event to webhook: %s", err)
 		}
 	}
 }
 
-func (c *Controller) alert(canary *flaggerv1.Canary, message string, metadata bool, severity flaggerv1.AlertSeverity) {
+// callEventWebhook posts the event in the payload format set with the -event-webhook-format flag
+func (c *Controller) callEventWebhook(r *flaggerv1.Canary, webhook, message, eventType, ceType string) error {
+	switch c.eventWebhookFormat {
+	case EventWebhookFormatCloudEvents:
+		return CallCloudEventWebhook(r, webhook, ceType, message, eventType, false)
+	case EventWebhookFormatCloudEventsBinary:
+		return CallCloudEventWebhook(r, webhook, ceType, message, eventType, true)
+	default:
+		return CallEventWebhook(r, webhook, message, eventType)
+	}
+}
+
+func (c *Controller) alert(canary *flaggerv1.Canary, message string, metadata bool, severity flaggerv1.AlertSeverity, kind notifier.EventKind) {
 	var fields []notifier.Field
 	if metadata {
 		fields = alertMetadata(canary)
 	}
+	fields = append(fields, c.alertContext(canary, kind)...)
+
+	// use the namespace default alerts when the canary has none
+	alerts := canary.GetAnalysis().Alerts
+	if len(alerts) == 0 {
+		alerts = c.defaultAlerts(canary.Namespace)
+	}
 
 	// send alert with the global notifier
-	if len(canary.GetAnalysis().Alerts) == 0 {
-		err := c.notifier.Post(canary.Name, canary.Namespace, message, fields, string(severity))
+	if len(alerts) == 0 {
+		if isOptInEvent(kind) {
+			return
+		}
+		// suppress repeated alerts with the -alerts-throttle-* flags
+		event := alertEvent(canary, message, fields, severity, kind)
+		if c.notifierThrottle != (notifier.ThrottleOptions{}) {
+			var ok bool
+			if event, ok = c.throttleAlert(canary, "global", event, c.notifierThrottle); !ok {
+				return
+			}
+		}
+		err := notifier.Send(c.notifier, event)
 		if err != nil {
 			c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
 				Errorf("alert can't be sent: %v", err)
@@ -67,20 +120,9 @@ func (c *Controller) alert(canary *flaggerv1.Canary, message string, metadata bo
 	}
 
 	// send canary alerts
-	for _, alert := range canary.GetAnalysis().Alerts {
-		// determine if alert should be sent based on severity level
-		shouldAlert := false
-		if alert.Severity == flaggerv1.SeverityInfo {
-			shouldAlert = true
-		} else {
-			if severity == alert.Severity {
-				shouldAlert = true
-			}
-			if severity == flaggerv1.SeverityWarn && alert.Severity == flaggerv1.SeverityError {
-				shouldAlert = true
-			}
-		}
-		if !shouldAlert {
+	for _, alert := range alerts {
+		// the halts are posted to the threads of the canary revisions even if the alert doesn't subscribe to them
+		if !shouldAlert(alert, severity, kind) && kind != notifier.EventKindHalted {
 			continue
 		}
 
@@ -91,54 +133,138 @@ func (c *Controller) alert(canary *flaggerv1.Canary, message string, metadata bo
 		}
 
 		// find alert provider
-		provider, err := c.flaggerInformers.AlertInformer.Lister().AlertProviders(providerNamespace).Get(alert.ProviderRef.Name)
-		if err != nil {
-			c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
-				Errorf("alert provider %s.%s error: %v", alert.ProviderRef.Name, providerNamespace, err)
-			continue
+		var providerSpec flaggerv1.AlertProviderSpec
+		if alert.ProviderRef.Kind == flaggerv1.ClusterAlertProviderKind {
+			// cluster alert providers read their secrets from the Flagger namespace
+			providerNamespace = c.clusterNamespace
+			provider, err := c.flaggerInformers.ClusterAlertInformer.Lister().Get(alert.ProviderRef.Name)
+			if err != nil {
+				c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+					Errorf("cluster alert provider %s error: %v", alert.ProviderRef.Name, err)
+				continue
+			}
+			providerSpec = provider.Spec
+		} else {
+			provider, err := c.flaggerInformers.AlertInformer.Lister().AlertProviders(providerNamespace).Get(alert.ProviderRef.Name)
+			if err != nil {
+				c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+					Errorf("alert provider %s.%s error: %v", alert.ProviderRef.Name, providerNamespace, err)
+				continue
+			}
+			providerSpec = provider.Spec
 		}
 
 		// set hook URL address
-		url := provider.Spec.Address
+		url := providerSpec.Address
+		var headers map[string]string
+		var signingSecret string
+		var token string
 
 		// extract address from secret
-		if provider.Spec.SecretRef != nil {
-			secret, err := c.kubeClient.CoreV1().Secrets(providerNamespace).Get(provider.Spec.SecretRef.Name, metav1.GetOptions{})
+		if providerSpec.SecretRef != nil {
+			secret, err := c.kubeClient.CoreV1().Secrets(providerNamespace).Get(providerSpec.SecretRef.Name, metav1.GetOptions{})
 			if err != nil {
 				c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
 					Errorf("alert provider %s.%s secretRef error: %v", alert.ProviderRef.Name, providerNamespace, err)
 				continue
 			}
+			// read the request signing secret and the incident or Slack bot API token
+			if value, ok := secret.Data["secret"]; ok {
+				signingSecret = string(value)
+			}
+			if value, ok := secret.Data["token"]; ok {
+				token = string(value)
+			}
+
 			if address, ok := secret.Data["address"]; ok {
 				url = string(address)
-			} else {
+			} else if url == "" && providerSpec.Type == "slack" && token != "" {
+				url = notifier.SlackAPIURL
+			} else if url == "" {
 				c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
 					Errorf("alert provider %s.%s secret does not contain an address", alert.ProviderRef.Name, providerNamespace)
 				continue
 			}
+
+			// use the other secret keys as the generic provider request headers
+			if providerSpec.Type == "generic" {
+				headers = genericHeaders(secret.Data)
+			}
+		}
+
+		// read the generic provider template from a config map
+		tmpl := providerSpec.Template
+		if providerSpec.TemplateRef != nil {
+			cm, err := c.kubeClient.CoreV1().ConfigMaps(providerNamespace).Get(providerSpec.TemplateRef.Name, metav1.GetOptions{})
+			if err != nil {
+				c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+					Errorf("alert provider %s.%s templateRef error: %v", alert.ProviderRef.Name, providerNamespace, err)
+				continue
+			}
+			if t, ok := cm.Data[providerSpec.TemplateRef.Key]; ok {
+				tmpl = t
+			} else {
+				c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+					Errorf("alert provider %s.%s config map %s does not contain %s",
+						alert.ProviderRef.Name, providerNamespace, providerSpec.TemplateRef.Name, providerSpec.TemplateRef.Key)
+				continue
+			}
 		}
 
 		// set defaults
 		username := "flagger"
-		if provider.Spec.Username != "" {
-			username = provider.Spec.Username
+		if providerSpec.Username != "" {
+			username = providerSpec.Username
 		}
 		channel := "general"
-		if provider.Spec.Channel != "" {
-			channel = provider.Spec.Channel
+		if providerSpec.Channel != "" {
+			channel = providerSpec.Channel
 		}
 
 		// create notifier based on provider type
 		f := notifier.NewFactory(url, username, channel)
-		n, err := f.Notifier(provider.Spec.Type)
+		f.Secret = signingSecret
+		f.Token = token
+		f.Generic = notifier.GenericOptions{
+			Method:              providerSpec.Method,
+			Template:            tmpl,
+			Headers:             headers,
+			ExpectedStatusCodes: providerSpec.ExpectedStatusCodes,
+		}
+		n, err := f.Notifier(providerSpec.Type)
 		if err != nil {
 			c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
 				Errorf("alert provider %s.%s error: %v", alert.ProviderRef.Name, providerNamespace, err)
 			continue
 		}
+		tn, threaded := n.(notifier.ThreadNotifier)
+		if kind == notifier.EventKindHalted && !threaded && !shouldAlert(alert, severity, kind) {
+			continue
+		}
 
-		// send alert
-		err = n.Post(canary.Name, canary.Namespace, message, fields, string(severity))
+		// suppress repeated alerts
+		event := alertEvent(canary, message, fields, severity, kind)
+		if providerSpec.Throttle != nil {
+			opts, err := throttleOptions(providerSpec.Throttle)
+			if err != nil {
+				c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+					Errorf("alert provider %s.%s throttle error: %v", alert.ProviderRef.Name, providerNamespace, err)
+			} else {
+				providerKey := fmt.Sprintf("%s.%s", alert.ProviderRef.Name, providerNamespace)
+				filtered, ok := c.throttleAlert(canary, providerKey, event, opts)
+				if !ok {
+					continue
+				}
+				event = filtered
+			}
+		}
+
+		// send alert, threaded notifiers reply to the parent message of the canary revision
+		if threaded {
+			err = c.postAlertThread(canary, tn, fmt.Sprintf("%s.%s", alert.ProviderRef.Name, providerNamespace), event)
+		} else {
+			err = notifier.Send(n, event)
+		}
 		if err != nil {
 			c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
 				Errorf("alert provider $s.%s send error: %v", alert.ProviderRef.Name, providerNamespace, err)
@@ -147,12 +273,149 @@ func (c *Controller) alert(canary *flaggerv1.Canary, message string, metadata bo
 	}
 }
 
+// throttleAlert returns the event to be sent to the provider and false if the alert is suppressed
+func (c *Controller) throttleAlert(canary *flaggerv1.Canary, providerKey string, event notifier.Event, opts notifier.ThrottleOptions) (notifier.Event, bool) {
+	filtered, result, suppressed := c.alertThrottler.Filter(providerKey, event, opts)
+	c.recorder.IncAlerts(canary, providerKey, string(result))
+	c.recorder.SetAlertsSuppressed(canary, providerKey, suppressed)
+	return filtered, result != notifier.ThrottleSuppressed
+}
+
+// shouldAlert determines if the alert subscribes to the event kind or, when no event kinds
+// are specified, if the alert should be sent based on severity level
+func shouldAlert(alert flaggerv1.CanaryAlert, severity flaggerv1.AlertSeverity, kind notifier.EventKind) bool {
+	if len(alert.Events) > 0 {
+		for _, event := range alert.Events {
+			if notifier.EventKind(event) == kind {
+				return true
+			}
+		}
+		return false
+	}
+
+	if isOptInEvent(kind) {
+		return false
+	}
+
+	if alert.Severity == flaggerv1.SeverityInfo {
+		return true
+	}
+	if severity == alert.Severity {
+		return true
+	}
+	if severity == flaggerv1.SeverityWarn && alert.Severity == flaggerv1.SeverityError {
+		return true
+	}
+	return false
+}
+
+// isOptInEvent returns true for the events only sent to the alerts subscribed to them
+func isOptInEvent(kind notifier.EventKind) bool {
+	return kind == notifier.EventKindStepAdvanced || kind == notifier.EventKindHalted || kind == notifier.EventKindWebhookFailed
+}
+
+// defaultAlerts returns the alerts of the providers with a default alert from the canary namespace
+func (c *Controller) defaultAlerts(namespace string) []flaggerv1.CanaryAlert {
+	providers, err := c.flaggerInformers.AlertInformer.Lister().AlertProviders(namespace).List(labels.Everything())
+	if err != nil {
+		c.logger.Errorf("alert providers list in namespace %s error: %v", namespace, err)
+		return nil
+	}
+
+	var alerts []flaggerv1.CanaryAlert
+	for _, provider := range providers {
+		if provider.Spec.DefaultAlert == nil {
+			continue
+		}
+		severity := provider.Spec.DefaultAlert.Severity
+		if severity == "" {
+			severity = flaggerv1.SeverityInfo
+		}
+		alerts = append(alerts, flaggerv1.CanaryAlert{
+			Name:     provider.Name,
+			Severity: severity,
+			Events:   provider.Spec.DefaultAlert.Events,
+			ProviderRef: flaggerv1.CrossNamespaceObjectReference{
+				Name:      provider.Name,
+				Namespace: namespace,
+			},
+		})
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Name < alerts[j].Name })
+	return alerts
+}
+
+// postAlertThread posts the event to the thread of the canary revision and persists
+// the parent message in the canary status when a new thread is started
+func (c *Controller) postAlertThread(canary *flaggerv1.Canary, tn notifier.ThreadNotifier, provider string, event notifier.Event) error {
+	var thread *notifier.Thread
+	for _, t := range canary.Status.AlertThreads {
+		if t.Provider == provider && t.Revision == event.Revision {
+			thread = &notifier.Thread{Channel: t.Channel, Timestamp: t.Timestamp}
+		}
+	}
+
+	result, err := tn.PostThread(event, thread)
+	if result == nil || thread != nil {
+		return err
+	}
+
+	status := flaggerv1.CanaryAlertThread{
+		Provider:  provider,
+		Revision:  event.Revision,
+		Channel:   result.Channel,
+		Timestamp: result.Timestamp,
+	}
+	if serr := c.canaryFactory.Controller(canary.GetTargetKind()).SetStatusAlertThread(canary, status); serr != nil {
+		c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+			Errorf("alert thread status update failed: %v", serr)
+	}
+	// keep the in-memory status in sync with the subsequent alerts and status updates
+	canary.Status.SetAlertThread(status)
+	return err
+}
+
+func throttleOptions(throttle *flaggerv1.AlertProviderThrottle) (notifier.ThrottleOptions, error) {
+	opts := notifier.ThrottleOptions{Limit: throttle.Limit}
+	if throttle.Window != "" {
+		d, err := time.ParseDuration(throttle.Window)
+		if err != nil {
+			return opts, fmt.Errorf("window %s parsing failed: %w", throttle.Window, err)
+		}
+		opts.Window = d
+	}
+	if throttle.DigestInterval != "" {
+		d, err := time.ParseDuration(throttle.DigestInterval)
+		if err != nil {
+			return opts, fmt.Errorf("digest interval %s parsing failed: %w", throttle.DigestInterval, err)
+		}
+		opts.DigestInterval = d
+	}
+	return opts, nil
+}
+
+func alertEvent(canary *flaggerv1.Canary, message string, fields []notifier.Field, severity flaggerv1.AlertSeverity, kind notifier.EventKind) notifier.Event {
+	return notifier.Event{
+		Name:         canary.Name,
+		Namespace:    canary.Namespace,
+		Message:      message,
+		Fields:       fields,
+		Severity:     string(severity),
+		Phase:        string(canary.Status.Phase),
+		Kind:         kind,
+		Weight:       canary.Status.CanaryWeight,
+		FailedChecks: canary.Status.FailedChecks,
+		Key:          fmt.Sprintf("%s.%s", canary.Name, canary.Namespace),
+		Revision:     canary.Status.LastAppliedSpec,
+	}
+}
+
 func alertMetadata(canary *flaggerv1.Canary) []notifier.Field {
 	var fields []notifier.Field
 	fields = append(fields,
 		notifier.Field{
 			Name:  "Target",
-			Value: fmt.Sprintf("%s/%s.%s", canary.Spec.TargetRef.Kind, canary.Spec.TargetRef.Name, canary.Namespace),
+			Value: fmt.Sprintf("%s/%s.%s", canary.GetTargetKind(), canary.Spec.TargetRef.Name, canary.Namespace),
 		},
 		notifier.Field{
 			Name:  "Failed checks threshold",
@@ -190,3 +453,116 @@ func alertMetadata(canary *flaggerv1.Canary) []notifier.Field {
 	}
 	return fields
 }
+
+// alertContext returns the image and config changes of the canary revision, the last failed
+// metric check of a rollback and the canary dashboard URL
+func (c *Controller) alertContext(canary *flaggerv1.Canary, kind notifier.EventKind) []notifier.Field {
+	var fields []notifier.Field
+	key := fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)
+
+	if kind == notifier.EventKindStarted || kind == notifier.EventKindRolledBack {
+		if metadata, err := c.canaryFactory.Controller(canary.GetTargetKind()).GetTargetMetadata(canary); err == nil {
+			if changes := imageChanges(metadata.PrimaryImages, metadata.CanaryImages); len(changes) > 0 {
+				fields = append(fields, notifier.Field{Name: "Image changes", Value: strings.Join(changes, "\n")})
+			}
+		}
+	}
+
+	if kind == notifier.EventKindStarted && canary.Status.TrackedConfigs != nil {
+		if configs, err := c.canaryFactory.ConfigTracker().GetConfigRefs(canary); err == nil && configs != nil {
+			if changes := configChanges(*canary.Status.TrackedConfigs, *configs); len(changes) > 0 {
+				fields = append(fields, notifier.Field{Name: "Config changes", Value: strings.Join(changes, "\n")})
+			}
+		}
+	}
+
+	switch kind {
+	case notifier.EventKindRolledBack:
+		if msg, ok := c.failedChecks.Load(key); ok {
+			fields = append(fields, notifier.Field{Name: "Last failed check", Value: msg.(string)})
+		}
+		c.failedChecks.Delete(key)
+	case notifier.EventKindStarted, notifier.EventKindPromoted:
+		c.failedChecks.Delete(key)
+	}
+
+	if tmpl := canary.GetAnalysis().DashboardURL; tmpl != "" {
+		url, err := observers.RenderQuery(tmpl, toMetricModel(canary, nil, canary.GetMetricInterval(), nil))
+		if err != nil {
+			c.logger.With("canary", key).Errorf("dashboard URL render error: %v", err)
+		} else {
+			fields = append(fields, notifier.Field{Name: "Dashboard", Value: url})
+		}
+	}
+	return fields
+}
+
+// alertWebhookFailed posts the failed webhook alert once per canary revision and phase,
+// the hooks are retried at every analysis interval until they pass
+func (c *Controller) alertWebhookFailed(r *flaggerv1.Canary, webhook flaggerv1.CanaryWebhook, message string) {
+	key := fmt.Sprintf("%s.%s/%s", r.Name, r.Namespace, webhook.Name)
+	state := fmt.Sprintf("%s/%s", r.Status.LastAppliedSpec, r.Status.Phase)
+	if last, ok := c.webhookAlerts.Load(key); ok && last == state {
+		return
+	}
+	c.webhookAlerts.Store(key, state)
+	c.alert(r, message, false, flaggerv1.SeverityWarn, notifier.EventKindWebhookFailed)
+}
+
+// recordMetricHaltf records the halt event of a failed metric check,
+// posts it to the alerts subscribed to halts and keeps the message for the rollback alert
+func (c *Controller) recordMetricHaltf(r *flaggerv1.Canary, template string, args ...interface{}) {
+	message := fmt.Sprintf(template, args...)
+	c.failedChecks.Store(fmt.Sprintf("%s.%s", r.Name, r.Namespace), message)
+	c.recordEventHaltf(r, template, args...)
+	c.alert(r, message, false, flaggerv1.SeverityWarn, notifier.EventKindHalted)
+}
+
+// genericHeaders returns the generic provider request headers from the secret data,
+// the token is sent as a bearer token and the address and credentials keys are never sent
+func genericHeaders(data map[string][]byte) map[string]string {
+	headers := make(map[string]string)
+	for k, v := range data {
+		switch k {
+		case "address", "secret", "password":
+		case "token":
+			headers["Authorization"] = fmt.Sprintf("Bearer %s", string(v))
+		default:
+			headers[k] = string(v)
+		}
+	}
+	return headers
+}
+
+// imageChanges returns the containers whose image differs between the primary and the canary
+func imageChanges(primary map[string]string, canary map[string]string) []string {
+	var changes []string
+	for name, image := range canary {
+		if old, ok := primary[name]; !ok {
+			changes = append(changes, fmt.Sprintf("%s: %s (added)", name, image))
+		} else if old != image {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, old, image))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// configChanges returns the ConfigMaps and Secrets whose checksum differs from the tracked ones
+func configChanges(tracked map[string]string, current map[string]string) []string {
+	var changes []string
+	for name, checksum := range current {
+		if old, ok := tracked[name]; !ok {
+			changes = append(changes, fmt.Sprintf("%s added", name))
+		} else if old != checksum {
+			changes = append(changes, fmt.Sprintf("%s changed", name))
+		}
+	}
+	for name := range tracked {
+		if _, ok := current[name]; !ok {
+			changes = append(changes, fmt.Sprintf("%s removed", name))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
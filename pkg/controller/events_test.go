@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/notifier"
+)
+
+func TestImageChanges(t *testing.T) {
+	primary := map[string]string{
+		"podinfo": "stefanprodan/podinfo:3.1.0",
+		"sidecar": "envoyproxy/envoy:v1.14.1",
+	}
+	canary := map[string]string{
+		"podinfo": "stefanprodan/podinfo:3.1.1",
+		"sidecar": "envoyproxy/envoy:v1.14.1",
+		"logger":  "fluent/fluent-bit:1.4",
+	}
+
+	changes := imageChanges(primary, canary)
+	require.Equal(t, []string{
+		"logger: fluent/fluent-bit:1.4 (added)",
+		"podinfo: stefanprodan/podinfo:3.1.0 -> stefanprodan/podinfo:3.1.1",
+	}, changes)
+}
+
+func TestConfigChanges(t *testing.T) {
+	tracked := map[string]string{
+		"configmap/podinfo-config-env": "a",
+		"secret/podinfo-secret-env":    "b",
+	}
+	current := map[string]string{
+		"configmap/podinfo-config-env": "c",
+		"configmap/podinfo-config-vol": "d",
+	}
+
+	changes := configChanges(tracked, current)
+	require.Equal(t, []string{
+		"configmap/podinfo-config-env changed",
+		"configmap/podinfo-config-vol added",
+		"secret/podinfo-secret-env removed",
+	}, changes)
+}
+
+func TestShouldAlert(t *testing.T) {
+	info := flaggerv1.CanaryAlert{Severity: flaggerv1.SeverityInfo}
+	require.True(t, shouldAlert(info, flaggerv1.SeverityInfo, notifier.EventKindPromoted))
+	require.False(t, shouldAlert(info, flaggerv1.SeverityInfo, notifier.EventKindStepAdvanced))
+	require.False(t, shouldAlert(info, flaggerv1.SeverityWarn, notifier.EventKindHalted))
+
+	errors := flaggerv1.CanaryAlert{Severity: flaggerv1.SeverityError}
+	require.True(t, shouldAlert(errors, flaggerv1.SeverityError, notifier.EventKindRolledBack))
+	require.True(t, shouldAlert(errors, flaggerv1.SeverityWarn, notifier.EventKindWaiting))
+	require.False(t, shouldAlert(errors, flaggerv1.SeverityInfo, notifier.EventKindPromoted))
+
+	releases := flaggerv1.CanaryAlert{
+		Severity: flaggerv1.SeverityError,
+		Events:   []flaggerv1.AlertEvent{flaggerv1.AlertEventPromoted, flaggerv1.AlertEventStepAdvanced, flaggerv1.AlertEventHalted},
+	}
+	require.True(t, shouldAlert(releases, flaggerv1.SeverityInfo, notifier.EventKindPromoted))
+	require.True(t, shouldAlert(releases, flaggerv1.SeverityInfo, notifier.EventKindStepAdvanced))
+	require.True(t, shouldAlert(releases, flaggerv1.SeverityWarn, notifier.EventKindHalted))
+	require.False(t, shouldAlert(releases, flaggerv1.SeverityError, notifier.EventKindRolledBack))
+}
+
+func TestCloudEventType(t *testing.T) {
+	tests := map[flaggerv1.CanaryPhase]string{
+		"":                               CloudEventInitialized,
+		flaggerv1.CanaryPhaseInitialized: CloudEventInitialized,
+		flaggerv1.CanaryPhaseWaiting:     CloudEventProgressing,
+		flaggerv1.CanaryPhaseProgressing: CloudEventProgressing,
+		flaggerv1.CanaryPhasePromoting:   CloudEventPromoting,
+		flaggerv1.CanaryPhaseFinalising:  CloudEventPromoting,
+		flaggerv1.CanaryPhaseSucceeded:   CloudEventPromoted,
+		flaggerv1.CanaryPhaseFailed:      CloudEventRolledBack,
+		flaggerv1.CanaryPhaseTerminating: CloudEventTerminated,
+		flaggerv1.CanaryPhaseTerminated:  CloudEventTerminated,
+	}
+	for phase, expected := range tests {
+		require.Equal(t, expected, cloudEventType(phase), "phase %q", phase)
+	}
+}
+
+func TestGenericHeaders(t *testing.T) {
+	headers := genericHeaders(map[string][]byte{
+		"address":   []byte("https://paging.example.com"),
+		"secret":    []byte("signing-secret"),
+		"password":  []byte("password"),
+		"token":     []byte("api-token"),
+		"X-Api-Key": []byte("flagger"),
+	})
+
+	require.Equal(t, map[string]string{
+		"Authorization": "Bearer api-token",
+		"X-Api-Key":     "flagger",
+	}, headers)
+}
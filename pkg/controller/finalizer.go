@@ -24,7 +24,7 @@ func (c *Controller) finalize(old interface{}) error {
 	}
 
 	// Retrieve a controller
-	canaryController := c.canaryFactory.Controller(r.Spec.TargetRef.Kind)
+	canaryController := c.canaryFactory.Controller(r.GetTargetKind())
 
 	// Set the status to terminating if not already in that state
 	if r.Status.Phase != flaggerv1.CanaryPhaseTerminating {
@@ -40,7 +40,7 @@ func (c *Controller) finalize(old interface{}) error {
 	if err != nil {
 		return fmt.Errorf("failed to revert target: %w", err)
 	}
-	c.logger.Infof("%s.%s kind %s reverted", r.Name, r.Namespace, r.Spec.TargetRef.Kind)
+	c.logger.Infof("%s.%s kind %s reverted", r.Name, r.Namespace, r.GetTargetKind())
 
 	// Ensure that targetRef has met a ready state
 	c.logger.Infof("Checking is canary is ready %s.%s", r.Name, r.Namespace)
@@ -55,8 +55,13 @@ func (c *Controller) finalize(old interface{}) error {
 		return fmt.Errorf("failed to get metadata for router finalizing: %w", err)
 	}
 
+	provider := c.meshProvider
+	if r.Spec.Provider != "" {
+		provider = r.Spec.Provider
+	}
+
 	// Revert the router
-	router := c.routerFactory.KubernetesRouter(r.Spec.TargetRef.Kind, labelSelector, map[string]string{}, ports)
+	router := c.routerFactory.KubernetesRouter(r.GetTargetKind(), provider, labelSelector, map[string]string{}, ports)
 	if err := router.Finalize(r); err != nil {
 		return fmt.Errorf("failed revert router: %w", err)
 	}
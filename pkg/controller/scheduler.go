@@ -2,12 +2,14 @@ package controller
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
 	"github.com/weaveworks/flagger/pkg/canary"
+	"github.com/weaveworks/flagger/pkg/notifier"
 	"github.com/weaveworks/flagger/pkg/router"
 )
 
@@ -64,6 +66,14 @@ func (c *Controller) scheduleCanaries() {
 		if _, exists := current[job]; !exists {
 			c.jobs[job].Stop()
 			delete(c.jobs, job)
+			c.alertThrottler.Forget(job)
+			c.failedChecks.Delete(job)
+			c.webhookAlerts.Range(func(key, _ interface{}) bool {
+				if strings.HasPrefix(key.(string), job+"/") {
+					c.webhookAlerts.Delete(key)
+				}
+				return true
+			})
 		}
 	}
 
@@ -100,17 +110,17 @@ func (c *Controller) advanceCanary(name string, namespace string) {
 	}
 
 	// init controller based on target kind
-	canaryController := c.canaryFactory.Controller(cd.Spec.TargetRef.Kind)
+	canaryController := c.canaryFactory.Controller(cd.GetTargetKind())
 	labelSelector, ports, err := canaryController.GetMetadata(cd)
 	if err != nil {
-		c.recordEventWarningf(cd, "%v", err)
+		c.recordEventErrorf(cd, "%v", err)
 		return
 	}
 
 	// init Kubernetes router
-	kubeRouter := c.routerFactory.KubernetesRouter(cd.Spec.TargetRef.Kind, labelSelector, map[string]string{}, ports)
+	kubeRouter := c.routerFactory.KubernetesRouter(cd.GetTargetKind(), provider, labelSelector, map[string]string{}, ports)
 	if err := kubeRouter.Initialize(cd); err != nil {
-		c.recordEventWarningf(cd, "%v", err)
+		c.recordEventErrorf(cd, "%v", err)
 		return
 	}
 
@@ -126,20 +136,22 @@ func (c *Controller) advanceCanary(name string, namespace string) {
 
 	// create or update svc
 	if err := kubeRouter.Reconcile(cd); err != nil {
-		c.recordEventWarningf(cd, "%v", err)
+		c.recordEventErrorf(cd, "%v", err)
 		return
 	}
 
-	// create or update mesh routes
-	if err := meshRouter.Reconcile(cd); err != nil {
-		c.recordEventWarningf(cd, "%v", err)
+	// create or update mesh routes, the analysis continues if the changes made by others have been reasserted
+	err = meshRouter.Reconcile(cd)
+	c.syncDriftCondition(cd, canaryController, err)
+	if err != nil && !router.IsReasserted(err) {
+		c.recordEventErrorf(cd, "%v", err)
 		return
 	}
 
 	// check for changes
 	shouldAdvance, err := c.shouldAdvance(cd, canaryController)
 	if err != nil {
-		c.recordEventWarningf(cd, "%v", err)
+		c.recordEventErrorf(cd, "%v", err)
 		return
 	}
 
@@ -170,7 +182,7 @@ func (c *Controller) advanceCanary(name string, namespace string) {
 	// get the routing settings
 	primaryWeight, canaryWeight, mirrored, err := meshRouter.GetRoutes(cd)
 	if err != nil {
-		c.recordEventWarningf(cd, "%v", err)
+		c.recordEventErrorf(cd, "%v", err)
 		return
 	}
 
@@ -190,7 +202,7 @@ func (c *Controller) advanceCanary(name string, namespace string) {
 		primaryWeight = 100
 		canaryWeight = 0
 		if err := meshRouter.SetRoutes(cd, primaryWeight, canaryWeight, false); err != nil {
-			c.recordEventWarningf(cd, "%v", err)
+			c.recordEventErrorf(cd, "%v", err)
 			return
 		}
 
@@ -202,7 +214,7 @@ func (c *Controller) advanceCanary(name string, namespace string) {
 			Iterations:   0,
 		}
 		if err := canaryController.SyncStatus(cd, status); err != nil {
-			c.recordEventWarningf(cd, "%v", err)
+			c.recordEventErrorf(cd, "%v", err)
 		}
 		return
 	}
@@ -224,8 +236,8 @@ func (c *Controller) advanceCanary(name string, namespace string) {
 	if cd.Status.Phase == flaggerv1.CanaryPhaseProgressing ||
 		cd.Status.Phase == flaggerv1.CanaryPhaseWaiting {
 		if ok := c.runRollbackHooks(cd, cd.Status.Phase); ok {
-			c.recordEventWarningf(cd, "Rolling back %s.%s manual webhook invoked", cd.Name, cd.Namespace)
-			c.alert(cd, "Rolling back manual webhook invoked", false, flaggerv1.SeverityWarn)
+			c.recordEventHaltf(cd, "Rolling back %s.%s manual webhook invoked", cd.Name, cd.Namespace)
+			c.alert(cd, "Rolling back manual webhook invoked", false, flaggerv1.SeverityWarn, notifier.EventKindRolledBack)
 			c.rollback(cd, canaryController, meshRouter)
 			return
 		}
@@ -236,7 +248,7 @@ func (c *Controller) advanceCanary(name string, namespace string) {
 		if provider != "kubernetes" {
 			c.recordEventInfof(cd, "Routing all traffic to primary")
 			if err := meshRouter.SetRoutes(cd, 100, 0, false); err != nil {
-				c.recordEventWarningf(cd, "%v", err)
+				c.recordEventErrorf(cd, "%v", err)
 				return
 			}
 			c.recorder.SetWeight(cd, 100, 0)
@@ -244,7 +256,7 @@ func (c *Controller) advanceCanary(name string, namespace string) {
 
 		// update status phase
 		if err := canaryController.SetStatusPhase(cd, flaggerv1.CanaryPhaseFinalising); err != nil {
-			c.recordEventWarningf(cd, "%v", err)
+			c.recordEventErrorf(cd, "%v", err)
 			return
 		}
 
@@ -254,20 +266,20 @@ func (c *Controller) advanceCanary(name string, namespace string) {
 	// scale canary to zero if promotion has finished
 	if cd.Status.Phase == flaggerv1.CanaryPhaseFinalising {
 		if err := canaryController.ScaleToZero(cd); err != nil {
-			c.recordEventWarningf(cd, "%v", err)
+			c.recordEventErrorf(cd, "%v", err)
 			return
 		}
 
 		// set status to succeeded
 		if err := canaryController.SetStatusPhase(cd, flaggerv1.CanaryPhaseSucceeded); err != nil {
-			c.recordEventWarningf(cd, "%v", err)
+			c.recordEventErrorf(cd, "%v", err)
 			return
 		}
 		c.recorder.SetStatus(cd, flaggerv1.CanaryPhaseSucceeded)
 		c.runPostRolloutHooks(cd, flaggerv1.CanaryPhaseSucceeded)
-		c.recordEventInfof(cd, "Promotion completed! Scaling down %s.%s", cd.Spec.TargetRef.Name, cd.Namespace)
+		c.recordEventPromotedf(cd, "Promotion completed! Scaling down %s.%s", cd.Spec.TargetRef.Name, cd.Namespace)
 		c.alert(cd, "Canary analysis completed successfully, promotion finished.",
-			false, flaggerv1.SeverityInfo)
+			false, flaggerv1.SeverityInfo, notifier.EventKindPromoted)
 		return
 	}
 
@@ -275,10 +287,10 @@ func (c *Controller) advanceCanary(name string, namespace string) {
 	if cd.Status.Phase == flaggerv1.CanaryPhaseProgressing &&
 		(!retriable || cd.Status.FailedChecks >= cd.GetAnalysisThreshold()) {
 		if !retriable {
-			c.recordEventWarningf(cd, "Rolling back %s.%s progress deadline exceeded %v",
+			c.recordEventHaltf(cd, "Rolling back %s.%s progress deadline exceeded %v",
 				cd.Name, cd.Namespace, err)
 			c.alert(cd, fmt.Sprintf("Progress deadline exceeded %v", err),
-				false, flaggerv1.SeverityError)
+				false, flaggerv1.SeverityError, notifier.EventKindRolledBack)
 		}
 		c.rollback(cd, canaryController, meshRouter)
 		return
@@ -298,14 +310,14 @@ func (c *Controller) advanceCanary(name string, namespace string) {
 		// run pre-rollout web hooks
 		if ok := c.runPreRolloutHooks(cd); !ok {
 			if err := canaryController.SetStatusFailedChecks(cd, cd.Status.FailedChecks+1); err != nil {
-				c.recordEventWarningf(cd, "%v", err)
+				c.recordEventErrorf(cd, "%v", err)
 			}
 			return
 		}
 	} else {
 		if ok := c.runAnalysis(cd); !ok {
 			if err := canaryController.SetStatusFailedChecks(cd, cd.Status.FailedChecks+1); err != nil {
-				c.recordEventWarningf(cd, "%v", err)
+				c.recordEventErrorf(cd, "%v", err)
 			}
 			return
 		}
@@ -324,6 +336,30 @@ func (c *Controller) advanceCanary(name string, namespace string) {
 		}
 	}
 
+	// traffic is split by replicas for kubernetes-replicas provider
+	if provider == "kubernetes-replicas" {
+		if len(cd.GetAnalysis().Match) > 0 {
+			c.recordEventWarningf(cd, "A/B testing is not supported when using the kubernetes-replicas provider")
+			cd.GetAnalysis().Match = nil
+		}
+		if cd.GetAnalysis().Mirror {
+			c.recordEventWarningf(cd, "Traffic mirroring is not supported when using the kubernetes-replicas provider")
+			cd.GetAnalysis().Mirror = false
+		}
+	}
+
+	// TCP and TLS connections can't be matched on HTTP headers or mirrored
+	if cd.IsL4() {
+		if len(cd.GetAnalysis().Match) > 0 {
+			c.recordEventWarningf(cd, "A/B testing is not supported for %s services", cd.GetAppProtocol())
+			cd.GetAnalysis().Match = nil
+		}
+		if cd.GetAnalysis().Mirror {
+			c.recordEventWarningf(cd, "Traffic mirroring is not supported for %s services", cd.GetAppProtocol())
+			cd.GetAnalysis().Mirror = false
+		}
+	}
+
 	// strategy: A/B testing
 	if len(cd.GetAnalysis().Match) > 0 && cd.GetAnalysis().Iterations > 0 {
 		c.runAB(cd, canaryController, meshRouter)
@@ -377,17 +413,19 @@ func (c *Controller) runCanary(canary *flaggerv1.Canary, canaryController canary
 		}
 
 		if err := meshRouter.SetRoutes(canary, primaryWeight, canaryWeight, mirrored); err != nil {
-			c.recordEventWarningf(canary, "%v", err)
+			c.recordEventErrorf(canary, "%v", err)
 			return
 		}
 
 		if err := canaryController.SetStatusWeight(canary, canaryWeight); err != nil {
-			c.recordEventWarningf(canary, "%v", err)
+			c.recordEventErrorf(canary, "%v", err)
 			return
 		}
 
 		c.recorder.SetWeight(canary, primaryWeight, canaryWeight)
 		c.recordEventInfof(canary, "Advance %s.%s canary weight %v", canary.Name, canary.Namespace, canaryWeight)
+		c.alert(canary, fmt.Sprintf("Advance canary weight %v", canaryWeight),
+			false, flaggerv1.SeverityInfo, notifier.EventKindStepAdvanced)
 		return
 	}
 
@@ -402,13 +440,13 @@ func (c *Controller) runCanary(canary *flaggerv1.Canary, canaryController canary
 		c.recordEventInfof(canary, "Copying %s.%s template spec to %s.%s",
 			canary.Spec.TargetRef.Name, canary.Namespace, primaryName, canary.Namespace)
 		if err := canaryController.Promote(canary); err != nil {
-			c.recordEventWarningf(canary, "%v", err)
+			c.recordEventErrorf(canary, "%v", err)
 			return
 		}
 
 		// update status phase
 		if err := canaryController.SetStatusPhase(canary, flaggerv1.CanaryPhasePromoting); err != nil {
-			c.recordEventWarningf(canary, "%v", err)
+			c.recordEventErrorf(canary, "%v", err)
 			return
 		}
 	}
@@ -421,17 +459,19 @@ func (c *Controller) runAB(canary *flaggerv1.Canary, canaryController canary.Con
 	// route traffic to canary and increment iterations
 	if canary.GetAnalysis().Iterations > canary.Status.Iterations {
 		if err := meshRouter.SetRoutes(canary, 0, 100, false); err != nil {
-			c.recordEventWarningf(canary, "%v", err)
+			c.recordEventErrorf(canary, "%v", err)
 			return
 		}
 		c.recorder.SetWeight(canary, 0, 100)
 
 		if err := canaryController.SetStatusIterations(canary, canary.Status.Iterations+1); err != nil {
-			c.recordEventWarningf(canary, "%v", err)
+			c.recordEventErrorf(canary, "%v", err)
 			return
 		}
 		c.recordEventInfof(canary, "Advance %s.%s canary iteration %v/%v",
 			canary.Name, canary.Namespace, canary.Status.Iterations+1, canary.GetAnalysis().Iterations)
+		c.alert(canary, fmt.Sprintf("Advance canary iteration %v/%v", canary.Status.Iterations+1, canary.GetAnalysis().Iterations),
+			false, flaggerv1.SeverityInfo, notifier.EventKindStepAdvanced)
 		return
 	}
 
@@ -445,13 +485,13 @@ func (c *Controller) runAB(canary *flaggerv1.Canary, canaryController canary.Con
 		c.recordEventInfof(canary, "Copying %s.%s template spec to %s.%s",
 			canary.Spec.TargetRef.Name, canary.Namespace, primaryName, canary.Namespace)
 		if err := canaryController.Promote(canary); err != nil {
-			c.recordEventWarningf(canary, "%v", err)
+			c.recordEventErrorf(canary, "%v", err)
 			return
 		}
 
 		// update status phase
 		if err := canaryController.SetStatusPhase(canary, flaggerv1.CanaryPhasePromoting); err != nil {
-			c.recordEventWarningf(canary, "%v", err)
+			c.recordEventErrorf(canary, "%v", err)
 			return
 		}
 	}
@@ -467,17 +507,19 @@ func (c *Controller) runBlueGreen(canary *flaggerv1.Canary, canaryController can
 		if provider != "kubernetes" &&
 			canary.GetAnalysis().Mirror == true && mirrored == false {
 			if err := meshRouter.SetRoutes(canary, 100, 0, true); err != nil {
-				c.recordEventWarningf(canary, "%v", err)
+				c.recordEventErrorf(canary, "%v", err)
 			}
 			c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
 				Infof("Start traffic mirroring")
 		}
 		if err := canaryController.SetStatusIterations(canary, canary.Status.Iterations+1); err != nil {
-			c.recordEventWarningf(canary, "%v", err)
+			c.recordEventErrorf(canary, "%v", err)
 			return
 		}
 		c.recordEventInfof(canary, "Advance %s.%s canary iteration %v/%v",
 			canary.Name, canary.Namespace, canary.Status.Iterations+1, canary.GetAnalysis().Iterations)
+		c.alert(canary, fmt.Sprintf("Advance canary iteration %v/%v", canary.Status.Iterations+1, canary.GetAnalysis().Iterations),
+			false, flaggerv1.SeverityInfo, notifier.EventKindStepAdvanced)
 		return
 	}
 
@@ -495,7 +537,7 @@ func (c *Controller) runBlueGreen(canary *flaggerv1.Canary, canaryController can
 				c.recordEventInfof(canary, "Routing all traffic to canary")
 			}
 			if err := meshRouter.SetRoutes(canary, 0, 100, false); err != nil {
-				c.recordEventWarningf(canary, "%v", err)
+				c.recordEventErrorf(canary, "%v", err)
 				return
 			}
 			c.recorder.SetWeight(canary, 0, 100)
@@ -503,7 +545,7 @@ func (c *Controller) runBlueGreen(canary *flaggerv1.Canary, canaryController can
 
 		// increment iterations
 		if err := canaryController.SetStatusIterations(canary, canary.Status.Iterations+1); err != nil {
-			c.recordEventWarningf(canary, "%v", err)
+			c.recordEventErrorf(canary, "%v", err)
 			return
 		}
 		return
@@ -514,13 +556,13 @@ func (c *Controller) runBlueGreen(canary *flaggerv1.Canary, canaryController can
 		c.recordEventInfof(canary, "Copying %s.%s template spec to %s.%s",
 			canary.Spec.TargetRef.Name, canary.Namespace, primaryName, canary.Namespace)
 		if err := canaryController.Promote(canary); err != nil {
-			c.recordEventWarningf(canary, "%v", err)
+			c.recordEventErrorf(canary, "%v", err)
 			return
 		}
 
 		// update status phase
 		if err := canaryController.SetStatusPhase(canary, flaggerv1.CanaryPhasePromoting); err != nil {
-			c.recordEventWarningf(canary, "%v", err)
+			c.recordEventErrorf(canary, "%v", err)
 			return
 		}
 	}
@@ -531,10 +573,11 @@ func (c *Controller) runAnalysis(canary *flaggerv1.Canary) bool {
 	// run external checks
 	for _, webhook := range canary.GetAnalysis().Webhooks {
 		if webhook.Type == "" || webhook.Type == flaggerv1.RolloutHook {
-			err := CallWebhook(canary.Name, canary.Namespace, flaggerv1.CanaryPhaseProgressing, webhook)
+			err := c.runWebhook(canary, flaggerv1.CanaryPhaseProgressing, webhook)
 			if err != nil {
-				c.recordEventWarningf(canary, "Halt %s.%s advancement external check %s failed %v",
+				c.recordEventHaltf(canary, "Halt %s.%s advancement external check %s failed %v",
 					canary.Name, canary.Namespace, webhook.Name, err)
+				c.alertWebhookFailed(canary, webhook, fmt.Sprintf("Rollout check %s failed %v", webhook.Name, err))
 				return false
 			}
 		}
@@ -562,7 +605,7 @@ func (c *Controller) shouldSkipAnalysis(canary *flaggerv1.Canary, canaryControll
 	primaryWeight := 100
 	canaryWeight := 0
 	if err := meshRouter.SetRoutes(canary, primaryWeight, canaryWeight, false); err != nil {
-		c.recordEventWarningf(canary, "%v", err)
+		c.recordEventErrorf(canary, "%v", err)
 		return false
 	}
 	c.recorder.SetWeight(canary, primaryWeight, canaryWeight)
@@ -571,28 +614,28 @@ func (c *Controller) shouldSkipAnalysis(canary *flaggerv1.Canary, canaryControll
 	c.recordEventInfof(canary, "Copying %s.%s template spec to %s-primary.%s",
 		canary.Spec.TargetRef.Name, canary.Namespace, canary.Spec.TargetRef.Name, canary.Namespace)
 	if err := canaryController.Promote(canary); err != nil {
-		c.recordEventWarningf(canary, "%v", err)
+		c.recordEventErrorf(canary, "%v", err)
 		return false
 	}
 
 	// shutdown canary
 	if err := canaryController.ScaleToZero(canary); err != nil {
-		c.recordEventWarningf(canary, "%v", err)
+		c.recordEventErrorf(canary, "%v", err)
 		return false
 	}
 
 	// update status phase
 	if err := canaryController.SetStatusPhase(canary, flaggerv1.CanaryPhaseSucceeded); err != nil {
-		c.recordEventWarningf(canary, "%v", err)
+		c.recordEventErrorf(canary, "%v", err)
 		return false
 	}
 
 	// notify
 	c.recorder.SetStatus(canary, flaggerv1.CanaryPhaseSucceeded)
-	c.recordEventInfof(canary, "Promotion completed! Canary analysis was skipped for %s.%s",
+	c.recordEventPromotedf(canary, "Promotion completed! Canary analysis was skipped for %s.%s",
 		canary.Spec.TargetRef.Name, canary.Namespace)
 	c.alert(canary, "Canary analysis was skipped, promotion finished.",
-		false, flaggerv1.SeverityInfo)
+		false, flaggerv1.SeverityInfo, notifier.EventKindPromoted)
 
 	return true
 }
@@ -639,8 +682,10 @@ func (c *Controller) checkCanaryStatus(canary *flaggerv1.Canary, canaryControlle
 		}
 		c.recorder.SetStatus(canary, flaggerv1.CanaryPhaseInitialized)
 		c.recordEventInfof(canary, "Initialization done! %s.%s", canary.Name, canary.Namespace)
-		c.alert(canary, fmt.Sprintf("New %s detected, initialization completed.", canary.Spec.TargetRef.Kind),
-			true, flaggerv1.SeverityInfo)
+		canaryPhaseInitialized := c.withSyncedRevision(canary)
+		canaryPhaseInitialized.Status.Phase = flaggerv1.CanaryPhaseInitialized
+		c.alert(canaryPhaseInitialized, fmt.Sprintf("New %s detected, initialization completed.", canary.GetTargetKind()),
+			true, flaggerv1.SeverityInfo, notifier.EventKindInitialized)
 		return false
 	}
 
@@ -648,8 +693,6 @@ func (c *Controller) checkCanaryStatus(canary *flaggerv1.Canary, canaryControlle
 		canaryPhaseProgressing := canary.DeepCopy()
 		canaryPhaseProgressing.Status.Phase = flaggerv1.CanaryPhaseProgressing
 		c.recordEventInfof(canaryPhaseProgressing, "New revision detected! Scaling up %s.%s", canaryPhaseProgressing.Spec.TargetRef.Name, canaryPhaseProgressing.Namespace)
-		c.alert(canaryPhaseProgressing, "New revision detected, starting canary analysis.",
-			true, flaggerv1.SeverityInfo)
 
 		if err := canaryController.ScaleFromZero(canary); err != nil {
 			c.recordEventErrorf(canary, "%v", err)
@@ -660,11 +703,30 @@ func (c *Controller) checkCanaryStatus(canary *flaggerv1.Canary, canaryControlle
 			return false
 		}
 		c.recorder.SetStatus(canary, flaggerv1.CanaryPhaseProgressing)
+
+		// key the analysis start on the synced revision, the previous tracked configs are kept for the config changes
+		canaryPhaseProgressing = c.withSyncedRevision(canaryPhaseProgressing)
+		canaryPhaseProgressing.Status.CanaryWeight = 0
+		canaryPhaseProgressing.Status.FailedChecks = 0
+		canaryPhaseProgressing.Status.Iterations = 0
+		c.alert(canaryPhaseProgressing, "New revision detected, starting canary analysis.",
+			true, flaggerv1.SeverityInfo, notifier.EventKindStarted)
 		return false
 	}
 	return false
 }
 
+// withSyncedRevision returns a copy of the canary with the revision and the alert threads
+// written by the last status sync, the alerts are keyed on the revision
+func (c *Controller) withSyncedRevision(canary *flaggerv1.Canary) *flaggerv1.Canary {
+	cd := canary.DeepCopy()
+	if synced, err := c.flaggerClient.FlaggerV1beta1().Canaries(canary.Namespace).Get(canary.Name, metav1.GetOptions{}); err == nil {
+		cd.Status.LastAppliedSpec = synced.Status.LastAppliedSpec
+		cd.Status.AlertThreads = synced.Status.AlertThreads
+	}
+	return cd
+}
+
 func (c *Controller) hasCanaryRevisionChanged(canary *flaggerv1.Canary, canaryController canary.Controller) bool {
 	if canary.Status.Phase == flaggerv1.CanaryPhaseProgressing {
 		if diff, _ := canaryController.HasTargetChanged(canary); diff {
@@ -679,30 +741,30 @@ func (c *Controller) hasCanaryRevisionChanged(canary *flaggerv1.Canary, canaryCo
 
 func (c *Controller) rollback(canary *flaggerv1.Canary, canaryController canary.Controller, meshRouter router.Interface) {
 	if canary.Status.FailedChecks >= canary.GetAnalysisThreshold() {
-		c.recordEventWarningf(canary, "Rolling back %s.%s failed checks threshold reached %v",
+		c.recordEventHaltf(canary, "Rolling back %s.%s failed checks threshold reached %v",
 			canary.Name, canary.Namespace, canary.Status.FailedChecks)
 		c.alert(canary, fmt.Sprintf("Failed checks threshold reached %v", canary.Status.FailedChecks),
-			false, flaggerv1.SeverityError)
+			false, flaggerv1.SeverityError, notifier.EventKindRolledBack)
 	}
 
 	// route all traffic back to primary
 	primaryWeight := 100
 	canaryWeight := 0
 	if err := meshRouter.SetRoutes(canary, primaryWeight, canaryWeight, false); err != nil {
-		c.recordEventWarningf(canary, "%v", err)
+		c.recordEventErrorf(canary, "%v", err)
 		return
 	}
 
 	canaryPhaseFailed := canary.DeepCopy()
 	canaryPhaseFailed.Status.Phase = flaggerv1.CanaryPhaseFailed
-	c.recordEventWarningf(canaryPhaseFailed, "Canary failed! Scaling down %s.%s",
+	c.recordEventRolledBackf(canaryPhaseFailed, "Canary failed! Scaling down %s.%s",
 		canaryPhaseFailed.Name, canaryPhaseFailed.Namespace)
 
 	c.recorder.SetWeight(canary, primaryWeight, canaryWeight)
 
 	// shutdown canary
 	if err := canaryController.ScaleToZero(canary); err != nil {
-		c.recordEventWarningf(canary, "%v", err)
+		c.recordEventErrorf(canary, "%v", err)
 		return
 	}
 
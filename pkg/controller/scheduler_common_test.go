@@ -30,3 +30,32 @@ func toFloatPtr(val int) *float64 {
 	v := float64(val)
 	return &v
 }
+
+func newTestClusterMetricTemplate() *flaggerv1.ClusterMetricTemplate {
+	return &flaggerv1.ClusterMetricTemplate{
+		TypeMeta: metav1.TypeMeta{APIVersion: flaggerv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "envoy-cluster",
+		},
+		Spec: flaggerv1.MetricTemplateSpec{
+			Provider: flaggerv1.MetricTemplateProvider{
+				Type:    "prometheus",
+				Address: "fake",
+			},
+			Query: `sum(envoy_cluster_upstream_rq{envoy_cluster_name=~"{{ namespace }}_{{ target }}"})`,
+		},
+	}
+}
+
+func newTestClusterAlertProvider() *flaggerv1.ClusterAlertProvider {
+	return &flaggerv1.ClusterAlertProvider{
+		TypeMeta: metav1.TypeMeta{APIVersion: flaggerv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "slack-cluster",
+		},
+		Spec: flaggerv1.AlertProviderSpec{
+			Type:    "slack",
+			Address: "http://fake.slack",
+		},
+	}
+}
@@ -9,6 +9,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/record"
@@ -49,6 +50,8 @@ func newDaemonSetFixture(c *flaggerv1.Canary) daemonSetFixture {
 		c,
 		newDaemonSetTestMetricTemplate(),
 		newDaemonSetTestAlertProvider(),
+		newTestClusterMetricTemplate(),
+		newTestClusterAlertProvider(),
 	)
 
 	// init Kubernetes clientset and register objects
@@ -70,13 +73,16 @@ func newDaemonSetFixture(c *flaggerv1.Canary) daemonSetFixture {
 	flaggerInformerFactory := informers.NewSharedInformerFactory(flaggerClient, 0)
 
 	fi := Informers{
-		CanaryInformer: flaggerInformerFactory.Flagger().V1beta1().Canaries(),
-		MetricInformer: flaggerInformerFactory.Flagger().V1beta1().MetricTemplates(),
-		AlertInformer:  flaggerInformerFactory.Flagger().V1beta1().AlertProviders(),
+		CanaryInformer:        flaggerInformerFactory.Flagger().V1beta1().Canaries(),
+		MetricInformer:        flaggerInformerFactory.Flagger().V1beta1().MetricTemplates(),
+		AlertInformer:         flaggerInformerFactory.Flagger().V1beta1().AlertProviders(),
+		ClusterMetricInformer: flaggerInformerFactory.Flagger().V1beta1().ClusterMetricTemplates(),
+		ClusterAlertInformer:  flaggerInformerFactory.Flagger().V1beta1().ClusterAlertProviders(),
+		SecretInformer:        kubeinformers.NewSharedInformerFactory(kubeClient, 0).Core().V1().Secrets(),
 	}
 
 	// init router
-	rf := router.NewFactory(nil, kubeClient, flaggerClient, "annotationsPrefix", logger, flaggerClient)
+	rf := router.NewFactory(nil, kubeClient, flaggerClient, "annotationsPrefix", logger, flaggerClient, nil, nil)
 
 	// init observer
 	observerFactory, _ := observers.NewFactory("fake")
@@ -87,7 +93,7 @@ func newDaemonSetFixture(c *flaggerv1.Canary) daemonSetFixture {
 		KubeClient:    kubeClient,
 		FlaggerClient: flaggerClient,
 	}
-	canaryFactory := canary.NewFactory(kubeClient, flaggerClient, configTracker, []string{"app", "name"}, logger)
+	canaryFactory := canary.NewFactory(kubeClient, flaggerClient, configTracker, []string{"app", "name"}, logger, nil)
 
 	ctrl := &Controller{
 		kubeClient:       kubeClient,
@@ -98,17 +104,24 @@ func newDaemonSetFixture(c *flaggerv1.Canary) daemonSetFixture {
 		eventRecorder:    &record.FakeRecorder{},
 		logger:           logger,
 		canaries:         new(sync.Map),
+		failedChecks:     new(sync.Map),
+		webhookAlerts:    new(sync.Map),
 		flaggerWindow:    time.Second,
 		canaryFactory:    canaryFactory,
 		observerFactory:  observerFactory,
 		recorder:         metrics.NewRecorder(controllerAgentName, false),
 		routerFactory:    rf,
 		notifier:         &notifier.NopNotifier{},
+		alertThrottler:   notifier.NewThrottler(),
+		webhookClients:   new(sync.Map),
+		clusterNamespace: "flagger-system",
 	}
 	ctrl.flaggerSynced = alwaysReady
 	ctrl.flaggerInformers.CanaryInformer.Informer().GetIndexer().Add(c)
 	ctrl.flaggerInformers.MetricInformer.Informer().GetIndexer().Add(newDaemonSetTestMetricTemplate())
 	ctrl.flaggerInformers.AlertInformer.Informer().GetIndexer().Add(newDaemonSetTestAlertProvider())
+	ctrl.flaggerInformers.ClusterMetricInformer.Informer().GetIndexer().Add(newTestClusterMetricTemplate())
+	ctrl.flaggerInformers.ClusterAlertInformer.Informer().GetIndexer().Add(newTestClusterAlertProvider())
 
 	meshRouter := rf.MeshRouter("istio")
 
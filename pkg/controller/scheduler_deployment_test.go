@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -615,3 +616,168 @@ func TestScheduler_DeploymentAlerts(t *testing.T) {
 	// init canary and send alerts
 	mocks.ctrl.advanceCanary("podinfo", "default")
 }
+
+func TestScheduler_DeploymentClusterMetricTemplate(t *testing.T) {
+	canary := newDeploymentTestCanary()
+	canary.Spec.Analysis.Metrics = []flaggerv1.CanaryMetric{
+		{
+			Name: "custom",
+			ThresholdRange: &flaggerv1.CanaryThresholdRange{
+				Max: toFloatPtr(100),
+			},
+			Interval: "1m",
+			TemplateRef: &flaggerv1.CrossNamespaceObjectReference{
+				Kind: flaggerv1.ClusterMetricTemplateKind,
+				Name: "envoy-cluster",
+			},
+		},
+	}
+	mocks := newDeploymentFixture(canary)
+	assert.True(t, mocks.ctrl.runMetricChecks(canary))
+
+	// a namespaced template with the same name does not exist
+	canary.Spec.Analysis.Metrics[0].TemplateRef.Kind = flaggerv1.MetricTemplateKind
+	assert.False(t, mocks.ctrl.runMetricChecks(canary))
+}
+
+func TestScheduler_DeploymentClusterAlerts(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		var payload = notifier.SlackPayload{}
+		err = json.Unmarshal(b, &payload)
+		require.NoError(t, err)
+		require.Equal(t, "podinfo.default", payload.Attachments[0].AuthorName)
+	}))
+	defer ts.Close()
+
+	canary := newDeploymentTestCanary()
+	canary.Spec.Analysis.Alerts = []flaggerv1.CanaryAlert{
+		{
+			Name:     "slack-cluster",
+			Severity: "info",
+			ProviderRef: flaggerv1.CrossNamespaceObjectReference{
+				Kind: flaggerv1.ClusterAlertProviderKind,
+				Name: "slack-cluster",
+			},
+		},
+	}
+	mocks := newDeploymentFixture(canary)
+
+	provider := newTestClusterAlertProvider()
+	provider.Spec.Address = ts.URL
+	err := mocks.ctrl.flaggerInformers.ClusterAlertInformer.Informer().GetIndexer().Update(provider)
+	require.NoError(t, err)
+
+	mocks.ctrl.alert(canary, "test", true, flaggerv1.SeverityInfo, notifier.EventKindStarted)
+	assert.Equal(t, 1, calls)
+}
+
+func TestScheduler_DeploymentGlobalAlertsThrottle(t *testing.T) {
+	var messages []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		var payload = notifier.SlackPayload{}
+		err = json.Unmarshal(b, &payload)
+		require.NoError(t, err)
+		messages = append(messages, payload.Attachments[0].Text)
+	}))
+	defer ts.Close()
+
+	mocks := newDeploymentFixture(nil)
+	slack, err := notifier.NewSlack(ts.URL, "flagger", "general")
+	require.NoError(t, err)
+	mocks.ctrl.notifier = slack
+	mocks.ctrl.notifierThrottle = notifier.ThrottleOptions{Window: time.Hour}
+
+	canary := newDeploymentTestCanary()
+	mocks.ctrl.alert(canary, "waiting", false, flaggerv1.SeverityInfo, notifier.EventKindWaiting)
+	mocks.ctrl.alert(canary, "waiting", false, flaggerv1.SeverityInfo, notifier.EventKindWaiting)
+	require.Len(t, messages, 1)
+
+	// the suppressed alerts are flushed with the promotion
+	mocks.ctrl.alert(canary, "promoted", false, flaggerv1.SeverityInfo, notifier.EventKindPromoted)
+	require.Len(t, messages, 2)
+	assert.Contains(t, messages[1], "1 alerts suppressed")
+}
+
+func TestScheduler_DeploymentWebhookFailedAlertOnce(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer ts.Close()
+
+	canary := newDeploymentTestCanary()
+	canary.Spec.Analysis.Alerts = []flaggerv1.CanaryAlert{
+		{
+			Name:   "slack-cluster",
+			Events: []flaggerv1.AlertEvent{flaggerv1.AlertEventWebhookFailed},
+			ProviderRef: flaggerv1.CrossNamespaceObjectReference{
+				Kind: flaggerv1.ClusterAlertProviderKind,
+				Name: "slack-cluster",
+			},
+		},
+	}
+	mocks := newDeploymentFixture(canary)
+
+	provider := newTestClusterAlertProvider()
+	provider.Spec.Address = ts.URL
+	err := mocks.ctrl.flaggerInformers.ClusterAlertInformer.Informer().GetIndexer().Update(provider)
+	require.NoError(t, err)
+
+	webhook := flaggerv1.CanaryWebhook{Name: "pre", Type: flaggerv1.PreRolloutHook}
+	canary.Status.LastAppliedSpec = "rev-1"
+	canary.Status.Phase = flaggerv1.CanaryPhaseProgressing
+	mocks.ctrl.alertWebhookFailed(canary, webhook, "Pre-rollout check pre failed")
+	mocks.ctrl.alertWebhookFailed(canary, webhook, "Pre-rollout check pre failed")
+	assert.Equal(t, 1, calls)
+
+	// the alert is posted again for a new revision
+	canary.Status.LastAppliedSpec = "rev-2"
+	mocks.ctrl.alertWebhookFailed(canary, webhook, "Pre-rollout check pre failed")
+	assert.Equal(t, 2, calls)
+}
+
+func TestScheduler_DeploymentRollbackCloudEvents(t *testing.T) {
+	var types []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		types = append(types, r.Header.Get("ce-type"))
+	}))
+	defer ts.Close()
+
+	canary := newDeploymentTestCanary()
+	canary.Spec.Analysis.Webhooks = []flaggerv1.CanaryWebhook{
+		{Name: "events", Type: flaggerv1.EventHook, URL: ts.URL},
+	}
+	mocks := newDeploymentFixture(canary)
+	mocks.ctrl.advanceCanary("podinfo", "default")
+	mocks.makePrimaryReady(t)
+	mocks.ctrl.advanceCanary("podinfo", "default")
+	mocks.ctrl.eventWebhookFormat = EventWebhookFormatCloudEventsBinary
+	types = nil
+
+	canary.Status.Phase = flaggerv1.CanaryPhaseProgressing
+	canary.Status.FailedChecks = canary.GetAnalysisThreshold()
+	mocks.ctrl.rollback(canary, mocks.deployer, mocks.router)
+
+	// the rollback reason halts the analysis and the rollback is reported once
+	require.NotEmpty(t, types)
+	assert.Equal(t, CloudEventHalted, types[0])
+	var rolledBack int
+	for _, ceType := range types {
+		if ceType == CloudEventRolledBack {
+			rolledBack++
+		}
+	}
+	assert.Equal(t, 1, rolledBack)
+
+	// the generic warnings are typed based on the phase
+	types = nil
+	mocks.ctrl.recordEventWarningf(canary, "Setting canaryAnalysis.iterations: 10")
+	mocks.ctrl.recordEventErrorf(canary, "%v", fmt.Errorf("service podinfo.default update error"))
+	assert.Equal(t, []string{CloudEventProgressing, CloudEventError}, types)
+}
@@ -2,12 +2,15 @@ package controller
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/canary"
+	"github.com/weaveworks/flagger/pkg/internal"
 	"github.com/weaveworks/flagger/pkg/metrics/observers"
 	"github.com/weaveworks/flagger/pkg/metrics/providers"
 )
@@ -31,8 +34,13 @@ func (c *Controller) runBuiltinMetricChecks(canary *flaggerv1.Canary) bool {
 			metricsProvider = "linkerd"
 		}
 	}
+	// set the metrics provider to EDAS when the Alibaba Cloud extension is on,
+	// Dubbo and Spring Cloud traffic doesn't go through the mesh
+	if internal.IsExtentOn(canary) {
+		metricsProvider = "edas"
+	}
 	// set the metrics provider to query Prometheus for the canary Kubernetes service if the canary target is Service
-	if canary.Spec.TargetRef.Kind == "Service" {
+	if canary.GetTargetKind() == "Service" {
 		metricsProvider = metricsProvider + MetricsProviderServiceSuffix
 	}
 
@@ -48,7 +56,15 @@ func (c *Controller) runBuiltinMetricChecks(canary *flaggerv1.Canary) bool {
 			return false
 		}
 	}
-	observer := observerFactory.Observer(metricsProvider)
+	observer, observerErr := observerFactory.Observer(metricsProvider)
+
+	// the builtin queries reference the target metadata only to select the Knative canary revision
+	// and to derive the EDAS provider version from the canary image tag
+	metadata, err := c.getTargetMetadata(canary, canary.GetTargetKind() == flaggerv1.KnativeServiceKind || isEdasObserver(observer))
+	if err != nil {
+		c.recordEventErrorf(canary, "Metric template model error: %v", err)
+		return false
+	}
 
 	// run metrics checks
 	for _, metric := range canary.GetAnalysis().Metrics {
@@ -56,11 +72,16 @@ func (c *Controller) runBuiltinMetricChecks(canary *flaggerv1.Canary) bool {
 			metric.Interval = canary.GetMetricInterval()
 		}
 
+		if isBuiltinMetric(metric.Name) && observerErr != nil {
+			c.recordEventErrorf(canary, "Builtin metric %s is not supported: %v", metric.Name, observerErr)
+			return false
+		}
+
 		if metric.Name == "request-success-rate" {
-			val, err := observer.GetRequestSuccessRate(toMetricModel(canary, metric.Interval))
+			val, err := observer.GetRequestSuccessRate(toMetricModel(canary, metadata, metric.Interval, metric.TemplateVariables))
 			if err != nil {
 				if errors.Is(err, providers.ErrNoValuesFound) {
-					c.recordEventWarningf(canary,
+					c.recordMetricHaltf(canary,
 						"Halt advancement no values found for %s metric %s probably %s.%s is not receiving traffic: %v",
 						metricsProvider, metric.Name, canary.Spec.TargetRef.Name, canary.Namespace, err)
 				} else {
@@ -72,27 +93,99 @@ func (c *Controller) runBuiltinMetricChecks(canary *flaggerv1.Canary) bool {
 			if metric.ThresholdRange != nil {
 				tr := *metric.ThresholdRange
 				if tr.Min != nil && val < *tr.Min {
-					c.recordEventWarningf(canary, "Halt %s.%s advancement success rate %.2f%% < %v%%",
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement success rate %.2f%% < %v%%",
 						canary.Name, canary.Namespace, val, *tr.Min)
 					return false
 				}
 				if tr.Max != nil && val > *tr.Max {
-					c.recordEventWarningf(canary, "Halt %s.%s advancement success rate %.2f%% > %v%%",
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement success rate %.2f%% > %v%%",
 						canary.Name, canary.Namespace, val, *tr.Max)
 					return false
 				}
 			} else if metric.Threshold > val {
-				c.recordEventWarningf(canary, "Halt %s.%s advancement success rate %.2f%% < %v%%",
+				c.recordMetricHaltf(canary, "Halt %s.%s advancement success rate %.2f%% < %v%%",
 					canary.Name, canary.Namespace, val, metric.Threshold)
 				return false
 			}
 		}
 
 		if metric.Name == "request-duration" {
-			val, err := observer.GetRequestDuration(toMetricModel(canary, metric.Interval))
+			val, err := observer.GetRequestDuration(toMetricModel(canary, metadata, metric.Interval, metric.TemplateVariables))
+			if errors.Is(err, observers.ErrTCPDurationNotSupported) {
+				// the TCP services are checked with the connection and byte metrics
+				c.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+					Debugf("Skipping metric %s: %v", metric.Name, err)
+				continue
+			}
 			if err != nil {
 				if errors.Is(err, providers.ErrNoValuesFound) {
-					c.recordEventWarningf(canary, "Halt advancement no values found for %s metric %s probably %s.%s is not receiving traffic",
+					c.recordMetricHaltf(canary, "Halt advancement no values found for %s metric %s probably %s.%s is not receiving traffic",
+						metricsProvider, metric.Name, canary.Spec.TargetRef.Name, canary.Namespace)
+				} else {
+					c.recordEventErrorf(canary, "Prometheus query failed: %v", err)
+				}
+				return false
+			}
+			if metric.ThresholdRange != nil {
+				tr := *metric.ThresholdRange
+				if tr.Min != nil && val < time.Duration(*tr.Min)*time.Millisecond {
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement request duration %v < %v",
+						canary.Name, canary.Namespace, val, time.Duration(*tr.Min)*time.Millisecond)
+					return false
+				}
+				if tr.Max != nil && val > time.Duration(*tr.Max)*time.Millisecond {
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement request duration %v > %v",
+						canary.Name, canary.Namespace, val, time.Duration(*tr.Max)*time.Millisecond)
+					return false
+				}
+			} else if val > time.Duration(metric.Threshold)*time.Millisecond {
+				c.recordMetricHaltf(canary, "Halt %s.%s advancement request duration %v > %v",
+					canary.Name, canary.Namespace, val, time.Duration(metric.Threshold)*time.Millisecond)
+				return false
+			}
+		}
+
+		if metric.Name == "grpc-success-rate" {
+			val, err := observer.GetGrpcSuccessRate(toMetricModel(canary, metadata, metric.Interval, metric.TemplateVariables), metric.GrpcErrorCodes)
+			if err != nil {
+				if errors.Is(err, observers.ErrGrpcNotSupported) {
+					c.recordEventErrorf(canary, "Builtin metric %s is not supported by %s: %v", metric.Name, metricsProvider, err)
+				} else if errors.Is(err, providers.ErrNoValuesFound) {
+					c.recordMetricHaltf(canary,
+						"Halt advancement no values found for %s metric %s probably %s.%s is not receiving traffic: %v",
+						metricsProvider, metric.Name, canary.Spec.TargetRef.Name, canary.Namespace, err)
+				} else {
+					c.recordEventErrorf(canary, "Prometheus query failed: %v", err)
+				}
+				return false
+			}
+
+			if metric.ThresholdRange != nil {
+				tr := *metric.ThresholdRange
+				if tr.Min != nil && val < *tr.Min {
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement gRPC success rate %.2f%% < %v%%",
+						canary.Name, canary.Namespace, val, *tr.Min)
+					return false
+				}
+				if tr.Max != nil && val > *tr.Max {
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement gRPC success rate %.2f%% > %v%%",
+						canary.Name, canary.Namespace, val, *tr.Max)
+					return false
+				}
+			} else if metric.Threshold > val {
+				c.recordMetricHaltf(canary, "Halt %s.%s advancement gRPC success rate %.2f%% < %v%%",
+					canary.Name, canary.Namespace, val, metric.Threshold)
+				return false
+			}
+		}
+
+		if metric.Name == "grpc-duration" {
+			val, err := observer.GetGrpcDuration(toMetricModel(canary, metadata, metric.Interval, metric.TemplateVariables))
+			if err != nil {
+				if errors.Is(err, observers.ErrGrpcNotSupported) {
+					c.recordEventErrorf(canary, "Builtin metric %s is not supported by %s: %v", metric.Name, metricsProvider, err)
+				} else if errors.Is(err, providers.ErrNoValuesFound) {
+					c.recordMetricHaltf(canary, "Halt advancement no values found for %s metric %s probably %s.%s is not receiving traffic",
 						metricsProvider, metric.Name, canary.Spec.TargetRef.Name, canary.Namespace)
 				} else {
 					c.recordEventErrorf(canary, "Prometheus query failed: %v", err)
@@ -102,28 +195,75 @@ func (c *Controller) runBuiltinMetricChecks(canary *flaggerv1.Canary) bool {
 			if metric.ThresholdRange != nil {
 				tr := *metric.ThresholdRange
 				if tr.Min != nil && val < time.Duration(*tr.Min)*time.Millisecond {
-					c.recordEventWarningf(canary, "Halt %s.%s advancement request duration %v < %v",
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement gRPC duration %v < %v",
 						canary.Name, canary.Namespace, val, time.Duration(*tr.Min)*time.Millisecond)
 					return false
 				}
 				if tr.Max != nil && val > time.Duration(*tr.Max)*time.Millisecond {
-					c.recordEventWarningf(canary, "Halt %s.%s advancement request duration %v > %v",
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement gRPC duration %v > %v",
 						canary.Name, canary.Namespace, val, time.Duration(*tr.Max)*time.Millisecond)
 					return false
 				}
 			} else if val > time.Duration(metric.Threshold)*time.Millisecond {
-				c.recordEventWarningf(canary, "Halt %s.%s advancement request duration %v > %v",
+				c.recordMetricHaltf(canary, "Halt %s.%s advancement gRPC duration %v > %v",
 					canary.Name, canary.Namespace, val, time.Duration(metric.Threshold)*time.Millisecond)
 				return false
 			}
 		}
 
+		if isTCPMetric(metric.Name) {
+			tcpObserver, ok := observer.(observers.TCPInterface)
+			if !ok {
+				c.recordEventErrorf(canary, "Builtin metric %s is not supported: %v", metric.Name, observers.ErrTCPNotSupported)
+				return false
+			}
+
+			model := toMetricModel(canary, metadata, metric.Interval, metric.TemplateVariables)
+			var val float64
+			switch metric.Name {
+			case "tcp-connection-success-rate":
+				val, err = tcpObserver.GetTCPConnectionSuccessRate(model)
+			case "tcp-sent-bytes":
+				val, err = tcpObserver.GetTCPSentBytes(model)
+			case "tcp-received-bytes":
+				val, err = tcpObserver.GetTCPReceivedBytes(model)
+			}
+			if err != nil {
+				if errors.Is(err, providers.ErrNoValuesFound) {
+					c.recordMetricHaltf(canary,
+						"Halt advancement no values found for %s metric %s probably %s.%s is not receiving traffic: %v",
+						metricsProvider, metric.Name, canary.Spec.TargetRef.Name, canary.Namespace, err)
+				} else {
+					c.recordEventErrorf(canary, "Prometheus query failed: %v", err)
+				}
+				return false
+			}
+
+			if metric.ThresholdRange != nil {
+				tr := *metric.ThresholdRange
+				if tr.Min != nil && val < *tr.Min {
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement %s %.2f < %v",
+						canary.Name, canary.Namespace, metric.Name, val, *tr.Min)
+					return false
+				}
+				if tr.Max != nil && val > *tr.Max {
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement %s %.2f > %v",
+						canary.Name, canary.Namespace, metric.Name, val, *tr.Max)
+					return false
+				}
+			} else if metric.Threshold > val {
+				c.recordMetricHaltf(canary, "Halt %s.%s advancement %s %.2f < %v",
+					canary.Name, canary.Namespace, metric.Name, val, metric.Threshold)
+				return false
+			}
+		}
+
 		// in-line PromQL
 		if metric.Query != "" {
 			val, err := observerFactory.Client.RunQuery(metric.Query)
 			if err != nil {
 				if errors.Is(err, providers.ErrNoValuesFound) {
-					c.recordEventWarningf(canary, "Halt advancement no values found for metric: %s",
+					c.recordMetricHaltf(canary, "Halt advancement no values found for metric: %s",
 						metric.Name)
 				} else {
 					c.recordEventErrorf(canary, "Prometheus query failed for %s: %v", metric.Name, err)
@@ -133,17 +273,17 @@ func (c *Controller) runBuiltinMetricChecks(canary *flaggerv1.Canary) bool {
 			if metric.ThresholdRange != nil {
 				tr := *metric.ThresholdRange
 				if tr.Min != nil && val < *tr.Min {
-					c.recordEventWarningf(canary, "Halt %s.%s advancement %s %.2f < %v",
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement %s %.2f < %v",
 						canary.Name, canary.Namespace, metric.Name, val, *tr.Min)
 					return false
 				}
 				if tr.Max != nil && val > *tr.Max {
-					c.recordEventWarningf(canary, "Halt %s.%s advancement %s %.2f > %v",
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement %s %.2f > %v",
 						canary.Name, canary.Namespace, metric.Name, val, *tr.Max)
 					return false
 				}
 			} else if val > metric.Threshold {
-				c.recordEventWarningf(canary, "Halt %s.%s advancement %s %.2f > %v",
+				c.recordMetricHaltf(canary, "Halt %s.%s advancement %s %.2f > %v",
 					canary.Name, canary.Namespace, metric.Name, val, metric.Threshold)
 				return false
 			}
@@ -161,32 +301,52 @@ func (c *Controller) runMetricChecks(canary *flaggerv1.Canary) bool {
 				namespace = metric.TemplateRef.Namespace
 			}
 
-			template, err := c.flaggerInformers.MetricInformer.Lister().MetricTemplates(namespace).Get(metric.TemplateRef.Name)
-			if err != nil {
-				c.recordEventErrorf(canary, "Metric template %s.%s error: %v", metric.TemplateRef.Name, namespace, err)
-				return false
+			var templateSpec flaggerv1.MetricTemplateSpec
+			if metric.TemplateRef.Kind == flaggerv1.ClusterMetricTemplateKind {
+				// cluster metric templates read their credentials from the Flagger namespace
+				namespace = c.clusterNamespace
+				template, err := c.flaggerInformers.ClusterMetricInformer.Lister().Get(metric.TemplateRef.Name)
+				if err != nil {
+					c.recordEventErrorf(canary, "Cluster metric template %s error: %v", metric.TemplateRef.Name, err)
+					return false
+				}
+				templateSpec = template.Spec
+			} else {
+				template, err := c.flaggerInformers.MetricInformer.Lister().MetricTemplates(namespace).Get(metric.TemplateRef.Name)
+				if err != nil {
+					c.recordEventErrorf(canary, "Metric template %s.%s error: %v", metric.TemplateRef.Name, namespace, err)
+					return false
+				}
+				templateSpec = template.Spec
 			}
 
 			var credentials map[string][]byte
-			if template.Spec.Provider.SecretRef != nil {
-				secret, err := c.kubeClient.CoreV1().Secrets(namespace).Get(template.Spec.Provider.SecretRef.Name, metav1.GetOptions{})
+			if templateSpec.Provider.SecretRef != nil {
+				secret, err := c.kubeClient.CoreV1().Secrets(namespace).Get(templateSpec.Provider.SecretRef.Name, metav1.GetOptions{})
 				if err != nil {
 					c.recordEventErrorf(canary, "Metric template %s.%s secret %s error: %v",
-						metric.TemplateRef.Name, namespace, template.Spec.Provider.SecretRef.Name, err)
+						metric.TemplateRef.Name, namespace, templateSpec.Provider.SecretRef.Name, err)
 					return false
 				}
 				credentials = secret.Data
 			}
 
 			factory := providers.Factory{}
-			provider, err := factory.Provider(metric.Interval, template.Spec.Provider, credentials)
+			provider, err := factory.Provider(metric.Interval, templateSpec.Provider, credentials)
 			if err != nil {
 				c.recordEventErrorf(canary, "Metric template %s.%s provider %s error: %v",
-					metric.TemplateRef.Name, namespace, template.Spec.Provider.Type, err)
+					metric.TemplateRef.Name, namespace, templateSpec.Provider.Type, err)
+				return false
+			}
+
+			metadata, err := c.getTargetMetadata(canary, usesTargetMetadata(templateSpec.Query))
+			if err != nil {
+				c.recordEventErrorf(canary, "Metric template %s.%s model error: %v",
+					metric.TemplateRef.Name, namespace, err)
 				return false
 			}
 
-			query, err := observers.RenderQuery(template.Spec.Query, toMetricModel(canary, metric.Interval))
+			query, err := observers.RenderQuery(templateSpec.Query, toMetricModel(canary, metadata, metric.Interval, metric.TemplateVariables))
 			if err != nil {
 				c.recordEventErrorf(canary, "Metric template %s.%s query render error: %v",
 					metric.TemplateRef.Name, namespace, err)
@@ -196,7 +356,7 @@ func (c *Controller) runMetricChecks(canary *flaggerv1.Canary) bool {
 			val, err := provider.RunQuery(query)
 			if err != nil {
 				if errors.Is(err, providers.ErrNoValuesFound) {
-					c.recordEventWarningf(canary, "Halt advancement no values found for custom metric: %s: %v",
+					c.recordMetricHaltf(canary, "Halt advancement no values found for custom metric: %s: %v",
 						metric.Name, err)
 				} else {
 					c.recordEventErrorf(canary, "Metric query failed for %s: %v", metric.Name, err)
@@ -207,17 +367,17 @@ func (c *Controller) runMetricChecks(canary *flaggerv1.Canary) bool {
 			if metric.ThresholdRange != nil {
 				tr := *metric.ThresholdRange
 				if tr.Min != nil && val < *tr.Min {
-					c.recordEventWarningf(canary, "Halt %s.%s advancement %s %.2f < %v",
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement %s %.2f < %v",
 						canary.Name, canary.Namespace, metric.Name, val, *tr.Min)
 					return false
 				}
 				if tr.Max != nil && val > *tr.Max {
-					c.recordEventWarningf(canary, "Halt %s.%s advancement %s %.2f > %v",
+					c.recordMetricHaltf(canary, "Halt %s.%s advancement %s %.2f > %v",
 						canary.Name, canary.Namespace, metric.Name, val, *tr.Max)
 					return false
 				}
 			} else if val > metric.Threshold {
-				c.recordEventWarningf(canary, "Halt %s.%s advancement %s %.2f > %v",
+				c.recordMetricHaltf(canary, "Halt %s.%s advancement %s %.2f > %v",
 					canary.Name, canary.Namespace, metric.Name, val, metric.Threshold)
 				return false
 			}
@@ -227,7 +387,21 @@ func (c *Controller) runMetricChecks(canary *flaggerv1.Canary) bool {
 	return true
 }
 
-func toMetricModel(r *flaggerv1.Canary, interval string) flaggerv1.MetricTemplateModel {
+// usesTargetMetadata returns true if the query template calls the target selectors or images functions
+func usesTargetMetadata(query string) bool {
+	return observers.QueryUsesFunctions(query, "canarySelector", "primarySelector", "image", "imageTag")
+}
+
+// getTargetMetadata returns the pod selectors and images of the target only when the queries require them,
+// a failed lookup shouldn't halt the analysis of the metrics that don't reference the metadata
+func (c *Controller) getTargetMetadata(cd *flaggerv1.Canary, required bool) (*canary.TargetMetadata, error) {
+	if !required {
+		return nil, nil
+	}
+	return c.canaryFactory.Controller(cd.GetTargetKind()).GetTargetMetadata(cd)
+}
+
+func toMetricModel(r *flaggerv1.Canary, metadata *canary.TargetMetadata, interval string, variables map[string]string) flaggerv1.MetricTemplateModel {
 	service := r.Spec.TargetRef.Name
 	if r.Spec.Service.Name != "" {
 		service = r.Spec.Service.Name
@@ -236,12 +410,47 @@ func toMetricModel(r *flaggerv1.Canary, interval string) flaggerv1.MetricTemplat
 	if r.Spec.IngressRef != nil {
 		ingress = r.Spec.IngressRef.Name
 	}
-	return flaggerv1.MetricTemplateModel{
-		Name:      r.Name,
-		Namespace: r.Namespace,
-		Target:    r.Spec.TargetRef.Name,
-		Service:   service,
-		Ingress:   ingress,
-		Interval:  interval,
+	model := flaggerv1.MetricTemplateModel{
+		Name:         r.Name,
+		Namespace:    r.Namespace,
+		Target:       r.Spec.TargetRef.Name,
+		Primary:      internal.GetSourceName(r),
+		Service:      service,
+		Ingress:      ingress,
+		Interval:     interval,
+		Protocol:     string(r.GetAppProtocol()),
+		CanaryWeight: r.Status.CanaryWeight,
+		Iterations:   r.Status.Iterations,
+		Variables:    variables,
+	}
+	if metadata != nil {
+		model.CanarySelector = metadata.CanarySelector
+		model.PrimarySelector = metadata.PrimarySelector
+		model.Images = metadata.CanaryImages
+	}
+	return model
+}
+
+// isEdasObserver returns true if the builtin metrics are computed from the Dubbo or Spring Cloud metrics
+func isEdasObserver(observer observers.Interface) bool {
+	_, ok := observer.(*observers.EdasObserver)
+	return ok
+}
+
+// isBuiltinMetric returns true if the metric is implemented by the mesh provider observer
+func isBuiltinMetric(name string) bool {
+	switch name {
+	case "request-success-rate", "request-duration", "grpc-success-rate", "grpc-duration":
+		return true
+	}
+	return isTCPMetric(name)
+}
+
+// isTCPMetric returns true for the builtin metrics of the TCP connections and bytes
+func isTCPMetric(name string) bool {
+	switch name {
+	case "tcp-connection-success-rate", "tcp-sent-bytes", "tcp-received-bytes":
+		return true
 	}
+	return false
 }
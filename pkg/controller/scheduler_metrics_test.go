@@ -0,0 +1,16 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsesTargetMetadata(t *testing.T) {
+	require.True(t, usesTargetMetadata(`sum(rate(http_requests_total{ {{ canarySelector }} }[{{ interval }}]))`))
+	require.True(t, usesTargetMetadata(`sum(rate(http_requests_total{ {{- canarySelector "label_" -}} }[1m]))`))
+	require.True(t, usesTargetMetadata(`up{version="{{ "podinfo" | imageTag }}"}`))
+	require.True(t, usesTargetMetadata(`{{ if eq (variable "mode") "primary" }}{{ primarySelector }}{{ end }}`))
+	require.False(t, usesTargetMetadata(`sum(rate(container_cpu{image="podinfo", pod=~"{{ target }}-.*"}[{{ interval }}]))`))
+	require.False(t, usesTargetMetadata(`{{/* canarySelector */}}sum(rate(http_requests_total{namespace="{{ namespace }}"}[1m]))`))
+}
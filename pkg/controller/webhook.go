@@ -15,9 +15,21 @@ import (
 	"k8s.io/utils/clock"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/signature"
 )
 
+// webhookOptions holds the headers, the signing key and the HTTP client of the webhook requests
+type webhookOptions struct {
+	headers    map[string]string
+	signingKey []byte
+	client     *http.Client
+}
+
 func callWebhook(webhook string, payload interface{}, timeout string) error {
+	return postWebhook(webhook, payload, webhookOptions{}, timeout)
+}
+
+func postWebhook(webhook string, payload interface{}, opts webhookOptions, timeout string) error {
 	payloadBin, err := json.Marshal(payload)
 	if err != nil {
 		return err
@@ -34,6 +46,12 @@ func callWebhook(webhook string, payload interface{}, timeout string) error {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	for k, v := range opts.headers {
+		req.Header.Set(k, v)
+	}
+	if len(opts.signingKey) > 0 {
+		signature.SignRequest(req, opts.signingKey, payloadBin, time.Now())
+	}
 
 	if timeout == "" {
 		timeout = "10s"
@@ -47,7 +65,12 @@ func callWebhook(webhook string, payload interface{}, timeout string) error {
 	ctx, cancel := context.WithTimeout(req.Context(), t)
 	defer cancel()
 
-	r, err := http.DefaultClient.Do(req.WithContext(ctx))
+	client := http.DefaultClient
+	if opts.client != nil {
+		client = opts.client
+	}
+
+	r, err := client.Do(req.WithContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -59,12 +82,125 @@ func callWebhook(webhook string, payload interface{}, timeout string) error {
 	}
 
 	if r.StatusCode > 202 {
-		return errors.New(string(b))
+		return &webhookStatusError{statusCode: r.StatusCode, body: string(b)}
 	}
 
 	return nil
 }
 
+// webhookStatusError is returned when the webhook responds with a non-2xx status code
+type webhookStatusError struct {
+	statusCode int
+	body       string
+}
+
+func (e *webhookStatusError) Error() string {
+	return e.body
+}
+
+const (
+	// maxWebhookAttempts caps the number of attempts of a webhook retry policy
+	maxWebhookAttempts = 5
+	// maxWebhookBackoff caps the wait time between two attempts
+	maxWebhookBackoff = 30 * time.Second
+)
+
+// defaultRetryStatusCodes are the status codes retried when the retry policy doesn't specify any
+var defaultRetryStatusCodes = []int{
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// postWebhookWithRetry calls the webhook until it succeeds, the error is not retryable
+// or the retry attempts are exhausted, the backoff is doubled after each attempt.
+// The attempts stop when the next one wouldn't complete within maxDuration or when stopCh is closed.
+func postWebhookWithRetry(webhook string, payload interface{}, opts webhookOptions, timeout string,
+	retry *flaggerv1.CanaryWebhookRetry, idempotent bool, maxDuration time.Duration, stopCh <-chan struct{}) error {
+	if retry == nil || retry.Attempts < 2 {
+		return postWebhook(webhook, payload, opts, timeout)
+	}
+
+	backoff := time.Second
+	if retry.Backoff != "" {
+		d, err := time.ParseDuration(retry.Backoff)
+		if err != nil {
+			return fmt.Errorf("invalid retry backoff %s: %w", retry.Backoff, err)
+		}
+		backoff = d
+	}
+
+	requestTimeout, err := time.ParseDuration(timeout)
+	if err != nil {
+		return err
+	}
+
+	attempts := retry.Attempts
+	if attempts > maxWebhookAttempts {
+		attempts = maxWebhookAttempts
+	}
+
+	statusCodes := retry.StatusCodes
+	if len(statusCodes) == 0 {
+		statusCodes = defaultRetryStatusCodes
+	}
+
+	start := time.Now()
+	attempt := 1
+	for ; ; attempt++ {
+		err = postWebhook(webhook, payload, opts, timeout)
+		if err == nil || !isRetryable(err, statusCodes, idempotent) {
+			return err
+		}
+		if attempt == attempts || time.Since(start)+backoff+requestTimeout > maxDuration {
+			break
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-stopCh:
+			timer.Stop()
+			return fmt.Errorf("retries cancelled after %d attempts, last error: %w", attempt, err)
+		}
+		backoff *= 2
+		if backoff > maxWebhookBackoff {
+			backoff = maxWebhookBackoff
+		}
+	}
+	return fmt.Errorf("%d attempts failed, last error: %w", attempt, err)
+}
+
+// isRetryable returns true for connection errors and the retryable 5xx status codes,
+// the timeouts are retried only for the idempotent hooks as the request may have been processed
+func isRetryable(err error, statusCodes []int, idempotent bool) bool {
+	var statusErr *webhookStatusError
+	if !errors.As(err, &statusErr) {
+		var urlErr *url.Error
+		if !errors.As(err, &urlErr) {
+			return false
+		}
+		return idempotent || !urlErr.Timeout()
+	}
+	if statusErr.statusCode < http.StatusInternalServerError {
+		return false
+	}
+	for _, code := range statusCodes {
+		if statusErr.statusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdempotentHook returns true for the gates that only query the state of an external system
+func isIdempotentHook(hookType flaggerv1.HookType) bool {
+	switch hookType {
+	case flaggerv1.ConfirmRolloutHook, flaggerv1.ConfirmPromotionHook, flaggerv1.RollbackHook:
+		return true
+	}
+	return false
+}
+
 // CallWebhook does a HTTP POST to an external service and
 // returns an error if the response status code is non-2xx
 func CallWebhook(name string, namespace string, phase flaggerv1.CanaryPhase, w flaggerv1.CanaryWebhook) error {
@@ -72,8 +208,15 @@ func CallWebhook(name string, namespace string, phase flaggerv1.CanaryPhase, w f
 		Name:      name,
 		Namespace: namespace,
 		Phase:     phase,
+		Type:      w.Type,
 	}
+	return callWebhookWithOptions(payload, w, webhookOptions{}, flaggerv1.AnalysisInterval, nil)
+}
 
+// callWebhookWithOptions calls the webhook, the retries are bound by the analysis interval
+// and are cancelled when stopCh is closed
+func callWebhookWithOptions(payload flaggerv1.CanaryWebhookPayload, w flaggerv1.CanaryWebhook, opts webhookOptions,
+	interval time.Duration, stopCh <-chan struct{}) error {
 	if w.Metadata != nil {
 		payload.Metadata = *w.Metadata
 	}
@@ -82,16 +225,21 @@ func CallWebhook(name string, namespace string, phase flaggerv1.CanaryPhase, w f
 		w.Timeout = "10s"
 	}
 
-	return callWebhook(w.URL, payload, w.Timeout)
+	return postWebhookWithRetry(w.URL, payload, opts, w.Timeout, w.Retry, isIdempotentHook(w.Type), interval, stopCh)
 }
 
 func CallEventWebhook(r *flaggerv1.Canary, webhook, message, eventtype string) error {
 	t := clock.RealClock{}.Now()
 
 	payload := flaggerv1.CanaryWebhookPayload{
-		Name:      r.Name,
-		Namespace: r.Namespace,
-		Phase:     r.Status.Phase,
+		Name:         r.Name,
+		Namespace:    r.Namespace,
+		Phase:        r.Status.Phase,
+		Type:         flaggerv1.EventHook,
+		Weight:       r.Status.CanaryWeight,
+		Iterations:   r.Status.Iterations,
+		FailedChecks: r.Status.FailedChecks,
+		Revision:     r.Status.LastAppliedSpec,
 		Metadata: map[string]string{
 			"eventMessage": message,
 			"eventType":    eventtype,
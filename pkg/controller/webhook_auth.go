@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+)
+
+// runWebhook calls the canary webhook with the credentials read from the webhook secrets
+func (c *Controller) runWebhook(canary *flaggerv1.Canary, phase flaggerv1.CanaryPhase, w flaggerv1.CanaryWebhook) error {
+	opts, err := c.webhookOptions(canary.Namespace, w)
+	if err != nil {
+		return err
+	}
+	return callWebhookWithOptions(c.webhookPayload(canary, phase, w), w, opts, canary.GetAnalysisInterval(), c.stopCh)
+}
+
+// webhookPayload returns the canary analysis state and the canary target images sent to the webhook
+func (c *Controller) webhookPayload(canary *flaggerv1.Canary, phase flaggerv1.CanaryPhase, w flaggerv1.CanaryWebhook) flaggerv1.CanaryWebhookPayload {
+	payload := flaggerv1.CanaryWebhookPayload{
+		Name:         canary.Name,
+		Namespace:    canary.Namespace,
+		Phase:        phase,
+		Type:         w.Type,
+		Weight:       canary.Status.CanaryWeight,
+		Iterations:   canary.Status.Iterations,
+		FailedChecks: canary.Status.FailedChecks,
+		Revision:     canary.Status.LastAppliedSpec,
+	}
+
+	if c.canaryFactory != nil {
+		if metadata, err := c.canaryFactory.Controller(canary.GetTargetKind()).GetTargetMetadata(canary); err == nil {
+			payload.Images = metadata.CanaryImages
+		}
+	}
+
+	return payload
+}
+
+// webhookOptions reads the signing key, the HTTP headers and the TLS config of a webhook
+func (c *Controller) webhookOptions(namespace string, w flaggerv1.CanaryWebhook) (webhookOptions, error) {
+	var opts webhookOptions
+
+	if w.SecretRef != nil {
+		secret, err := c.flaggerInformers.SecretInformer.Lister().Secrets(namespace).Get(w.SecretRef.Name)
+		if err != nil {
+			return opts, fmt.Errorf("webhook %s secret %s.%s error: %w", w.Name, w.SecretRef.Name, namespace, err)
+		}
+
+		opts.headers = make(map[string]string)
+		for k, v := range secret.Data {
+			switch k {
+			case "hmacKey":
+				opts.signingKey = v
+			case "token":
+				opts.headers["Authorization"] = fmt.Sprintf("Bearer %s", string(v))
+			default:
+				opts.headers[k] = string(v)
+			}
+		}
+	}
+
+	if w.TLSSecretRef != nil {
+		secret, err := c.flaggerInformers.SecretInformer.Lister().Secrets(namespace).Get(w.TLSSecretRef.Name)
+		if err != nil {
+			return opts, fmt.Errorf("webhook %s TLS secret %s.%s error: %w", w.Name, w.TLSSecretRef.Name, namespace, err)
+		}
+
+		client, err := c.webhookClient(secret)
+		if err != nil {
+			return opts, fmt.Errorf("webhook %s TLS secret %s.%s error: %w", w.Name, w.TLSSecretRef.Name, namespace, err)
+		}
+		opts.client = client
+	}
+
+	return opts, nil
+}
+
+// webhookTLSClient is the HTTP client built from a version of a TLS secret
+type webhookTLSClient struct {
+	resourceVersion string
+	client          *http.Client
+}
+
+// webhookClient returns the HTTP client of a TLS secret, the client is reused until the secret changes
+// so that the webhook calls share the transport connections
+func (c *Controller) webhookClient(secret *corev1.Secret) (*http.Client, error) {
+	key := fmt.Sprintf("%s.%s", secret.Name, secret.Namespace)
+	if value, ok := c.webhookClients.Load(key); ok {
+		cached := value.(webhookTLSClient)
+		if cached.resourceVersion == secret.ResourceVersion {
+			return cached.client, nil
+		}
+		cached.client.CloseIdleConnections()
+	}
+
+	tlsConfig, err := webhookTLSConfig(secret.Data)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	c.webhookClients.Store(key, webhookTLSClient{resourceVersion: secret.ResourceVersion, client: client})
+	return client, nil
+}
+
+// webhookTLSConfig returns the TLS config with the client certificate and the CA from the secret data
+func webhookTLSConfig(data map[string][]byte) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if ca, ok := data["ca.crt"]; ok {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("ca.crt does not contain a valid PEM certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	cert, hasCert := data["tls.crt"]
+	key, hasKey := data["tls.key"]
+	if hasCert != hasKey {
+		return nil, errors.New("tls.crt and tls.key must be specified together")
+	}
+	if hasCert {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate parsing failed: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+
+	return tlsConfig, nil
+}
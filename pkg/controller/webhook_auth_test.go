@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/signature"
+)
+
+func newWebhookTestController(t *testing.T, secrets ...*corev1.Secret) *Controller {
+	kubeClient := fake.NewSimpleClientset()
+	secretInformer := kubeinformers.NewSharedInformerFactory(kubeClient, 0).Core().V1().Secrets()
+	for _, secret := range secrets {
+		require.NoError(t, secretInformer.Informer().GetIndexer().Add(secret))
+	}
+	return &Controller{
+		kubeClient:       kubeClient,
+		flaggerInformers: Informers{SecretInformer: secretInformer},
+		webhookClients:   new(sync.Map),
+	}
+}
+
+func TestRunWebhook_Secret(t *testing.T) {
+	key := []byte("hmac-key")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, signature.Verify(key, r.Header, body, signature.DefaultTolerance, time.Now()))
+		require.Equal(t, "Bearer secret-token", r.Header.Get("Authorization"))
+		require.Equal(t, "flagger", r.Header.Get("X-Api-Key"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook-auth", Namespace: "default"},
+		Data: map[string][]byte{
+			"hmacKey":   key,
+			"token":     []byte("secret-token"),
+			"X-Api-Key": []byte("flagger"),
+		},
+	}
+	ctrl := newWebhookTestController(t, secret)
+
+	canary := newDeploymentTestCanary()
+	hook := flaggerv1.CanaryWebhook{
+		Name:      "validation",
+		URL:       ts.URL,
+		SecretRef: &corev1.LocalObjectReference{Name: "webhook-auth"},
+	}
+	err := ctrl.runWebhook(canary, flaggerv1.CanaryPhaseProgressing, hook)
+	require.NoError(t, err)
+
+	hook.SecretRef.Name = "missing"
+	err = ctrl.runWebhook(canary, flaggerv1.CanaryPhaseProgressing, hook)
+	require.Error(t, err)
+}
+
+func TestRunWebhook_TLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook-tls", Namespace: "default"},
+		Data:       map[string][]byte{"ca.crt": ca},
+	}
+	ctrl := newWebhookTestController(t, secret)
+
+	canary := newDeploymentTestCanary()
+	hook := flaggerv1.CanaryWebhook{
+		Name: "validation",
+		URL:  ts.URL,
+	}
+	err := ctrl.runWebhook(canary, flaggerv1.CanaryPhaseProgressing, hook)
+	require.Error(t, err)
+
+	hook.TLSSecretRef = &corev1.LocalObjectReference{Name: "webhook-tls"}
+	err = ctrl.runWebhook(canary, flaggerv1.CanaryPhaseProgressing, hook)
+	require.NoError(t, err)
+
+	// the client is reused until the secret changes
+	opts, err := ctrl.webhookOptions("default", hook)
+	require.NoError(t, err)
+	cached, err := ctrl.webhookOptions("default", hook)
+	require.NoError(t, err)
+	require.True(t, opts.client == cached.client)
+
+	secret = secret.DeepCopy()
+	secret.ResourceVersion = "2"
+	require.NoError(t, ctrl.flaggerInformers.SecretInformer.Informer().GetIndexer().Update(secret))
+	updated, err := ctrl.webhookOptions("default", hook)
+	require.NoError(t, err)
+	require.False(t, opts.client == updated.client)
+}
+
+func TestWebhookTLSConfig_KeyPair(t *testing.T) {
+	_, err := webhookTLSConfig(map[string][]byte{"tls.crt": []byte("cert")})
+	require.Error(t, err)
+
+	_, err = webhookTLSConfig(map[string][]byte{"ca.crt": []byte("invalid")})
+	require.Error(t, err)
+}
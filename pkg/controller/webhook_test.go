@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -45,6 +46,173 @@ func TestCallWebhook_StatusCode(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestCallWebhook_Retry(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	hook := flaggerv1.CanaryWebhook{
+		Name: "validation",
+		URL:  ts.URL,
+		Retry: &flaggerv1.CanaryWebhookRetry{
+			Attempts: 3,
+			Backoff:  "10ms",
+		},
+	}
+
+	err := CallWebhook("podinfo", v1.NamespaceDefault, flaggerv1.CanaryPhaseProgressing, hook)
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	hook.Retry.Attempts = 2
+	err = CallWebhook("podinfo", v1.NamespaceDefault, flaggerv1.CanaryPhaseProgressing, hook)
+	require.Error(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCallWebhook_RetryStatusCodes(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("internal error"))
+	}))
+	defer ts.Close()
+	hook := flaggerv1.CanaryWebhook{
+		Name: "validation",
+		URL:  ts.URL,
+		Retry: &flaggerv1.CanaryWebhookRetry{
+			Attempts: 3,
+			Backoff:  "10ms",
+		},
+	}
+
+	err := CallWebhook("podinfo", v1.NamespaceDefault, flaggerv1.CanaryPhaseProgressing, hook)
+	require.EqualError(t, err, "internal error")
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	hook.Retry.StatusCodes = []int{http.StatusInternalServerError}
+	err = CallWebhook("podinfo", v1.NamespaceDefault, flaggerv1.CanaryPhaseProgressing, hook)
+	require.Error(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCallWebhook_RetryTimeout(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	hook := flaggerv1.CanaryWebhook{
+		Name:    "load-test",
+		Type:    flaggerv1.RolloutHook,
+		URL:     ts.URL,
+		Timeout: "10ms",
+		Retry: &flaggerv1.CanaryWebhookRetry{
+			Attempts: 3,
+			Backoff:  "10ms",
+		},
+	}
+
+	// the timeouts of the rollout hooks are not retried
+	err := CallWebhook("podinfo", v1.NamespaceDefault, flaggerv1.CanaryPhaseProgressing, hook)
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+
+	// the gates are retried
+	calls = 0
+	hook.Type = flaggerv1.ConfirmPromotionHook
+	err = CallWebhook("podinfo", v1.NamespaceDefault, flaggerv1.CanaryPhaseProgressing, hook)
+	require.Error(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCallWebhook_RetryLimits(t *testing.T) {
+	calls := 0
+	status := http.StatusTooManyRequests
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+	retry := &flaggerv1.CanaryWebhookRetry{
+		Attempts:    10,
+		Backoff:     "1ms",
+		StatusCodes: []int{http.StatusTooManyRequests},
+	}
+
+	// only the 5xx status codes are retried
+	err := postWebhookWithRetry(ts.URL, nil, webhookOptions{}, "1s", retry, true, time.Minute, nil)
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+
+	// the attempts are capped
+	calls = 0
+	retry.StatusCodes = nil
+	status = http.StatusServiceUnavailable
+	err = postWebhookWithRetry(ts.URL, nil, webhookOptions{}, "1s", retry, true, time.Minute, nil)
+	require.EqualError(t, err, "5 attempts failed, last error: ")
+	assert.Equal(t, maxWebhookAttempts, calls)
+
+	// the attempts stop when the next one would exceed the analysis interval
+	calls = 0
+	retry.Backoff = "1s"
+	err = postWebhookWithRetry(ts.URL, nil, webhookOptions{}, "1s", retry, true, 1500*time.Millisecond, nil)
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+
+	// the retries are cancelled on shutdown
+	calls = 0
+	retry.Backoff = "1m"
+	stopCh := make(chan struct{})
+	close(stopCh)
+	err = postWebhookWithRetry(ts.URL, nil, webhookOptions{}, "1s", retry, true, time.Hour, stopCh)
+	require.EqualError(t, err, "retries cancelled after 1 attempts, last error: ")
+	assert.Equal(t, 1, calls)
+}
+
+func TestWebhookPayload(t *testing.T) {
+	canary := newDeploymentTestCanary()
+	canary.Status = flaggerv1.CanaryStatus{
+		CanaryWeight:    20,
+		Iterations:      2,
+		FailedChecks:    1,
+		LastAppliedSpec: "abc",
+	}
+
+	var payload flaggerv1.CanaryWebhookPayload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	ctrl := &Controller{}
+	hook := flaggerv1.CanaryWebhook{
+		Name: "load-test",
+		Type: flaggerv1.RolloutHook,
+		URL:  ts.URL,
+	}
+	err := ctrl.runWebhook(canary, flaggerv1.CanaryPhaseProgressing, hook)
+	require.NoError(t, err)
+
+	assert.Equal(t, flaggerv1.RolloutHook, payload.Type)
+	assert.Equal(t, 20, payload.Weight)
+	assert.Equal(t, 2, payload.Iterations)
+	assert.Equal(t, 1, payload.FailedChecks)
+	assert.Equal(t, "abc", payload.Revision)
+}
+
 func TestCallEventWebhook(t *testing.T) {
 	canaryName := "podinfo"
 	canaryNamespace := v1.NamespaceDefault
@@ -124,3 +292,72 @@ func TestCallEventWebhookStatusCode(t *testing.T) {
 	err := CallEventWebhook(canary, ts.URL, canaryMessage, canaryEventType)
 	assert.Error(t, err)
 }
+
+func TestCallCloudEventWebhook(t *testing.T) {
+	canary := &flaggerv1.Canary{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "podinfo",
+			Namespace: v1.NamespaceDefault,
+		},
+		Status: flaggerv1.CanaryStatus{
+			Phase:           flaggerv1.CanaryPhaseProgressing,
+			CanaryWeight:    20,
+			Iterations:      2,
+			FailedChecks:    1,
+			LastAppliedSpec: "abc",
+		},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/cloudevents+json", r.Header.Get("Content-Type"))
+
+		var event CloudEvent
+		err := json.NewDecoder(r.Body).Decode(&event)
+		require.NoError(t, err)
+		require.Equal(t, "1.0", event.SpecVersion)
+		require.NotEmpty(t, event.ID)
+		require.Equal(t, CloudEventHalted, event.Type)
+		require.Equal(t, "/apis/flagger.app/v1beta1/namespaces/default/canaries", event.Source)
+		require.Equal(t, "podinfo", event.Subject)
+		require.Equal(t, 20, event.Data.Weight)
+		require.Equal(t, 2, event.Data.Iterations)
+		require.Equal(t, 1, event.Data.FailedChecks)
+		require.Equal(t, "abc", event.Data.Revision)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	err := CallCloudEventWebhook(canary, ts.URL, CloudEventHalted, "Halt advancement", corev1.EventTypeWarning, false)
+	require.NoError(t, err)
+}
+
+func TestCallCloudEventWebhook_Binary(t *testing.T) {
+	canary := &flaggerv1.Canary{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "podinfo",
+			Namespace: v1.NamespaceDefault,
+		},
+		Status: flaggerv1.CanaryStatus{
+			Phase: flaggerv1.CanaryPhaseSucceeded,
+		},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "1.0", r.Header.Get("ce-specversion"))
+		require.Equal(t, CloudEventPromoted, r.Header.Get("ce-type"))
+		require.Equal(t, "podinfo", r.Header.Get("ce-subject"))
+		require.NotEmpty(t, r.Header.Get("ce-id"))
+
+		var data CanaryEventData
+		err := json.NewDecoder(r.Body).Decode(&data)
+		require.NoError(t, err)
+		require.Equal(t, flaggerv1.CanaryPhaseSucceeded, data.Phase)
+		require.Equal(t, "Promotion completed!", data.Message)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	err := CallCloudEventWebhook(canary, ts.URL, CloudEventPromoted, "Promotion completed!", corev1.EventTypeNormal, true)
+	require.NoError(t, err)
+}
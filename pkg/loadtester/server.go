@@ -15,19 +15,19 @@ import (
 )
 
 // ListenAndServe starts a web server and waits for SIGTERM
-func ListenAndServe(port string, timeout time.Duration, logger *zap.SugaredLogger, taskRunner *TaskRunner, gate *GateStorage, stopCh <-chan struct{}) {
+func ListenAndServe(port string, timeout time.Duration, logger *zap.SugaredLogger, taskRunner *TaskRunner, gate *GateStorage, signingKey []byte, stopCh <-chan struct{}) {
 	mux := http.DefaultServeMux
 	mux.Handle("/metrics", promhttp.Handler())
 	mux.HandleFunc("/healthz", HandleHealthz)
-	mux.HandleFunc("/gate/approve", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/gate/approve", VerifySignature(logger, signingKey, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
-	})
-	mux.HandleFunc("/gate/halt", func(w http.ResponseWriter, r *http.Request) {
+	}))
+	mux.HandleFunc("/gate/halt", VerifySignature(logger, signingKey, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusForbidden)
 		w.Write([]byte("Forbidden"))
-	})
-	mux.HandleFunc("/gate/check", func(w http.ResponseWriter, r *http.Request) {
+	}))
+	mux.HandleFunc("/gate/check", VerifySignature(logger, signingKey, func(w http.ResponseWriter, r *http.Request) {
 		body, err := ioutil.ReadAll(r.Body)
 		if err != nil {
 			logger.Error("reading the request body failed", zap.Error(err))
@@ -55,9 +55,9 @@ func ListenAndServe(port string, timeout time.Duration, logger *zap.SugaredLogge
 		}
 
 		logger.Infof("%s gate check: approved %v", canaryName, approved)
-	})
+	}))
 
-	mux.HandleFunc("/gate/open", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/gate/open", VerifySignature(logger, signingKey, func(w http.ResponseWriter, r *http.Request) {
 		body, err := ioutil.ReadAll(r.Body)
 		if err != nil {
 			logger.Error("reading the request body failed", zap.Error(err))
@@ -80,9 +80,9 @@ func ListenAndServe(port string, timeout time.Duration, logger *zap.SugaredLogge
 		w.WriteHeader(http.StatusAccepted)
 
 		logger.Infof("%s gate opened", canaryName)
-	})
+	}))
 
-	mux.HandleFunc("/gate/close", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/gate/close", VerifySignature(logger, signingKey, func(w http.ResponseWriter, r *http.Request) {
 		body, err := ioutil.ReadAll(r.Body)
 		if err != nil {
 			logger.Error("reading the request body failed", zap.Error(err))
@@ -105,9 +105,9 @@ func ListenAndServe(port string, timeout time.Duration, logger *zap.SugaredLogge
 		w.WriteHeader(http.StatusAccepted)
 
 		logger.Infof("%s gate closed", canaryName)
-	})
+	}))
 
-	mux.HandleFunc("/rollback/check", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/rollback/check", VerifySignature(logger, signingKey, func(w http.ResponseWriter, r *http.Request) {
 		body, err := ioutil.ReadAll(r.Body)
 		if err != nil {
 			logger.Error("reading the request body failed", zap.Error(err))
@@ -135,8 +135,8 @@ func ListenAndServe(port string, timeout time.Duration, logger *zap.SugaredLogge
 		}
 
 		logger.Infof("%s rollback check: approved %v", canaryName, approved)
-	})
-	mux.HandleFunc("/rollback/open", func(w http.ResponseWriter, r *http.Request) {
+	}))
+	mux.HandleFunc("/rollback/open", VerifySignature(logger, signingKey, func(w http.ResponseWriter, r *http.Request) {
 		body, err := ioutil.ReadAll(r.Body)
 		if err != nil {
 			logger.Error("reading the request body failed", zap.Error(err))
@@ -159,8 +159,8 @@ func ListenAndServe(port string, timeout time.Duration, logger *zap.SugaredLogge
 		w.WriteHeader(http.StatusAccepted)
 
 		logger.Infof("%s rollback opened", canaryName)
-	})
-	mux.HandleFunc("/rollback/close", func(w http.ResponseWriter, r *http.Request) {
+	}))
+	mux.HandleFunc("/rollback/close", VerifySignature(logger, signingKey, func(w http.ResponseWriter, r *http.Request) {
 		body, err := ioutil.ReadAll(r.Body)
 		if err != nil {
 			logger.Error("reading the request body failed", zap.Error(err))
@@ -183,9 +183,9 @@ func ListenAndServe(port string, timeout time.Duration, logger *zap.SugaredLogge
 		w.WriteHeader(http.StatusAccepted)
 
 		logger.Infof("%s rollback closed", canaryName)
-	})
+	}))
 
-	mux.HandleFunc("/", HandleNewTask(logger, taskRunner))
+	mux.HandleFunc("/", VerifySignature(logger, signingKey, HandleNewTask(logger, taskRunner)))
 	srv := &http.Server{
 		Addr:         ":" + port,
 		Handler:      mux,
@@ -0,0 +1,39 @@
+package loadtester
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/weaveworks/flagger/pkg/signature"
+)
+
+// VerifySignature rejects the requests that are not signed with the webhook signing key,
+// the verification is disabled when the key is empty
+func VerifySignature(logger *zap.SugaredLogger, key []byte, next http.HandlerFunc) http.HandlerFunc {
+	if len(key) == 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			logger.Error("reading the request body failed", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.Body.Close()
+
+		if err := signature.Verify(key, r.Header, body, signature.DefaultTolerance, time.Now()); err != nil {
+			logger.Errorf("%s signature verification failed: %v", r.URL.Path, err)
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Unauthorized"))
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		next(w, r)
+	}
+}
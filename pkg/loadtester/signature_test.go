@@ -0,0 +1,58 @@
+package loadtester
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weaveworks/flagger/pkg/signature"
+)
+
+func TestVerifySignature(t *testing.T) {
+	mocks := newServerFixture()
+	key := []byte("hmac-key")
+	body := []byte(`{"name":"podinfo","namespace":"test"}`)
+
+	var received []byte
+	handler := VerifySignature(mocks.logger, key, func(w http.ResponseWriter, r *http.Request) {
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(r.Body)
+		received = buf.Bytes()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("POST", "/gate/check", bytes.NewReader(body))
+	resp := httptest.NewRecorder()
+	handler(resp, req)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Nil(t, received)
+
+	req, _ = http.NewRequest("POST", "/gate/check", bytes.NewReader(body))
+	signature.SignRequest(req, key, body, time.Now())
+	resp = httptest.NewRecorder()
+	handler(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, body, received)
+
+	req, _ = http.NewRequest("POST", "/gate/check", bytes.NewReader(body))
+	signature.SignRequest(req, []byte("other-key"), body, time.Now())
+	resp = httptest.NewRecorder()
+	handler(resp, req)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestVerifySignature_Disabled(t *testing.T) {
+	mocks := newServerFixture()
+	handler := VerifySignature(mocks.logger, nil, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("POST", "/", bytes.NewReader([]byte("{}")))
+	resp := httptest.NewRecorder()
+	handler(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
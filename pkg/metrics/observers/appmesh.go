@@ -41,6 +41,40 @@ var appMeshQueries = map[string]string{
 			)
 		) by (le)
 	)`,
+	"grpc-success-rate": `
+	(
+		sum(
+			rate(
+				envoy_cluster_grpc_total{
+					kubernetes_namespace="{{ namespace }}",
+					kubernetes_pod_name=~"{{ target }}-[0-9a-zA-Z]+(-[0-9a-zA-Z]+)"
+				}[{{ interval }}]
+			)
+		) 
+		- 
+		(
+			sum(
+				rate(
+					{
+						__name__=~"envoy_cluster_grpc_({{ variable "grpcErrorCodes" }})",
+						kubernetes_namespace="{{ namespace }}",
+						kubernetes_pod_name=~"{{ target }}-[0-9a-zA-Z]+(-[0-9a-zA-Z]+)"
+					}[{{ interval }}]
+				)
+			) 
+			or vector(0)
+		)
+	) 
+	/ 
+	sum(
+		rate(
+			envoy_cluster_grpc_total{
+				kubernetes_namespace="{{ namespace }}",
+				kubernetes_pod_name=~"{{ target }}-[0-9a-zA-Z]+(-[0-9a-zA-Z]+)"
+			}[{{ interval }}]
+		)
+	) 
+	* 100`,
 }
 
 type AppMeshObserver struct {
@@ -75,3 +109,28 @@ func (ob *AppMeshObserver) GetRequestDuration(model flaggerv1.MetricTemplateMode
 	ms := time.Duration(int64(value)) * time.Millisecond
 	return ms, nil
 }
+
+func (ob *AppMeshObserver) GetGrpcSuccessRate(model flaggerv1.MetricTemplateModel, errorCodes []string) (float64, error) {
+	model, err := grpcModel(model, errorCodes)
+	if err != nil {
+		return 0, err
+	}
+
+	query, err := RenderQuery(appMeshQueries["grpc-success-rate"], model)
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	return value, nil
+}
+
+// GetGrpcDuration is not supported, the Envoy upstream request time histogram
+// doesn't distinguish the gRPC calls from the other HTTP requests
+func (ob *AppMeshObserver) GetGrpcDuration(model flaggerv1.MetricTemplateModel) (time.Duration, error) {
+	return 0, ErrGrpcNotSupported
+}
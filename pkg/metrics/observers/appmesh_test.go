@@ -1,6 +1,7 @@
 package observers
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -81,3 +82,50 @@ func TestAppMeshObserver_GetRequestDuration(t *testing.T) {
 
 	assert.Equal(t, 100*time.Millisecond, val)
 }
+
+func TestAppMeshObserver_GetGrpcSuccessRate(t *testing.T) {
+	expected := ` ( sum( rate( envoy_cluster_grpc_total{ kubernetes_namespace="default", kubernetes_pod_name=~"podinfo-[0-9a-zA-Z]+(-[0-9a-zA-Z]+)" }[1m] ) ) - ( sum( rate( { __name__=~"envoy_cluster_grpc_(14|13)", kubernetes_namespace="default", kubernetes_pod_name=~"podinfo-[0-9a-zA-Z]+(-[0-9a-zA-Z]+)" }[1m] ) ) or vector(0) ) ) / sum( rate( envoy_cluster_grpc_total{ kubernetes_namespace="default", kubernetes_pod_name=~"podinfo-[0-9a-zA-Z]+(-[0-9a-zA-Z]+)" }[1m] ) ) * 100`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		promql := r.URL.Query()["query"][0]
+		assert.Equal(t, expected, promql)
+
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"100"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := providers.NewPrometheusProvider(flaggerv1.MetricTemplateProvider{
+		Type:      "prometheus",
+		Address:   ts.URL,
+		SecretRef: nil,
+	}, nil)
+	require.NoError(t, err)
+
+	observer := &AppMeshObserver{
+		client: client,
+	}
+
+	val, err := observer.GetGrpcSuccessRate(flaggerv1.MetricTemplateModel{
+		Name:      "podinfo",
+		Namespace: "default",
+		Target:    "podinfo",
+		Service:   "podinfo",
+		Interval:  "1m",
+	}, []string{"Unavailable", "13"})
+	require.NoError(t, err)
+	assert.Equal(t, float64(100), val)
+}
+
+func TestAppMeshObserver_GetGrpcDuration(t *testing.T) {
+	observer := &AppMeshObserver{}
+
+	_, err := observer.GetGrpcDuration(flaggerv1.MetricTemplateModel{
+		Name:      "podinfo",
+		Namespace: "default",
+		Target:    "podinfo",
+		Service:   "podinfo",
+		Interval:  "1m",
+	})
+	require.True(t, errors.Is(err, ErrGrpcNotSupported))
+}
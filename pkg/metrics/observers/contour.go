@@ -43,6 +43,7 @@ var contourQueries = map[string]string{
 }
 
 type ContourObserver struct {
+	noGrpcObserver
 	client providers.Interface
 }
 
@@ -44,6 +44,7 @@ var crossoverQueries = map[string]string{
 }
 
 type CrossoverObserver struct {
+	noGrpcObserver
 	client providers.Interface
 }
 
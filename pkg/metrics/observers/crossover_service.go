@@ -44,6 +44,7 @@ var crossoverServiceQueries = map[string]string{
 }
 
 type CrossoverServiceObserver struct {
+	noGrpcObserver
 	client providers.Interface
 }
 
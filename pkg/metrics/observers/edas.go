@@ -0,0 +1,166 @@
+package observers
+
+import (
+	"fmt"
+	"time"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/metrics/providers"
+)
+
+var edasQueries = map[string]string{
+	"request-success-rate": `
+	(
+		sum(
+			rate(
+				{{ variable "requestsMetric" }}{
+					{{ variable "versionLabel" }}="{{ variable "providerVersion" }}"
+				}[{{ interval }}]
+			)
+		) 
+		- 
+		(
+			sum(
+				rate(
+					{{ variable "failedRequestsMetric" }}{
+						{{ variable "versionLabel" }}="{{ variable "providerVersion" }}"
+					}[{{ interval }}]
+				)
+			) 
+			or vector(0)
+		)
+	) 
+	/ 
+	sum(
+		rate(
+			{{ variable "requestsMetric" }}{
+				{{ variable "versionLabel" }}="{{ variable "providerVersion" }}"
+			}[{{ interval }}]
+		)
+	) 
+	* 100`,
+	"request-duration": `
+	histogram_quantile(
+		0.99,
+		sum(
+			rate(
+				{{ variable "durationMetric" }}{
+					{{ variable "versionLabel" }}="{{ variable "providerVersion" }}"
+				}[{{ interval }}]
+			)
+		) by (le)
+	)`,
+}
+
+// edasMetrics are the default client-side metric names for each RPC framework
+var edasMetrics = map[string]map[string]string{
+	"dubbo": {
+		"requestsMetric":       "dubbo_consumer_requests_total",
+		"failedRequestsMetric": "dubbo_consumer_requests_failed_total",
+		"durationMetric":       "dubbo_consumer_rt_seconds_bucket",
+	},
+	"springcloud": {
+		"requestsMetric":       "spring_cloud_consumer_requests_total",
+		"failedRequestsMetric": "spring_cloud_consumer_requests_failed_total",
+		"durationMetric":       "spring_cloud_consumer_rt_seconds_bucket",
+	},
+}
+
+// EdasObserver computes the RPC success rate and latency of Dubbo and Spring Cloud
+// providers from the consumers metrics labelled by provider version
+type EdasObserver struct {
+	noGrpcObserver
+	client providers.Interface
+}
+
+func (ob *EdasObserver) GetRequestSuccessRate(model flaggerv1.MetricTemplateModel) (float64, error) {
+	model, err := edasModel(model)
+	if err != nil {
+		return 0, err
+	}
+
+	query, err := RenderQuery(edasQueries["request-success-rate"], model)
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	return value, nil
+}
+
+func (ob *EdasObserver) GetRequestDuration(model flaggerv1.MetricTemplateModel) (time.Duration, error) {
+	model, err := edasModel(model)
+	if err != nil {
+		return 0, err
+	}
+
+	query, err := RenderQuery(edasQueries["request-duration"], model)
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	ms := time.Duration(int64(value*1000)) * time.Millisecond
+	return ms, nil
+}
+
+// edasModel fills in the defaults of the metric names and labels not set in the template variables,
+// the provider version defaults to the image tag of the canary container
+func edasModel(model flaggerv1.MetricTemplateModel) (flaggerv1.MetricTemplateModel, error) {
+	framework := model.Variables["framework"]
+	if framework == "" {
+		framework = "dubbo"
+	}
+	defaults, ok := edasMetrics[framework]
+	if !ok {
+		return model, fmt.Errorf("framework %s is not supported, can be dubbo or springcloud", framework)
+	}
+
+	variables := map[string]string{
+		"versionLabel": "provider_version",
+	}
+	for k, v := range defaults {
+		variables[k] = v
+	}
+	for k, v := range model.Variables {
+		if v != "" {
+			variables[k] = v
+		}
+	}
+	if variables["providerVersion"] == "" {
+		version, err := edasProviderVersion(model, variables["container"])
+		if err != nil {
+			return model, err
+		}
+		variables["providerVersion"] = version
+	}
+	model.Variables = variables
+	return model, nil
+}
+
+// edasProviderVersion returns the image tag of the canary container, the container is the one named
+// in the template variables, the one named after the target or the only container of the canary
+func edasProviderVersion(model flaggerv1.MetricTemplateModel, container string) (string, error) {
+	if container == "" {
+		container = model.Target
+		if _, ok := model.Images[container]; !ok && len(model.Images) == 1 {
+			for name := range model.Images {
+				container = name
+			}
+		}
+	}
+	if _, ok := model.Images[container]; !ok {
+		return "", fmt.Errorf("the provider version can't be derived from the canary images, " +
+			"set the providerVersion or the container template variable")
+	}
+	imageTag := model.TemplateFunctions()["imageTag"].(func(string) string)
+	return imageTag(container), nil
+}
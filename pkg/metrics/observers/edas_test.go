@@ -0,0 +1,129 @@
+package observers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/metrics/providers"
+)
+
+func TestEdasObserver_GetRequestSuccessRate(t *testing.T) {
+	expected := ` ( sum( rate( dubbo_consumer_requests_total{ provider_version="podinfo" }[1m] ) ) - ( sum( rate( dubbo_consumer_requests_failed_total{ provider_version="podinfo" }[1m] ) ) or vector(0) ) ) / sum( rate( dubbo_consumer_requests_total{ provider_version="podinfo" }[1m] ) ) * 100`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		promql := r.URL.Query()["query"][0]
+		assert.Equal(t, expected, promql)
+
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"100"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := providers.NewPrometheusProvider(flaggerv1.MetricTemplateProvider{
+		Type:      "prometheus",
+		Address:   ts.URL,
+		SecretRef: nil,
+	}, nil)
+	require.NoError(t, err)
+
+	observer := &EdasObserver{
+		client: client,
+	}
+
+	model := flaggerv1.MetricTemplateModel{
+		Name:      "podinfo",
+		Namespace: "default",
+		Target:    "podinfo",
+		Service:   "podinfo",
+		Interval:  "1m",
+	}
+
+	// the provider version can't be derived without the canary images
+	_, err = observer.GetRequestSuccessRate(model)
+	require.Error(t, err)
+
+	model.Variables = map[string]string{"providerVersion": "podinfo"}
+	val, err := observer.GetRequestSuccessRate(model)
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(100), val)
+}
+
+func TestEdasObserver_GetRequestDuration(t *testing.T) {
+	expected := ` histogram_quantile( 0.99, sum( rate( spring_cloud_consumer_rt_seconds_bucket{ version="1.1.0" }[1m] ) ) by (le) )`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		promql := r.URL.Query()["query"][0]
+		assert.Equal(t, expected, promql)
+
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"0.1"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := providers.NewPrometheusProvider(flaggerv1.MetricTemplateProvider{
+		Type:      "prometheus",
+		Address:   ts.URL,
+		SecretRef: nil,
+	}, nil)
+	require.NoError(t, err)
+
+	observer := &EdasObserver{
+		client: client,
+	}
+
+	val, err := observer.GetRequestDuration(flaggerv1.MetricTemplateModel{
+		Name:      "podinfo",
+		Namespace: "default",
+		Target:    "podinfo",
+		Service:   "podinfo",
+		Interval:  "1m",
+		Variables: map[string]string{
+			"framework":       "springcloud",
+			"versionLabel":    "version",
+			"providerVersion": "1.1.0",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 100*time.Millisecond, val)
+}
+
+func TestEdasModel_ProviderVersion(t *testing.T) {
+	model := flaggerv1.MetricTemplateModel{
+		Target: "podinfo",
+		Images: map[string]string{
+			"podinfo": "ghcr.io/stefanprodan/podinfo:1.1.0",
+			"sidecar": "envoyproxy/envoy:v1.15.0",
+		},
+	}
+
+	// defaults to the container named after the target
+	m, err := edasModel(model)
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", m.Variables["providerVersion"])
+
+	model.Variables = map[string]string{"container": "sidecar"}
+	m, err = edasModel(model)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.15.0", m.Variables["providerVersion"])
+
+	model.Variables = map[string]string{"providerVersion": "2.0.0"}
+	m, err = edasModel(model)
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", m.Variables["providerVersion"])
+
+	// the only container of the canary
+	model.Target = "app"
+	model.Images = map[string]string{"server": "app:1.2.0"}
+	model.Variables = nil
+	m, err = edasModel(model)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0", m.Variables["providerVersion"])
+}
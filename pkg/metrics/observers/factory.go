@@ -1,6 +1,7 @@
 package observers
 
 import (
+	"fmt"
 	"strings"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
@@ -26,51 +27,85 @@ func NewFactory(metricsServer string) (*Factory, error) {
 	}, nil
 }
 
-func (factory Factory) Observer(provider string) Interface {
+func (factory Factory) Observer(provider string) (Interface, error) {
 	switch {
 	case provider == "none":
 		return &HttpObserver{
 			client: factory.Client,
-		}
-	case provider == "kubernetes":
+		}, nil
+	case provider == "kubernetes" || provider == "kubernetes-replicas":
 		return &HttpObserver{
 			client: factory.Client,
-		}
+		}, nil
+	case provider == "istio" || provider == "":
+		return &IstioObserver{
+			client: factory.Client,
+		}, nil
 	case provider == "appmesh":
 		return &AppMeshObserver{
 			client: factory.Client,
-		}
+		}, nil
 	case provider == "crossover":
 		return &CrossoverObserver{
 			client: factory.Client,
-		}
+		}, nil
 	case provider == "nginx":
 		return &NginxObserver{
 			client: factory.Client,
-		}
+		}, nil
 	case strings.HasPrefix(provider, "gloo"):
 		return &GlooObserver{
 			client: factory.Client,
-		}
-	case provider == "smi:linkerd":
-		return &LinkerdObserver{
-			client: factory.Client,
-		}
+		}, nil
 	case provider == "crossover:service":
 		return &CrossoverServiceObserver{
 			client: factory.Client,
-		}
+		}, nil
 	case provider == "linkerd":
 		return &LinkerdObserver{
 			client: factory.Client,
-		}
+		}, nil
 	case provider == "contour":
 		return &ContourObserver{
 			client: factory.Client,
-		}
-	default:
-		return &IstioObserver{
+		}, nil
+	case provider == "gatewayapi":
+		return &GatewayAPIObserver{
+			client: factory.Client,
+		}, nil
+	case provider == "traefik":
+		return &TraefikObserver{
 			client: factory.Client,
-		}
+		}, nil
+	case provider == "consul":
+		return &ConsulObserver{
+			client: factory.Client,
+		}, nil
+	case provider == "knative":
+		return &KnativeObserver{
+			client: factory.Client,
+		}, nil
+	case provider == "kuma":
+		return &KumaObserver{
+			client: factory.Client,
+		}, nil
+	case provider == "skipper":
+		return &SkipperObserver{
+			client: factory.Client,
+		}, nil
+	case provider == "cse" || provider == "edas":
+		return &EdasObserver{
+			client: factory.Client,
+		}, nil
+	case strings.HasPrefix(provider, "smi:"):
+		return factory.Observer(strings.TrimPrefix(provider, "smi:"))
+	case strings.HasPrefix(provider, "supergloo:"):
+		// supergloo:<mesh>.<namespace>
+		mesh := strings.Split(strings.TrimPrefix(provider, "supergloo:"), ".")[0]
+		return factory.Observer(mesh)
+	case strings.HasSuffix(provider, ":service"):
+		return factory.Observer(strings.TrimSuffix(provider, ":service"))
+	default:
+		return nil, fmt.Errorf("no builtin metrics are available for provider %s", provider)
 	}
 }
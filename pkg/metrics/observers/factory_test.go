@@ -0,0 +1,38 @@
+package observers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactory_Observer(t *testing.T) {
+	factory, err := NewFactory("fake")
+	require.NoError(t, err)
+
+	tests := map[string]Interface{
+		"istio":                              &IstioObserver{},
+		"istio:service":                      &IstioObserver{},
+		"smi:linkerd":                        &LinkerdObserver{},
+		"supergloo:appmesh.supergloo-system": &AppMeshObserver{},
+		"kubernetes":                         &HttpObserver{},
+		"crossover:service":                  &CrossoverServiceObserver{},
+		"traefik":                            &TraefikObserver{},
+		"kuma":                               &KumaObserver{},
+		"consul":                             &ConsulObserver{},
+		"knative":                            &KnativeObserver{},
+		"smi:cse":                            &EdasObserver{},
+		"edas":                               &EdasObserver{},
+		"skipper":                            &SkipperObserver{},
+	}
+
+	for provider, expected := range tests {
+		observer, err := factory.Observer(provider)
+		require.NoError(t, err, provider)
+		assert.IsType(t, expected, observer, provider)
+	}
+
+	_, err = factory.Observer("unknown")
+	require.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package observers
+
+import (
+	"fmt"
+	"time"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/metrics/providers"
+)
+
+// The Gateway API implementations based on Envoy name the upstream clusters after the backend service,
+// e.g. envoy_cluster_name="test_podinfo-canary_9898" for Contour or
+// envoy_cluster_name="outbound|9898||podinfo-canary.test.svc.cluster.local" for Istio
+
+var gatewayAPIQueries = map[string]string{
+	"request-success-rate": `
+	sum(
+		rate(
+			envoy_cluster_upstream_rq{
+				envoy_cluster_name=~".*{{ namespace }}.{{ target }}-canary.*|.*{{ target }}-canary.{{ namespace }}.*",
+				envoy_response_code!~"5.*"
+			}[{{ interval }}]
+		)
+	)
+	/
+	sum(
+		rate(
+			envoy_cluster_upstream_rq{
+				envoy_cluster_name=~".*{{ namespace }}.{{ target }}-canary.*|.*{{ target }}-canary.{{ namespace }}.*",
+			}[{{ interval }}]
+		)
+	)
+	* 100`,
+	"request-duration": `
+	histogram_quantile(
+		0.99,
+		sum(
+			rate(
+				envoy_cluster_upstream_rq_time_bucket{
+					envoy_cluster_name=~".*{{ namespace }}.{{ target }}-canary.*|.*{{ target }}-canary.{{ namespace }}.*",
+				}[{{ interval }}]
+			)
+		) by (le)
+	)`,
+}
+
+type GatewayAPIObserver struct {
+	noGrpcObserver
+	client providers.Interface
+}
+
+func (ob *GatewayAPIObserver) GetRequestSuccessRate(model flaggerv1.MetricTemplateModel) (float64, error) {
+	query, err := RenderQuery(gatewayAPIQueries["request-success-rate"], model)
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	return value, nil
+}
+
+func (ob *GatewayAPIObserver) GetRequestDuration(model flaggerv1.MetricTemplateModel) (time.Duration, error) {
+	query, err := RenderQuery(gatewayAPIQueries["request-duration"], model)
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	ms := time.Duration(int64(value)) * time.Millisecond
+	return ms, nil
+}
@@ -43,6 +43,7 @@ var glooQueries = map[string]string{
 }
 
 type GlooObserver struct {
+	noGrpcObserver
 	client providers.Interface
 }
 
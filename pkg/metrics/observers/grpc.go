@@ -0,0 +1,85 @@
+package observers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+)
+
+// DefaultGrpcErrorCodes are the gRPC status codes that count as failures
+// when the canary metric doesn't specify any
+var DefaultGrpcErrorCodes = []string{"Unknown", "DeadlineExceeded", "Unimplemented", "Internal", "Unavailable", "DataLoss"}
+
+var grpcCodes = map[string]int{
+	"ok":                 0,
+	"canceled":           1,
+	"cancelled":          1,
+	"unknown":            2,
+	"invalidargument":    3,
+	"deadlineexceeded":   4,
+	"notfound":           5,
+	"alreadyexists":      6,
+	"permissiondenied":   7,
+	"resourceexhausted":  8,
+	"failedprecondition": 9,
+	"aborted":            10,
+	"outofrange":         11,
+	"unimplemented":      12,
+	"internal":           13,
+	"unavailable":        14,
+	"dataloss":           15,
+	"unauthenticated":    16,
+}
+
+// grpcCodesRegex converts a list of gRPC status code names or numbers
+// to a PromQL regex alternation of numeric codes e.g. 2|4|14
+func grpcCodesRegex(codes []string) (string, error) {
+	if len(codes) == 0 {
+		codes = DefaultGrpcErrorCodes
+	}
+
+	result := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if n, err := strconv.Atoi(code); err == nil && n >= 0 && n <= 16 {
+			result = append(result, strconv.Itoa(n))
+			continue
+		}
+		key := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(code))
+		n, ok := grpcCodes[key]
+		if !ok {
+			return "", fmt.Errorf("unknown gRPC status code %s", code)
+		}
+		result = append(result, strconv.Itoa(n))
+	}
+	return strings.Join(result, "|"), nil
+}
+
+// grpcModel makes the gRPC error codes available to the query as {{ variable "grpcErrorCodes" }}
+func grpcModel(model flaggerv1.MetricTemplateModel, codes []string) (flaggerv1.MetricTemplateModel, error) {
+	regex, err := grpcCodesRegex(codes)
+	if err != nil {
+		return model, err
+	}
+
+	variables := make(map[string]string, len(model.Variables)+1)
+	for k, v := range model.Variables {
+		variables[k] = v
+	}
+	variables["grpcErrorCodes"] = regex
+	model.Variables = variables
+	return model, nil
+}
+
+// noGrpcObserver is embedded by the observers that don't implement the gRPC builtin metrics
+type noGrpcObserver struct{}
+
+func (ob noGrpcObserver) GetGrpcSuccessRate(model flaggerv1.MetricTemplateModel, errorCodes []string) (float64, error) {
+	return 0, ErrGrpcNotSupported
+}
+
+func (ob noGrpcObserver) GetGrpcDuration(model flaggerv1.MetricTemplateModel) (time.Duration, error) {
+	return 0, ErrGrpcNotSupported
+}
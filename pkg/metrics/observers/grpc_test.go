@@ -0,0 +1,21 @@
+package observers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrpcCodesRegex(t *testing.T) {
+	regex, err := grpcCodesRegex(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2|4|12|13|14|15", regex)
+
+	regex, err = grpcCodesRegex([]string{"UNAVAILABLE", "deadline_exceeded", "8"})
+	require.NoError(t, err)
+	assert.Equal(t, "14|4|8", regex)
+
+	_, err = grpcCodesRegex([]string{"Teapot"})
+	require.Error(t, err)
+}
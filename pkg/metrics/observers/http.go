@@ -44,6 +44,7 @@ var httpQueries = map[string]string{
 }
 
 type HttpObserver struct {
+	noGrpcObserver
 	client providers.Interface
 }
 
@@ -31,6 +31,48 @@ var istioQueries = map[string]string{
 		)
 	) 
 	* 100`,
+	"tcp-connection-success-rate": `
+	sum(
+		rate(
+			istio_tcp_connections_closed_total{
+				reporter="destination",
+				destination_workload_namespace="{{ namespace }}",
+				destination_workload=~"{{ target }}",
+				response_flags="-"
+			}[{{ interval }}]
+		)
+	) 
+	/ 
+	sum(
+		rate(
+			istio_tcp_connections_closed_total{
+				reporter="destination",
+				destination_workload_namespace="{{ namespace }}",
+				destination_workload=~"{{ target }}"
+			}[{{ interval }}]
+		)
+	) 
+	* 100`,
+	"tcp-sent-bytes": `
+	sum(
+		rate(
+			istio_tcp_sent_bytes_total{
+				reporter="destination",
+				destination_workload_namespace="{{ namespace }}",
+				destination_workload=~"{{ target }}"
+			}[{{ interval }}]
+		)
+	)`,
+	"tcp-received-bytes": `
+	sum(
+		rate(
+			istio_tcp_received_bytes_total{
+				reporter="destination",
+				destination_workload_namespace="{{ namespace }}",
+				destination_workload=~"{{ target }}"
+			}[{{ interval }}]
+		)
+	)`,
 	"request-duration": `
 	histogram_quantile(
 		0.99,
@@ -44,14 +86,59 @@ var istioQueries = map[string]string{
 			)
 		) by (le)
 	)`,
+	"grpc-success-rate": `
+	sum(
+		rate(
+			istio_requests_total{
+				reporter="destination",
+				destination_workload_namespace="{{ namespace }}",
+				destination_workload=~"{{ target }}",
+				request_protocol="grpc",
+				grpc_response_status!~"{{ variable "grpcErrorCodes" }}"
+			}[{{ interval }}]
+		)
+	) 
+	/ 
+	sum(
+		rate(
+			istio_requests_total{
+				reporter="destination",
+				destination_workload_namespace="{{ namespace }}",
+				destination_workload=~"{{ target }}",
+				request_protocol="grpc"
+			}[{{ interval }}]
+		)
+	) 
+	* 100`,
+	"grpc-duration": `
+	histogram_quantile(
+		0.99,
+		sum(
+			rate(
+				istio_request_duration_seconds_bucket{
+					reporter="destination",
+					destination_workload_namespace="{{ namespace }}",
+					destination_workload=~"{{ target }}",
+					request_protocol="grpc"
+				}[{{ interval }}]
+			)
+		) by (le)
+	)`,
 }
 
 type IstioObserver struct {
 	client providers.Interface
 }
 
+// GetRequestSuccessRate returns the percentage of the canary HTTP requests that didn't result in a 5xx error,
+// for TCP and TLS services it returns the percentage of the connections closed without Envoy response flags
 func (ob *IstioObserver) GetRequestSuccessRate(model flaggerv1.MetricTemplateModel) (float64, error) {
-	query, err := RenderQuery(istioQueries["request-success-rate"], model)
+	queryName := "request-success-rate"
+	if isL4Protocol(model.Protocol) {
+		queryName = "tcp-connection-success-rate"
+	}
+
+	query, err := RenderQuery(istioQueries[queryName], model)
 	if err != nil {
 		return 0, fmt.Errorf("rendering query failed: %w", err)
 	}
@@ -65,6 +152,10 @@ func (ob *IstioObserver) GetRequestSuccessRate(model flaggerv1.MetricTemplateMod
 }
 
 func (ob *IstioObserver) GetRequestDuration(model flaggerv1.MetricTemplateModel) (time.Duration, error) {
+	if isL4Protocol(model.Protocol) {
+		return 0, ErrTCPDurationNotSupported
+	}
+
 	query, err := RenderQuery(istioQueries["request-duration"], model)
 	if err != nil {
 		return 0, fmt.Errorf("rendering query failed: %w", err)
@@ -78,3 +169,71 @@ func (ob *IstioObserver) GetRequestDuration(model flaggerv1.MetricTemplateModel)
 	ms := time.Duration(int64(value*1000)) * time.Millisecond
 	return ms, nil
 }
+
+func (ob *IstioObserver) GetGrpcSuccessRate(model flaggerv1.MetricTemplateModel, errorCodes []string) (float64, error) {
+	model, err := grpcModel(model, errorCodes)
+	if err != nil {
+		return 0, err
+	}
+
+	query, err := RenderQuery(istioQueries["grpc-success-rate"], model)
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	return value, nil
+}
+
+func (ob *IstioObserver) GetGrpcDuration(model flaggerv1.MetricTemplateModel) (time.Duration, error) {
+	query, err := RenderQuery(istioQueries["grpc-duration"], model)
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	ms := time.Duration(int64(value*1000)) * time.Millisecond
+	return ms, nil
+}
+
+// GetTCPConnectionSuccessRate returns the percentage of the canary connections closed without Envoy response flags
+func (ob *IstioObserver) GetTCPConnectionSuccessRate(model flaggerv1.MetricTemplateModel) (float64, error) {
+	return ob.runQuery("tcp-connection-success-rate", model)
+}
+
+// GetTCPSentBytes returns the bytes per second sent by the canary
+func (ob *IstioObserver) GetTCPSentBytes(model flaggerv1.MetricTemplateModel) (float64, error) {
+	return ob.runQuery("tcp-sent-bytes", model)
+}
+
+// GetTCPReceivedBytes returns the bytes per second received by the canary
+func (ob *IstioObserver) GetTCPReceivedBytes(model flaggerv1.MetricTemplateModel) (float64, error) {
+	return ob.runQuery("tcp-received-bytes", model)
+}
+
+func (ob *IstioObserver) runQuery(name string, model flaggerv1.MetricTemplateModel) (float64, error) {
+	query, err := RenderQuery(istioQueries[name], model)
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	return value, nil
+}
+
+// isL4Protocol returns true for the TCP and TLS services that have no HTTP metrics
+func isL4Protocol(protocol string) bool {
+	return protocol == string(flaggerv1.AppProtocolTCP) || protocol == string(flaggerv1.AppProtocolTLS)
+}
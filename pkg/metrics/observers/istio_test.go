@@ -1,6 +1,7 @@
 package observers
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -82,3 +83,157 @@ func TestIstioObserver_GetRequestDuration(t *testing.T) {
 
 	assert.Equal(t, 100*time.Millisecond, val)
 }
+
+func TestIstioObserver_GetGrpcSuccessRate(t *testing.T) {
+	expected := ` sum( rate( istio_requests_total{ reporter="destination", destination_workload_namespace="default", destination_workload=~"podinfo", request_protocol="grpc", grpc_response_status!~"14|13" }[1m] ) ) / sum( rate( istio_requests_total{ reporter="destination", destination_workload_namespace="default", destination_workload=~"podinfo", request_protocol="grpc" }[1m] ) ) * 100`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		promql := r.URL.Query()["query"][0]
+		assert.Equal(t, expected, promql)
+
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"100"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := providers.NewPrometheusProvider(flaggerv1.MetricTemplateProvider{
+		Type:      "prometheus",
+		Address:   ts.URL,
+		SecretRef: nil,
+	}, nil)
+	require.NoError(t, err)
+
+	observer := &IstioObserver{
+		client: client,
+	}
+
+	val, err := observer.GetGrpcSuccessRate(flaggerv1.MetricTemplateModel{
+		Name:      "podinfo",
+		Namespace: "default",
+		Target:    "podinfo",
+		Service:   "podinfo",
+		Interval:  "1m",
+	}, []string{"Unavailable", "13"})
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(100), val)
+}
+
+func TestIstioObserver_GetGrpcDuration(t *testing.T) {
+	expected := ` histogram_quantile( 0.99, sum( rate( istio_request_duration_seconds_bucket{ reporter="destination", destination_workload_namespace="default", destination_workload=~"podinfo", request_protocol="grpc" }[1m] ) ) by (le) )`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		promql := r.URL.Query()["query"][0]
+		assert.Equal(t, expected, promql)
+
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"0.100"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := providers.NewPrometheusProvider(flaggerv1.MetricTemplateProvider{
+		Type:      "prometheus",
+		Address:   ts.URL,
+		SecretRef: nil,
+	}, nil)
+	require.NoError(t, err)
+
+	observer := &IstioObserver{
+		client: client,
+	}
+
+	val, err := observer.GetGrpcDuration(flaggerv1.MetricTemplateModel{
+		Name:      "podinfo",
+		Namespace: "default",
+		Target:    "podinfo",
+		Service:   "podinfo",
+		Interval:  "1m",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 100*time.Millisecond, val)
+}
+
+func TestIstioObserver_GetTCPConnectionSuccessRate(t *testing.T) {
+	expected := ` sum( rate( istio_tcp_connections_closed_total{ reporter="destination", destination_workload_namespace="default", destination_workload=~"podinfo", response_flags="-" }[1m] ) ) / sum( rate( istio_tcp_connections_closed_total{ reporter="destination", destination_workload_namespace="default", destination_workload=~"podinfo" }[1m] ) ) * 100`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		promql := r.URL.Query()["query"][0]
+		assert.Equal(t, expected, promql)
+
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"99"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := providers.NewPrometheusProvider(flaggerv1.MetricTemplateProvider{
+		Type:      "prometheus",
+		Address:   ts.URL,
+		SecretRef: nil,
+	}, nil)
+	require.NoError(t, err)
+
+	observer := &IstioObserver{
+		client: client,
+	}
+
+	model := flaggerv1.MetricTemplateModel{
+		Name:      "podinfo",
+		Namespace: "default",
+		Target:    "podinfo",
+		Service:   "podinfo",
+		Interval:  "1m",
+		Protocol:  "tcp",
+	}
+
+	val, err := observer.GetRequestSuccessRate(model)
+	require.NoError(t, err)
+	assert.Equal(t, float64(99), val)
+
+	_, err = observer.GetRequestDuration(model)
+	assert.True(t, errors.Is(err, ErrTCPDurationNotSupported))
+}
+
+func TestIstioObserver_GetTCPBytes(t *testing.T) {
+	expected := map[string]bool{
+		` sum( rate( istio_tcp_sent_bytes_total{ reporter="destination", destination_workload_namespace="default", destination_workload=~"podinfo" }[1m] ) )`:     true,
+		` sum( rate( istio_tcp_received_bytes_total{ reporter="destination", destination_workload_namespace="default", destination_workload=~"podinfo" }[1m] ) )`: true,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		promql := r.URL.Query()["query"][0]
+		assert.True(t, expected[promql], promql)
+
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"2048"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := providers.NewPrometheusProvider(flaggerv1.MetricTemplateProvider{
+		Type:      "prometheus",
+		Address:   ts.URL,
+		SecretRef: nil,
+	}, nil)
+	require.NoError(t, err)
+
+	var observer TCPInterface = &IstioObserver{
+		client: client,
+	}
+
+	model := flaggerv1.MetricTemplateModel{
+		Name:      "podinfo",
+		Namespace: "default",
+		Target:    "podinfo",
+		Service:   "podinfo",
+		Interval:  "1m",
+		Protocol:  "tcp",
+	}
+
+	val, err := observer.GetTCPSentBytes(model)
+	require.NoError(t, err)
+	assert.Equal(t, float64(2048), val)
+
+	val, err = observer.GetTCPReceivedBytes(model)
+	require.NoError(t, err)
+	assert.Equal(t, float64(2048), val)
+}
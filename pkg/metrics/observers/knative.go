@@ -0,0 +1,94 @@
+package observers
+
+import (
+	"fmt"
+	"time"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/metrics/providers"
+)
+
+// the requests of the canary revision are reported by the Knative queue-proxy sidecar
+var knativeQueries = map[string]string{
+	"request-success-rate": `
+	sum(
+		rate(
+			revision_app_request_count{
+				namespace_name="{{ namespace }}",
+				configuration_name="{{ target }}",
+				revision_name="{{ variable "revision" }}",
+				response_code_class!="5xx"
+			}[{{ interval }}]
+		)
+	)
+	/
+	sum(
+		rate(
+			revision_app_request_count{
+				namespace_name="{{ namespace }}",
+				configuration_name="{{ target }}",
+				revision_name="{{ variable "revision" }}"
+			}[{{ interval }}]
+		)
+	)
+	* 100`,
+	"request-duration": `
+	histogram_quantile(
+		0.99,
+		sum(
+			rate(
+				revision_app_request_latencies_bucket{
+					namespace_name="{{ namespace }}",
+					configuration_name="{{ target }}",
+					revision_name="{{ variable "revision" }}"
+				}[{{ interval }}]
+			)
+		) by (le)
+	)`,
+}
+
+type KnativeObserver struct {
+	noGrpcObserver
+	client providers.Interface
+}
+
+func (ob *KnativeObserver) GetRequestSuccessRate(model flaggerv1.MetricTemplateModel) (float64, error) {
+	query, err := RenderQuery(knativeQueries["request-success-rate"], withKnativeRevision(model))
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	return value, nil
+}
+
+func (ob *KnativeObserver) GetRequestDuration(model flaggerv1.MetricTemplateModel) (time.Duration, error) {
+	query, err := RenderQuery(knativeQueries["request-duration"], withKnativeRevision(model))
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	ms := time.Duration(int64(value)) * time.Millisecond
+	return ms, nil
+}
+
+// withKnativeRevision exposes the canary revision to the queries as the revision variable,
+// the queue-proxy metrics are labeled with the revision name instead of the pod labels
+func withKnativeRevision(model flaggerv1.MetricTemplateModel) flaggerv1.MetricTemplateModel {
+	variables := make(map[string]string, len(model.Variables)+1)
+	for k, v := range model.Variables {
+		variables[k] = v
+	}
+	variables["revision"] = model.CanarySelector[flaggerv1.KnativeRevisionMetricLabel]
+	model.Variables = variables
+	return model
+}
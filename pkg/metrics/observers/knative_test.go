@@ -0,0 +1,95 @@
+package observers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/metrics/providers"
+)
+
+func TestKnativeObserver_GetRequestSuccessRate(t *testing.T) {
+	expected := ` sum( rate( revision_app_request_count{ namespace_name="default", configuration_name="podinfo", revision_name="podinfo-00002", response_code_class!="5xx" }[1m] ) ) / sum( rate( revision_app_request_count{ namespace_name="default", configuration_name="podinfo", revision_name="podinfo-00002" }[1m] ) ) * 100`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		promql := r.URL.Query()["query"][0]
+		assert.Equal(t, expected, promql)
+
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"100"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := providers.NewPrometheusProvider(flaggerv1.MetricTemplateProvider{
+		Type:      "prometheus",
+		Address:   ts.URL,
+		SecretRef: nil,
+	}, nil)
+	require.NoError(t, err)
+
+	observer := &KnativeObserver{
+		client: client,
+	}
+
+	val, err := observer.GetRequestSuccessRate(flaggerv1.MetricTemplateModel{
+		Name:           "podinfo",
+		Namespace:      "default",
+		Target:         "podinfo",
+		Service:        "podinfo",
+		Interval:       "1m",
+		CanarySelector: map[string]string{flaggerv1.KnativeRevisionMetricLabel: "podinfo-00002"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(100), val)
+}
+
+func TestKnativeObserver_GetRequestDuration(t *testing.T) {
+	expected := ` histogram_quantile( 0.99, sum( rate( revision_app_request_latencies_bucket{ namespace_name="default", configuration_name="podinfo", revision_name="podinfo-00002" }[1m] ) ) by (le) )`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		promql := r.URL.Query()["query"][0]
+		assert.Equal(t, expected, promql)
+
+		json := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"100"]}]}}`
+		w.Write([]byte(json))
+	}))
+	defer ts.Close()
+
+	client, err := providers.NewPrometheusProvider(flaggerv1.MetricTemplateProvider{
+		Type:      "prometheus",
+		Address:   ts.URL,
+		SecretRef: nil,
+	}, nil)
+	require.NoError(t, err)
+
+	observer := &KnativeObserver{
+		client: client,
+	}
+
+	val, err := observer.GetRequestDuration(flaggerv1.MetricTemplateModel{
+		Name:           "podinfo",
+		Namespace:      "default",
+		Target:         "podinfo",
+		Service:        "podinfo",
+		Interval:       "1m",
+		CanarySelector: map[string]string{flaggerv1.KnativeRevisionMetricLabel: "podinfo-00002"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 100*time.Millisecond, val)
+}
+
+func TestKnativeObserver_Selectors(t *testing.T) {
+	// custom metric templates select the queue-proxy series of the revisions with the selectors
+	query, err := RenderQuery(`revision_app_request_count{ {{ canarySelector }} }`, flaggerv1.MetricTemplateModel{
+		CanarySelector: map[string]string{flaggerv1.KnativeRevisionMetricLabel: "podinfo-00002"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `revision_app_request_count{ revision_name="podinfo-00002" }`, query)
+}
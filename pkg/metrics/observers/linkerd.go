@@ -44,6 +44,30 @@ var linkerdQueries = map[string]string{
 			)
 		) by (le)
 	)`,
+	"grpc-success-rate": `
+	sum(
+		rate(
+			response_total{
+				namespace="{{ namespace }}",
+				deployment=~"{{ target }}",
+				grpc_status=~".+",
+				grpc_status!~"{{ variable "grpcErrorCodes" }}",
+				direction="inbound"
+			}[{{ interval }}]
+		)
+	) 
+	/ 
+	sum(
+		rate(
+			response_total{
+				namespace="{{ namespace }}",
+				deployment=~"{{ target }}",
+				grpc_status=~".+",
+				direction="inbound"
+			}[{{ interval }}]
+		)
+	) 
+	* 100`,
 }
 
 type LinkerdObserver struct {
@@ -78,3 +102,28 @@ func (ob *LinkerdObserver) GetRequestDuration(model flaggerv1.MetricTemplateMode
 	ms := time.Duration(int64(value)) * time.Millisecond
 	return ms, nil
 }
+
+func (ob *LinkerdObserver) GetGrpcSuccessRate(model flaggerv1.MetricTemplateModel, errorCodes []string) (float64, error) {
+	model, err := grpcModel(model, errorCodes)
+	if err != nil {
+		return 0, err
+	}
+
+	query, err := RenderQuery(linkerdQueries["grpc-success-rate"], model)
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	return value, nil
+}
+
+// GetGrpcDuration is not supported, the Linkerd proxy latency histogram
+// has no gRPC status label to tell the gRPC calls apart from the other HTTP requests
+func (ob *LinkerdObserver) GetGrpcDuration(model flaggerv1.MetricTemplateModel) (time.Duration, error) {
+	return 0, ErrGrpcNotSupported
+}
@@ -51,6 +51,7 @@ var nginxQueries = map[string]string{
 }
 
 type NginxObserver struct {
+	noGrpcObserver
 	client providers.Interface
 }
 
@@ -1,12 +1,32 @@
 package observers
 
 import (
+	"errors"
 	"time"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
 )
 
+// ErrGrpcNotSupported is returned by the observers that have no gRPC metrics
+var ErrGrpcNotSupported = errors.New("gRPC metrics are not supported by this provider")
+
+// ErrTCPDurationNotSupported is returned by the observers when the request duration is queried for TCP services
+var ErrTCPDurationNotSupported = errors.New("request duration metrics are not available for TCP services")
+
+// ErrTCPNotSupported is returned for the TCP metrics of the providers that don't implement TCPInterface
+var ErrTCPNotSupported = errors.New("TCP metrics are not supported by this provider")
+
 type Interface interface {
 	GetRequestSuccessRate(model flaggerv1.MetricTemplateModel) (float64, error)
 	GetRequestDuration(model flaggerv1.MetricTemplateModel) (time.Duration, error)
+	GetGrpcSuccessRate(model flaggerv1.MetricTemplateModel, errorCodes []string) (float64, error)
+	GetGrpcDuration(model flaggerv1.MetricTemplateModel) (time.Duration, error)
+}
+
+// TCPInterface is implemented by the observers that report the TCP connections and the bytes
+// exchanged by the canary, the bytes are returned per second
+type TCPInterface interface {
+	GetTCPConnectionSuccessRate(model flaggerv1.MetricTemplateModel) (float64, error)
+	GetTCPSentBytes(model flaggerv1.MetricTemplateModel) (float64, error)
+	GetTCPReceivedBytes(model flaggerv1.MetricTemplateModel) (float64, error)
 }
@@ -4,13 +4,51 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"text/template"
+	"text/template/parse"
+	"time"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
 )
 
+// templateHelpers are the generic functions available in query templates
+var templateHelpers = template.FuncMap{
+	"quote":       strconv.Quote,
+	"regexEscape": regexp.QuoteMeta,
+	"join":        strings.Join,
+	"default": func(def string, val string) string {
+		if val == "" {
+			return def
+		}
+		return val
+	},
+	"durationAdd": func(a string, b string) (string, error) {
+		return durationOp(a, b, func(x, y time.Duration) time.Duration { return x + y })
+	},
+	"durationSub": func(a string, b string) (string, error) {
+		return durationOp(a, b, func(x, y time.Duration) time.Duration { return x - y })
+	},
+	"durationMul": func(a string, n int) (string, error) {
+		d, err := time.ParseDuration(a)
+		if err != nil {
+			return "", err
+		}
+		return formatDuration(d * time.Duration(n)), nil
+	},
+	"durationSeconds": func(a string) (int64, error) {
+		d, err := time.ParseDuration(a)
+		if err != nil {
+			return 0, err
+		}
+		return int64(d / time.Second), nil
+	},
+}
+
 func RenderQuery(queryTemplate string, model flaggerv1.MetricTemplateModel) (string, error) {
-	t, err := template.New("tmpl").Funcs(model.TemplateFunctions()).Parse(queryTemplate)
+	t, err := template.New("tmpl").Funcs(templateHelpers).Funcs(model.TemplateFunctions()).Parse(queryTemplate)
 	if err != nil {
 		return "", fmt.Errorf("template parsing failed: %w", err)
 	}
@@ -27,3 +65,96 @@ func RenderQuery(queryTemplate string, model flaggerv1.MetricTemplateModel) (str
 	}
 	return data.String(), nil
 }
+
+// QueryUsesFunctions returns true if the query template calls one of the given functions,
+// a template that fails to parse is reported as not using them and fails at render time
+func QueryUsesFunctions(queryTemplate string, names ...string) bool {
+	model := flaggerv1.MetricTemplateModel{}
+	t, err := template.New("tmpl").Funcs(templateHelpers).Funcs(model.TemplateFunctions()).Parse(queryTemplate)
+	if err != nil {
+		return false
+	}
+
+	functions := make(map[string]bool, len(names))
+	for _, name := range names {
+		functions[name] = true
+	}
+	return nodeUsesFunctions(t.Tree.Root, functions)
+}
+
+// nodeUsesFunctions walks the template nodes looking for the identifiers of the given functions
+func nodeUsesFunctions(node parse.Node, functions map[string]bool) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if nodeUsesFunctions(child, functions) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return nodeUsesFunctions(n.Pipe, functions)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if nodeUsesFunctions(cmd, functions) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if nodeUsesFunctions(arg, functions) {
+				return true
+			}
+		}
+	case *parse.IdentifierNode:
+		return functions[n.Ident]
+	case *parse.ChainNode:
+		return nodeUsesFunctions(n.Node, functions)
+	case *parse.IfNode:
+		return branchUsesFunctions(&n.BranchNode, functions)
+	case *parse.RangeNode:
+		return branchUsesFunctions(&n.BranchNode, functions)
+	case *parse.WithNode:
+		return branchUsesFunctions(&n.BranchNode, functions)
+	case *parse.TemplateNode:
+		return nodeUsesFunctions(n.Pipe, functions)
+	}
+	return false
+}
+
+func branchUsesFunctions(n *parse.BranchNode, functions map[string]bool) bool {
+	return nodeUsesFunctions(n.Pipe, functions) ||
+		nodeUsesFunctions(n.List, functions) ||
+		nodeUsesFunctions(n.ElseList, functions)
+}
+
+func durationOp(a string, b string, op func(x, y time.Duration) time.Duration) (string, error) {
+	x, err := time.ParseDuration(a)
+	if err != nil {
+		return "", err
+	}
+	y, err := time.ParseDuration(b)
+	if err != nil {
+		return "", err
+	}
+	return formatDuration(op(x, y)), nil
+}
+
+// formatDuration returns the duration in a format accepted by PromQL range selectors
+func formatDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	default:
+		return fmt.Sprintf("%dms", d/time.Millisecond)
+	}
+}
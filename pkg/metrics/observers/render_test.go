@@ -0,0 +1,85 @@
+package observers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+)
+
+func TestRenderQuery_Model(t *testing.T) {
+	model := flaggerv1.MetricTemplateModel{
+		Name:            "podinfo",
+		Namespace:       "default",
+		Target:          "podinfo",
+		Primary:         "podinfo-primary",
+		Interval:        "1m",
+		CanaryWeight:    20,
+		Iterations:      3,
+		CanarySelector:  map[string]string{"app": "podinfo", "tier": "web"},
+		PrimarySelector: map[string]string{"app": "podinfo-primary"},
+		Images:          map[string]string{"podinfo": "ghcr.io/stefanprodan/podinfo:4.0.6"},
+		Variables:       map[string]string{"route": "/api"},
+	}
+
+	query, err := RenderQuery(`{{ primary }} {{ canaryWeight }} {{ iterations }} {{ canarySelector }} {{ primarySelector }} {{ imageTag "podinfo" }} {{ variable "route" }}`, model)
+	require.NoError(t, err)
+	assert.Equal(t, `podinfo-primary 20 3 app="podinfo",tier="web" app="podinfo-primary" 4.0.6 /api`, query)
+}
+
+func TestRenderQuery_Selectors(t *testing.T) {
+	model := flaggerv1.MetricTemplateModel{
+		CanarySelector: map[string]string{"app.kubernetes.io/name": "podinfo", "2fa": "on"},
+	}
+
+	query, err := RenderQuery(`{{ canarySelector }}`, model)
+	require.NoError(t, err)
+	assert.Equal(t, `_2fa="on",app_kubernetes_io_name="podinfo"`, query)
+
+	// kube-state-metrics prefixes the pod labels with label_
+	query, err = RenderQuery(`{{ canarySelector "label_" }}`, model)
+	require.NoError(t, err)
+	assert.Equal(t, `label_2fa="on",label_app_kubernetes_io_name="podinfo"`, query)
+}
+
+func TestRenderQuery_Helpers(t *testing.T) {
+	model := flaggerv1.MetricTemplateModel{
+		Interval:  "1m",
+		Variables: map[string]string{"route": "/api/v1.0"},
+	}
+
+	tests := map[string]string{
+		`{{ variable "route" | regexEscape }}`:             `/api/v1\.0`,
+		`{{ variable "route" | quote }}`:                   `"/api/v1.0"`,
+		`{{ variable "missing" | default "/" }}`:           `/`,
+		`{{ durationAdd interval "30s" }}`:                 `90s`,
+		`{{ durationSub interval "30s" }}`:                 `30s`,
+		`{{ durationMul interval 5 }}`:                     `5m`,
+		`{{ durationSeconds interval }}`:                   `60`,
+		`{{ durationMul (durationAdd interval "1m") 30 }}`: `1h`,
+	}
+	for tmpl, expected := range tests {
+		query, err := RenderQuery(tmpl, model)
+		require.NoError(t, err)
+		assert.Equal(t, expected, query, tmpl)
+	}
+
+	_, err := RenderQuery(`{{ durationAdd interval "x" }}`, model)
+	require.Error(t, err)
+}
+
+func TestImageTag(t *testing.T) {
+	model := flaggerv1.MetricTemplateModel{
+		Images: map[string]string{
+			"a": "localhost:5000/podinfo",
+			"b": "podinfo@sha256:abc",
+			"c": "podinfo:1.0.0",
+		},
+	}
+
+	query, err := RenderQuery(`{{ imageTag "a" }} {{ imageTag "b" }} {{ imageTag "c" }}`, model)
+	require.NoError(t, err)
+	assert.Equal(t, `latest sha256:abc 1.0.0`, query)
+}
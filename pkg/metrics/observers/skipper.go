@@ -0,0 +1,89 @@
+package observers
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	"github.com/weaveworks/flagger/pkg/metrics/providers"
+)
+
+var skipperQueries = map[string]string{
+	"request-success-rate": `
+	sum(
+		rate(
+			skipper_serve_route_duration_seconds_bucket{
+				route=~"kube(ew)?_{{ namespace }}__{{ ingress }}__.*__{{ service }}_canary(_[0-9]+)?",
+				code!~"5..",
+				le="+Inf"
+			}[{{ interval }}]
+		)
+	) 
+	/ 
+	sum(
+		rate(
+			skipper_serve_route_duration_seconds_bucket{
+				route=~"kube(ew)?_{{ namespace }}__{{ ingress }}__.*__{{ service }}_canary(_[0-9]+)?",
+				le="+Inf"
+			}[{{ interval }}]
+		)
+	) 
+	* 100`,
+	"request-duration": `
+	histogram_quantile(
+		0.99,
+		sum(
+			rate(
+				skipper_serve_route_duration_seconds_bucket{
+					route=~"kube(ew)?_{{ namespace }}__{{ ingress }}__.*__{{ service }}_canary(_[0-9]+)?"
+				}[{{ interval }}]
+			)
+		) by (le)
+	)`,
+}
+
+// skipperRouteRegexp matches the characters Skipper replaces with underscores in route IDs
+var skipperRouteRegexp = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+type SkipperObserver struct {
+	noGrpcObserver
+	client providers.Interface
+}
+
+func (ob *SkipperObserver) GetRequestSuccessRate(model flaggerv1.MetricTemplateModel) (float64, error) {
+	query, err := RenderQuery(skipperQueries["request-success-rate"], skipperModel(model))
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	return value, nil
+}
+
+func (ob *SkipperObserver) GetRequestDuration(model flaggerv1.MetricTemplateModel) (time.Duration, error) {
+	query, err := RenderQuery(skipperQueries["request-duration"], skipperModel(model))
+	if err != nil {
+		return 0, fmt.Errorf("rendering query failed: %w", err)
+	}
+
+	value, err := ob.client.RunQuery(query)
+	if err != nil {
+		return 0, fmt.Errorf("running query failed: %w", err)
+	}
+
+	ms := time.Duration(int64(value*1000)) * time.Millisecond
+	return ms, nil
+}
+
+// skipperModel escapes the names used in the Skipper route IDs
+func skipperModel(model flaggerv1.MetricTemplateModel) flaggerv1.MetricTemplateModel {
+	model.Namespace = skipperRouteRegexp.ReplaceAllString(model.Namespace, "_")
+	model.Ingress = skipperRouteRegexp.ReplaceAllString(model.Ingress, "_")
+	model.Service = skipperRouteRegexp.ReplaceAllString(model.Service, "_")
+	return model
+}
@@ -15,6 +15,8 @@ type Recorder struct {
 	total    *prometheus.GaugeVec
 	status   *prometheus.GaugeVec
 	weight   *prometheus.GaugeVec
+	alerts   *prometheus.CounterVec
+	pending  *prometheus.GaugeVec
 }
 
 // NewRecorder creates a new recorder and registers the Prometheus metrics
@@ -51,12 +53,27 @@ func NewRecorder(controller string, register bool) Recorder {
 		Help:      "The virtual service destination weight current value",
 	}, []string{"workload", "namespace"})
 
+	// result can be sent, suppressed or digest
+	alerts := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: controller,
+		Name:      "canary_alerts_total",
+		Help:      "Total number of canary alerts by provider and throttle result",
+	}, []string{"name", "namespace", "provider", "result"})
+
+	pending := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: controller,
+		Name:      "canary_alerts_suppressed",
+		Help:      "Number of suppressed canary alerts waiting for the next digest",
+	}, []string{"name", "namespace", "provider"})
+
 	if register {
 		prometheus.MustRegister(info)
 		prometheus.MustRegister(duration)
 		prometheus.MustRegister(total)
 		prometheus.MustRegister(status)
 		prometheus.MustRegister(weight)
+		prometheus.MustRegister(alerts)
+		prometheus.MustRegister(pending)
 	}
 
 	return Recorder{
@@ -65,6 +82,8 @@ func NewRecorder(controller string, register bool) Recorder {
 		total:    total,
 		status:   status,
 		weight:   weight,
+		alerts:   alerts,
+		pending:  pending,
 	}
 }
 
@@ -102,3 +121,13 @@ func (cr *Recorder) SetWeight(cd *flaggerv1.Canary, primary int, canary int) {
 	cr.weight.WithLabelValues(fmt.Sprintf("%s-primary", cd.Spec.TargetRef.Name), cd.Namespace).Set(float64(primary))
 	cr.weight.WithLabelValues(cd.Spec.TargetRef.Name, cd.Namespace).Set(float64(canary))
 }
+
+// IncAlerts increments the number of alerts sent or suppressed for a provider
+func (cr *Recorder) IncAlerts(cd *flaggerv1.Canary, provider string, result string) {
+	cr.alerts.WithLabelValues(cd.Spec.TargetRef.Name, cd.Namespace, provider, result).Inc()
+}
+
+// SetAlertsSuppressed sets the number of suppressed alerts waiting for the next digest
+func (cr *Recorder) SetAlertsSuppressed(cd *flaggerv1.Canary, provider string, count int) {
+	cr.pending.WithLabelValues(cd.Spec.TargetRef.Name, cd.Namespace, provider).Set(float64(count))
+}
@@ -11,33 +11,60 @@ import (
 )
 
 func postMessage(address string, payload interface{}) error {
+	_, err := postMessageWithResponse(address, payload)
+	return err
+}
+
+// postMessageWithResponse sends the payload and returns the response body
+// for the providers that report errors with a 200 status code
+func postMessageWithResponse(address string, payload interface{}) ([]byte, error) {
+	return postJSON(address, nil, payload, http.StatusOK)
+}
+
+// postJSON sends the payload with the given headers and returns the response body,
+// the request fails if the status code is not one of the expected codes or not 2xx when none are given
+func postJSON(address string, headers map[string]string, payload interface{}, expectedStatusCodes ...int) ([]byte, error) {
 	data, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("marshalling notification payload failed: %w", err)
+		return nil, fmt.Errorf("marshalling notification payload failed: %w", err)
 	}
 
 	b := bytes.NewBuffer(data)
 
 	req, err := http.NewRequest("POST", address, b)
 	if err != nil {
-		return fmt.Errorf("http.NewRequest failed: %w", err)
+		return nil, fmt.Errorf("http.NewRequest failed: %w", err)
 	}
 	req.Header.Set("Content-type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
 
 	ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
 	defer cancel()
 
 	res, err := http.DefaultClient.Do(req.WithContext(ctx))
 	if err != nil {
-		return fmt.Errorf("sending notification failed: %w", err)
+		return nil, fmt.Errorf("sending notification failed: %w", err)
 	}
 
 	defer res.Body.Close()
-	statusCode := res.StatusCode
-	if statusCode != 200 {
-		body, _ := ioutil.ReadAll(res.Body)
-		return fmt.Errorf("sending notification failed: %s", string(body))
+	body, _ := ioutil.ReadAll(res.Body)
+	if !isExpectedStatus(res.StatusCode, expectedStatusCodes) {
+		return nil, fmt.Errorf("sending notification failed: %s", string(body))
 	}
 
-	return nil
+	return body, nil
+}
+
+func isExpectedStatus(statusCode int, expectedStatusCodes []int) bool {
+	if len(expectedStatusCodes) == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+	for _, code := range expectedStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
 }
@@ -14,7 +14,7 @@ type DingTalk struct {
 
 type DingTalkText struct {
 	Text    string `json:"text,omitempty"`
-	UserIds string `json:"userIds, omitempty"`
+	UserIds string `json:"userIds,omitempty"`
 }
 
 type DingTalkLinkText struct {
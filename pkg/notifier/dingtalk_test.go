@@ -1,16 +1,33 @@
 package notifier
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
-func Test_PostMessage(t *testing.T) {
-	payload := &DingTalkText{
-		Text:    "just test",
-		UserIds: "060004",
-	}
-	err := postMessage(defaultUrl, payload)
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestDingTalk_Post(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/notify/text", r.URL.Path)
+
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var payload = DingTalkText{}
+		err = json.Unmarshal(b, &payload)
+		require.NoError(t, err)
+		require.Equal(t, "Application: podinfo\r\nNamespace: test\r\nMessage: just test", payload.Text)
+		require.Equal(t, "060004", payload.UserIds)
+	}))
+	defer ts.Close()
+
+	dingtalk, err := NewDingTalk(ts.URL, "060004", "notify-text")
+	require.NoError(t, err)
+
+	err = dingtalk.Post("podinfo", "test", "just test", nil, "info")
+	require.NoError(t, err)
 }
@@ -8,6 +8,9 @@ type Factory struct {
 	URL      string
 	Username string
 	Channel  string
+	Secret   string
+	Token    string
+	Generic  GenericOptions
 }
 
 func NewFactory(url string, username string, channel string) *Factory {
@@ -27,7 +30,11 @@ func (f Factory) Notifier(provider string) (Interface, error) {
 	var err error
 	switch provider {
 	case "slack":
-		n, err = NewSlack(f.URL, f.Username, f.Channel)
+		if f.Token != "" {
+			n, err = NewSlackBot(f.URL, f.Token, f.Username, f.Channel)
+		} else {
+			n, err = NewSlack(f.URL, f.Username, f.Channel)
+		}
 	case "discord":
 		n, err = NewDiscord(f.URL, f.Username, f.Channel)
 	case "rocket":
@@ -36,6 +43,16 @@ func (f Factory) Notifier(provider string) (Interface, error) {
 		n, err = NewMSTeams(f.URL)
 	case "dingtalk":
 		n, err = NewDingTalk(f.URL, f.Username, f.Channel)
+	case "wecom":
+		n, err = NewWeCom(f.URL, f.Secret)
+	case "feishu":
+		n, err = NewFeishu(f.URL, f.Secret)
+	case "pagerduty":
+		n, err = NewPagerDuty(f.URL, f.Token)
+	case "opsgenie":
+		n, err = NewOpsgenie(f.URL, f.Token)
+	case "generic":
+		n, err = NewGeneric(f.URL, f.Generic)
 	default:
 		err = fmt.Errorf("provider %s not supported", provider)
 	}
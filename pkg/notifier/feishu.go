@@ -0,0 +1,126 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Feishu holds the custom bot webhook URL and the optional signing secret
+type Feishu struct {
+	URL    string
+	Secret string
+}
+
+// FeishuPayload holds the interactive card and the signature
+type FeishuPayload struct {
+	Timestamp string     `json:"timestamp,omitempty"`
+	Sign      string     `json:"sign,omitempty"`
+	MsgType   string     `json:"msg_type"`
+	Card      FeishuCard `json:"card"`
+}
+
+type FeishuCard struct {
+	Header   FeishuHeader    `json:"header"`
+	Elements []FeishuElement `json:"elements"`
+}
+
+type FeishuHeader struct {
+	Title    FeishuText `json:"title"`
+	Template string     `json:"template"`
+}
+
+type FeishuElement struct {
+	Tag    string        `json:"tag"`
+	Text   *FeishuText   `json:"text,omitempty"`
+	Fields []FeishuField `json:"fields,omitempty"`
+}
+
+type FeishuField struct {
+	IsShort bool       `json:"is_short"`
+	Text    FeishuText `json:"text"`
+}
+
+type FeishuText struct {
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+}
+
+type feishuResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// NewFeishu validates the Feishu URL and returns a Feishu object
+func NewFeishu(hookURL string, secret string) (*Feishu, error) {
+	_, err := url.ParseRequestURI(hookURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Feishu webhook URL %s", hookURL)
+	}
+
+	return &Feishu{
+		URL:    hookURL,
+		Secret: secret,
+	}, nil
+}
+
+// Post Feishu interactive card message
+func (s *Feishu) Post(workload string, namespace string, message string, fields []Field, severity string) error {
+	color := "green"
+	switch severity {
+	case "warn":
+		color = "orange"
+	case "error":
+		color = "red"
+	}
+
+	elements := []FeishuElement{
+		{
+			Tag:  "div",
+			Text: &FeishuText{Tag: "lark_md", Content: message},
+		},
+	}
+	if len(fields) > 0 {
+		cardFields := make([]FeishuField, 0, len(fields))
+		for _, f := range fields {
+			cardFields = append(cardFields, FeishuField{
+				IsShort: true,
+				Text:    FeishuText{Tag: "lark_md", Content: fmt.Sprintf("**%s**\n%s", f.Name, f.Value)},
+			})
+		}
+		elements = append(elements, FeishuElement{
+			Tag:    "div",
+			Fields: cardFields,
+		})
+	}
+
+	payload := FeishuPayload{
+		MsgType: "interactive",
+		Card: FeishuCard{
+			Header: FeishuHeader{
+				Title:    FeishuText{Tag: "plain_text", Content: fmt.Sprintf("%s.%s", workload, namespace)},
+				Template: color,
+			},
+			Elements: elements,
+		},
+	}
+
+	if s.Secret != "" {
+		timestamp := time.Now().Unix()
+		payload.Timestamp = strconv.FormatInt(timestamp, 10)
+		payload.Sign = feishuSign(timestamp, s.Secret)
+	}
+
+	body, err := postMessageWithResponse(s.URL, payload)
+	if err != nil {
+		return err
+	}
+
+	var res feishuResponse
+	if err := json.Unmarshal(body, &res); err == nil && res.Code != 0 {
+		return fmt.Errorf("sending notification failed: %d %s", res.Code, res.Msg)
+	}
+	return nil
+}
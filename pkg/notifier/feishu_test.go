@@ -0,0 +1,59 @@
+package notifier
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeishu_Post(t *testing.T) {
+	fields := []Field{
+		{Name: "name1", Value: "value1"},
+		{Name: "name2", Value: "value2"},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var payload = FeishuPayload{}
+		err = json.Unmarshal(b, &payload)
+		require.NoError(t, err)
+
+		timestamp, err := strconv.ParseInt(payload.Timestamp, 10, 64)
+		require.NoError(t, err)
+		require.Equal(t, feishuSign(timestamp, "secret"), payload.Sign)
+
+		require.Equal(t, "interactive", payload.MsgType)
+		require.Equal(t, "podinfo.test", payload.Card.Header.Title.Content)
+		require.Equal(t, "orange", payload.Card.Header.Template)
+		require.Equal(t, len(fields), len(payload.Card.Elements[1].Fields))
+
+		w.Write([]byte(`{"code":0,"msg":"success"}`))
+	}))
+	defer ts.Close()
+
+	feishu, err := NewFeishu(ts.URL, "secret")
+	require.NoError(t, err)
+
+	err = feishu.Post("podinfo", "test", "test", fields, "warn")
+	require.NoError(t, err)
+}
+
+func TestFeishu_PostError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`))
+	}))
+	defer ts.Close()
+
+	feishu, err := NewFeishu(ts.URL, "")
+	require.NoError(t, err)
+
+	err = feishu.Post("podinfo", "test", "test", nil, "info")
+	require.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultGenericTemplate is the request body used when the alert provider has no template
+const defaultGenericTemplate = `{
+  "name": {{ toJson .Name }},
+  "namespace": {{ toJson .Namespace }},
+  "phase": {{ toJson .Phase }},
+  "severity": {{ toJson .Severity }},
+  "message": {{ toJson .Message }},
+  "fields": {{ toJson .Fields }}
+}`
+
+// GenericOptions holds the request settings of the generic notifier
+type GenericOptions struct {
+	// Method defaults to POST
+	Method string
+
+	// Template is the Go template of the request body
+	Template string
+
+	// Headers are added to the request, Content-Type defaults to application/json
+	Headers map[string]string
+
+	// ExpectedStatusCodes defaults to any 2xx status
+	ExpectedStatusCodes []int
+}
+
+// Generic holds the templated webhook configuration
+type Generic struct {
+	URL                 string
+	Method              string
+	Headers             map[string]string
+	ExpectedStatusCodes []int
+	template            *template.Template
+}
+
+var genericFuncs = template.FuncMap{
+	"toJson": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// NewGeneric validates the URL and parses the body template
+func NewGeneric(address string, options GenericOptions) (*Generic, error) {
+	if _, err := url.ParseRequestURI(address); err != nil {
+		return nil, fmt.Errorf("invalid generic webhook URL %s", address)
+	}
+
+	method := strings.ToUpper(options.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	body := options.Template
+	if body == "" {
+		body = defaultGenericTemplate
+	}
+	tmpl, err := template.New("generic").Funcs(genericFuncs).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("template parsing failed: %w", err)
+	}
+
+	return &Generic{
+		URL:                 address,
+		Method:              method,
+		Headers:             options.Headers,
+		ExpectedStatusCodes: options.ExpectedStatusCodes,
+		template:            tmpl,
+	}, nil
+}
+
+// Post renders the template and sends the request without a canary phase
+func (g *Generic) Post(workload string, namespace string, message string, fields []Field, severity string) error {
+	return g.PostEvent(Event{
+		Name:      workload,
+		Namespace: namespace,
+		Message:   message,
+		Fields:    fields,
+		Severity:  severity,
+	})
+}
+
+// PostEvent renders the template and sends the request
+func (g *Generic) PostEvent(event Event) error {
+	var body bytes.Buffer
+	if err := g.template.Execute(&body, event); err != nil {
+		return fmt.Errorf("template execution failed: %w", err)
+	}
+
+	req, err := http.NewRequest(g.Method, g.URL, &body)
+	if err != nil {
+		return fmt.Errorf("http.NewRequest failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range g.Headers {
+		req.Header.Set(k, v)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+	defer cancel()
+
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("sending notification failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if !isExpectedStatus(res.StatusCode, g.ExpectedStatusCodes) {
+		b, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("sending notification failed: status %d %s", res.StatusCode, string(b))
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package notifier
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneric_Post(t *testing.T) {
+	fields := []Field{
+		{Name: "name1", Value: "value1"},
+		{Name: "name2", Value: "value2"},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var payload = map[string]interface{}{}
+		err = json.Unmarshal(b, &payload)
+		require.NoError(t, err)
+		require.Equal(t, "podinfo", payload["name"])
+		require.Equal(t, "Progressing", payload["phase"])
+		require.Equal(t, "quoted \"message\"", payload["message"])
+		require.Len(t, payload["fields"], len(fields))
+	}))
+	defer ts.Close()
+
+	generic, err := NewGeneric(ts.URL, GenericOptions{})
+	require.NoError(t, err)
+
+	err = generic.PostEvent(Event{
+		Name:      "podinfo",
+		Namespace: "test",
+		Message:   "quoted \"message\"",
+		Fields:    fields,
+		Severity:  "info",
+		Phase:     "Progressing",
+	})
+	require.NoError(t, err)
+}
+
+func TestGeneric_PostTemplate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		require.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, "ERROR podinfo.test: test", string(b))
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	generic, err := NewGeneric(ts.URL, GenericOptions{
+		Method:   "put",
+		Template: "{{ upper .Severity }} {{ .Name }}.{{ .Namespace }}: {{ .Message }}",
+		Headers: map[string]string{
+			"Content-Type":  "text/plain",
+			"Authorization": "Bearer token",
+		},
+		ExpectedStatusCodes: []int{http.StatusAccepted},
+	})
+	require.NoError(t, err)
+
+	err = generic.Post("podinfo", "test", "test", nil, "error")
+	require.NoError(t, err)
+}
+
+func TestGeneric_PostUnexpectedStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	generic, err := NewGeneric(ts.URL, GenericOptions{
+		ExpectedStatusCodes: []int{http.StatusCreated},
+	})
+	require.NoError(t, err)
+
+	err = generic.Post("podinfo", "test", "test", nil, "error")
+	require.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"fmt"
+)
+
+const (
+	incidentTrigger = "trigger"
+	incidentResolve = "resolve"
+)
+
+// incidentAction returns trigger for error alerts, resolve for promotions and an empty string
+// for the events that incident notifiers ignore
+func incidentAction(event Event) string {
+	switch {
+	case event.Kind == EventKindPromoted:
+		return incidentResolve
+	case event.Severity == "error":
+		return incidentTrigger
+	default:
+		return ""
+	}
+}
+
+// incidentKey returns the deduplication key of the canary incident, the key contains the revision
+// so that the failures of a revision don't update or resolve the incident of another one
+func incidentKey(event Event) string {
+	key := event.Key
+	if key == "" {
+		key = fmt.Sprintf("%s.%s", event.Name, event.Namespace)
+	}
+	if event.Revision != "" {
+		return fmt.Sprintf("flagger/%s/%s", key, event.Revision)
+	}
+	return fmt.Sprintf("flagger/%s", key)
+}
+
+func incidentDetails(event Event) map[string]string {
+	details := make(map[string]string, len(event.Fields)+2)
+	for _, f := range event.Fields {
+		details[f.Name] = f.Value
+	}
+	if event.Phase != "" {
+		details["Phase"] = event.Phase
+	}
+	if event.Revision != "" {
+		details["Revision"] = event.Revision
+	}
+	return details
+}
@@ -1,10 +1,75 @@
 package notifier
 
+import (
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+)
+
 type Interface interface {
 	Post(workload string, namespace string, message string, fields []Field, severity string) error
 }
 
+// EventNotifier is implemented by the notifiers that need the canary details not passed to Post
+type EventNotifier interface {
+	PostEvent(event Event) error
+}
+
 type Field struct {
 	Name  string
 	Value string
 }
+
+// EventKind is the canary lifecycle event that triggered an alert,
+// the kinds match the events the canary alerts can subscribe to
+type EventKind string
+
+const (
+	EventKindInitialized = EventKind(flaggerv1.AlertEventInitialized)
+	EventKindStarted     = EventKind(flaggerv1.AlertEventStarted)
+	EventKindWaiting     = EventKind(flaggerv1.AlertEventWaiting)
+	EventKindPromoted    = EventKind(flaggerv1.AlertEventPromoted)
+	EventKindRolledBack  = EventKind(flaggerv1.AlertEventRolledBack)
+	// EventKindStepAdvanced, EventKindHalted and EventKindWebhookFailed are only sent to the alerts
+	// subscribed to them, halts are also posted to the threads of the canary revisions
+	EventKindStepAdvanced  = EventKind(flaggerv1.AlertEventStepAdvanced)
+	EventKindHalted        = EventKind(flaggerv1.AlertEventHalted)
+	EventKindWebhookFailed = EventKind(flaggerv1.AlertEventWebhookFailed)
+)
+
+// Event holds the canary details of an alert
+type Event struct {
+	Name      string
+	Namespace string
+	Message   string
+	Fields    []Field
+	Severity  string
+	Phase     string
+	Kind      EventKind
+	// Weight is the canary traffic weight when the event occurred
+	Weight int
+	// FailedChecks is the number of failed checks of the current analysis
+	FailedChecks int
+	// Key correlates the events of the same canary
+	Key string
+	// Revision is the hash of the canary spec the event refers to
+	Revision string
+}
+
+// Thread identifies the parent message of the alerts of a canary revision
+type Thread struct {
+	Channel   string
+	Timestamp string
+}
+
+// ThreadNotifier is implemented by the notifiers that post the alerts of a canary revision
+// as replies to a parent message, the parent message is updated with the canary state on each event
+type ThreadNotifier interface {
+	PostThread(event Event, thread *Thread) (*Thread, error)
+}
+
+// Send posts the event with PostEvent if the notifier implements it or with Post otherwise
+func Send(n Interface, event Event) error {
+	if en, ok := n.(EventNotifier); ok {
+		return en.PostEvent(event)
+	}
+	return n.Post(event.Name, event.Namespace, event.Message, event.Fields, event.Severity)
+}
@@ -0,0 +1,90 @@
+package notifier
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Opsgenie holds the Alert API URL and the integration API key
+type Opsgenie struct {
+	URL    string
+	APIKey string
+}
+
+// OpsgenieAlert holds the create alert request
+type OpsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source"`
+	Entity      string            `json:"entity,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// OpsgenieClose holds the close alert request
+type OpsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+// NewOpsgenie validates the Opsgenie URL and returns an Opsgenie object
+func NewOpsgenie(address string, apiKey string) (*Opsgenie, error) {
+	_, err := url.ParseRequestURI(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Opsgenie URL %s", address)
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("Opsgenie API key is empty")
+	}
+
+	return &Opsgenie{
+		URL:    strings.TrimSuffix(address, "/"),
+		APIKey: apiKey,
+	}, nil
+}
+
+// Post creates an alert for error alerts
+func (s *Opsgenie) Post(workload string, namespace string, message string, fields []Field, severity string) error {
+	return s.PostEvent(Event{
+		Name:      workload,
+		Namespace: namespace,
+		Message:   message,
+		Fields:    fields,
+		Severity:  severity,
+	})
+}
+
+// PostEvent creates the canary alert for error alerts, Opsgenie increases the count of an open alert
+// with the same alias, and closes it when the revision is promoted
+func (s *Opsgenie) PostEvent(event Event) error {
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("GenieKey %s", s.APIKey),
+	}
+	alias := incidentKey(event)
+
+	switch incidentAction(event) {
+	case incidentTrigger:
+		payload := OpsgenieAlert{
+			Message:     fmt.Sprintf("%s.%s: %s", event.Name, event.Namespace, event.Message),
+			Alias:       alias,
+			Description: event.Message,
+			Priority:    "P2",
+			Source:      "flagger",
+			Entity:      fmt.Sprintf("%s.%s", event.Name, event.Namespace),
+			Details:     incidentDetails(event),
+		}
+		_, err := postJSON(fmt.Sprintf("%s/v2/alerts", s.URL), headers, payload)
+		return err
+	case incidentResolve:
+		payload := OpsgenieClose{
+			Source: "flagger",
+			Note:   event.Message,
+		}
+		address := fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", s.URL, url.PathEscape(alias))
+		_, err := postJSON(address, headers, payload)
+		return err
+	}
+	return nil
+}
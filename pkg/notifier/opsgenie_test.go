@@ -0,0 +1,54 @@
+package notifier
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpsgenie_PostEvent(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "GenieKey api-key", r.Header.Get("Authorization"))
+		paths = append(paths, r.URL.RequestURI())
+
+		if r.URL.Path == "/v2/alerts" {
+			b, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+
+			var payload = OpsgenieAlert{}
+			err = json.Unmarshal(b, &payload)
+			require.NoError(t, err)
+			require.Equal(t, "flagger/podinfo.test", payload.Alias)
+			require.Equal(t, "podinfo.test: Progress deadline exceeded", payload.Message)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	og, err := NewOpsgenie(ts.URL+"/", "api-key")
+	require.NoError(t, err)
+
+	err = og.Post("podinfo", "test", "Progress deadline exceeded", nil, "error")
+	require.NoError(t, err)
+
+	err = og.PostEvent(Event{
+		Name:      "podinfo",
+		Namespace: "test",
+		Message:   "Canary analysis completed successfully, promotion finished.",
+		Severity:  "info",
+		Kind:      EventKindPromoted,
+		Key:       "podinfo.test",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"/v2/alerts",
+		"/v2/alerts/flagger%2Fpodinfo.test/close?identifierType=alias",
+	}, paths)
+}
@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// PagerDuty holds the Events API v2 URL and the integration routing key
+type PagerDuty struct {
+	URL        string
+	RoutingKey string
+}
+
+// PagerDutyEvent holds the Events API v2 request
+type PagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *PagerDutyPayload `json:"payload,omitempty"`
+}
+
+type PagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Component     string            `json:"component,omitempty"`
+	Group         string            `json:"group,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+// NewPagerDuty validates the PagerDuty URL and returns a PagerDuty object
+func NewPagerDuty(address string, routingKey string) (*PagerDuty, error) {
+	_, err := url.ParseRequestURI(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PagerDuty URL %s", address)
+	}
+	if routingKey == "" {
+		return nil, fmt.Errorf("PagerDuty routing key is empty")
+	}
+
+	return &PagerDuty{
+		URL:        address,
+		RoutingKey: routingKey,
+	}, nil
+}
+
+// Post triggers an incident for error alerts
+func (s *PagerDuty) Post(workload string, namespace string, message string, fields []Field, severity string) error {
+	return s.PostEvent(Event{
+		Name:      workload,
+		Namespace: namespace,
+		Message:   message,
+		Fields:    fields,
+		Severity:  severity,
+	})
+}
+
+// PostEvent triggers or updates the canary incident for error alerts
+// and resolves it when the revision is promoted
+func (s *PagerDuty) PostEvent(event Event) error {
+	action := incidentAction(event)
+	if action == "" {
+		return nil
+	}
+
+	payload := PagerDutyEvent{
+		RoutingKey:  s.RoutingKey,
+		EventAction: action,
+		DedupKey:    incidentKey(event),
+	}
+	if action == incidentTrigger {
+		payload.Payload = &PagerDutyPayload{
+			Summary:       fmt.Sprintf("%s.%s: %s", event.Name, event.Namespace, event.Message),
+			Source:        "flagger",
+			Severity:      "critical",
+			Component:     event.Name,
+			Group:         event.Namespace,
+			CustomDetails: incidentDetails(event),
+		}
+	}
+
+	_, err := postJSON(s.URL, nil, payload)
+	return err
+}
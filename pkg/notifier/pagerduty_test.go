@@ -0,0 +1,75 @@
+package notifier
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagerDuty_PostEvent(t *testing.T) {
+	var events []PagerDutyEvent
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var payload = PagerDutyEvent{}
+		err = json.Unmarshal(b, &payload)
+		require.NoError(t, err)
+		events = append(events, payload)
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	pd, err := NewPagerDuty(ts.URL, "routing-key")
+	require.NoError(t, err)
+
+	// rollback triggers the incident
+	err = pd.PostEvent(Event{
+		Name:      "podinfo",
+		Namespace: "test",
+		Message:   "Failed checks threshold reached 5",
+		Severity:  "error",
+		Kind:      EventKindRolledBack,
+		Key:       "podinfo.test",
+		Revision:  "abc",
+	})
+	require.NoError(t, err)
+
+	// info alerts are ignored
+	err = pd.PostEvent(Event{
+		Name:      "podinfo",
+		Namespace: "test",
+		Message:   "New revision detected, starting canary analysis.",
+		Severity:  "info",
+		Kind:      EventKindStarted,
+		Key:       "podinfo.test",
+		Revision:  "def",
+	})
+	require.NoError(t, err)
+
+	// promotion resolves the incident of the same revision
+	err = pd.PostEvent(Event{
+		Name:      "podinfo",
+		Namespace: "test",
+		Message:   "Canary analysis completed successfully, promotion finished.",
+		Severity:  "info",
+		Kind:      EventKindPromoted,
+		Key:       "podinfo.test",
+		Revision:  "abc",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, events, 2)
+	require.Equal(t, "trigger", events[0].EventAction)
+	require.Equal(t, "routing-key", events[0].RoutingKey)
+	require.Equal(t, "flagger/podinfo.test/abc", events[0].DedupKey)
+	require.Equal(t, "abc", events[0].Payload.CustomDetails["Revision"])
+	require.Equal(t, "resolve", events[1].EventAction)
+	require.Equal(t, "flagger/podinfo.test/abc", events[1].DedupKey)
+	require.Nil(t, events[1].Payload)
+}
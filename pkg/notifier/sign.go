@@ -0,0 +1,22 @@
+package notifier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// hmacSign returns the base64 HMAC-SHA256 of timestamp + "\n" + secret keyed with the secret,
+// the signature scheme of DingTalk robots
+func hmacSign(timestamp int64, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// feishuSign returns the base64 HMAC-SHA256 of an empty message keyed with timestamp + "\n" + secret
+func feishuSign(timestamp int64, secret string) string {
+	h := hmac.New(sha256.New, []byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -20,6 +20,8 @@ type SlackPayload struct {
 	IconUrl     string            `json:"icon_url"`
 	IconEmoji   string            `json:"icon_emoji"`
 	Text        string            `json:"text,omitempty"`
+	ThreadTs    string            `json:"thread_ts,omitempty"`
+	Ts          string            `json:"ts,omitempty"`
 	Attachments []SlackAttachment `json:"attachments,omitempty"`
 }
 
@@ -62,6 +64,16 @@ func NewSlack(hookURL string, username string, channel string) (*Slack, error) {
 
 // Post Slack message
 func (s *Slack) Post(workload string, namespace string, message string, fields []Field, severity string) error {
+	payload := s.payload(workload, namespace, message, fields, severity)
+
+	err := postMessage(s.URL, payload)
+	if err != nil {
+		return fmt.Errorf("postMessage failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Slack) payload(workload string, namespace string, message string, fields []Field, severity string) SlackPayload {
 	payload := SlackPayload{
 		Channel:   s.Channel,
 		Username:  s.Username,
@@ -87,10 +99,5 @@ func (s *Slack) Post(workload string, namespace string, message string, fields [
 	}
 
 	payload.Attachments = []SlackAttachment{a}
-
-	err := postMessage(s.URL, payload)
-	if err != nil {
-		return fmt.Errorf("postMessage failed: %w", err)
-	}
-	return nil
+	return payload
 }
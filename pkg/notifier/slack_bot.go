@@ -0,0 +1,150 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// SlackAPIURL is the default address of the Slack Web API
+const SlackAPIURL = "https://slack.com/api"
+
+// SlackBot posts messages with the Slack Web API using a bot token
+type SlackBot struct {
+	Slack
+	Token string
+}
+
+// SlackAPIResponse holds the Slack Web API result
+type SlackAPIResponse struct {
+	Ok      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	Ts      string `json:"ts,omitempty"`
+}
+
+// NewSlackBot validates the Slack Web API URL and the bot token and returns a SlackBot object
+func NewSlackBot(apiURL string, token string, username string, channel string) (*SlackBot, error) {
+	if apiURL == "" {
+		apiURL = SlackAPIURL
+	}
+
+	_, err := url.ParseRequestURI(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Slack API URL %s", apiURL)
+	}
+
+	if token == "" {
+		return nil, errors.New("empty Slack bot token")
+	}
+
+	if channel == "" {
+		return nil, errors.New("empty Slack channel")
+	}
+
+	return &SlackBot{
+		Slack: Slack{
+			URL:      strings.TrimSuffix(apiURL, "/"),
+			Username: username,
+			Channel:  channel,
+		},
+		Token: token,
+	}, nil
+}
+
+// Post Slack message with chat.postMessage
+func (s *SlackBot) Post(workload string, namespace string, message string, fields []Field, severity string) error {
+	_, err := s.call("chat.postMessage", s.payload(workload, namespace, message, fields, severity))
+	return err
+}
+
+// PostThread posts the event as a reply to the canary revision parent message,
+// the parent message is created on the first event and updated with the canary state afterwards
+func (s *SlackBot) PostThread(event Event, thread *Thread) (*Thread, error) {
+	parent := s.parentPayload(event)
+	if thread == nil {
+		res, err := s.call("chat.postMessage", parent)
+		if err != nil {
+			return nil, err
+		}
+		thread = &Thread{Channel: res.Channel, Timestamp: res.Ts}
+	} else {
+		parent.Channel = thread.Channel
+		parent.Ts = thread.Timestamp
+		if _, err := s.call("chat.update", parent); err != nil {
+			return thread, err
+		}
+	}
+
+	reply := s.payload(event.Name, event.Namespace, event.Message, event.Fields, event.Severity)
+	reply.Channel = thread.Channel
+	reply.ThreadTs = thread.Timestamp
+	_, err := s.call("chat.postMessage", reply)
+	return thread, err
+}
+
+// parentPayload renders the canary state summary
+func (s *SlackBot) parentPayload(event Event) SlackPayload {
+	color := "#439FE0"
+	switch {
+	case event.Phase == "Succeeded":
+		color = "good"
+	case event.Phase == "Failed":
+		color = "danger"
+	case event.FailedChecks > 0:
+		color = "warning"
+	}
+
+	phase := event.Phase
+	if phase == "" {
+		phase = "Initializing"
+	}
+
+	fields := []SlackField{
+		{Title: "Phase", Value: phase, Short: true},
+		{Title: "Traffic weight", Value: fmt.Sprintf("%d%%", event.Weight), Short: true},
+		{Title: "Failed checks", Value: fmt.Sprintf("%d", event.FailedChecks), Short: true},
+	}
+	if event.Revision != "" {
+		fields = append(fields, SlackField{Title: "Revision", Value: event.Revision, Short: true})
+	}
+
+	return SlackPayload{
+		Channel:   s.Channel,
+		Username:  s.Username,
+		IconEmoji: ":rocket:",
+		Attachments: []SlackAttachment{
+			{
+				Color:      color,
+				AuthorName: fmt.Sprintf("%s.%s", event.Name, event.Namespace),
+				Text:       fmt.Sprintf("Canary analysis of %s.%s", event.Name, event.Namespace),
+				MrkdwnIn:   []string{"text"},
+				Fields:     fields,
+			},
+		},
+	}
+}
+
+// call invokes a Slack Web API method, the API returns errors in the response body
+func (s *SlackBot) call(method string, payload SlackPayload) (*SlackAPIResponse, error) {
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", s.Token),
+		"Content-type":  "application/json; charset=utf-8",
+	}
+
+	body, err := postJSON(fmt.Sprintf("%s/%s", s.URL, method), headers, payload)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", method, err)
+	}
+
+	var res SlackAPIResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("%s response unmarshal failed: %w", method, err)
+	}
+	if !res.Ok {
+		return nil, fmt.Errorf("%s failed: %s", method, res.Error)
+	}
+	return &res, nil
+}
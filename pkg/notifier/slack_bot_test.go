@@ -0,0 +1,77 @@
+package notifier
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBot_PostThread(t *testing.T) {
+	var calls []string
+	var payloads []SlackPayload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer xoxb-token", r.Header.Get("Authorization"))
+
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var payload = SlackPayload{}
+		err = json.Unmarshal(b, &payload)
+		require.NoError(t, err)
+
+		calls = append(calls, r.URL.Path)
+		payloads = append(payloads, payload)
+		w.Write([]byte(`{"ok":true,"channel":"C123","ts":"1503435956.000247"}`))
+	}))
+	defer ts.Close()
+
+	bot, err := NewSlackBot(ts.URL, "xoxb-token", "flagger", "general")
+	require.NoError(t, err)
+
+	event := Event{
+		Name:      "podinfo",
+		Namespace: "test",
+		Message:   "Starting canary analysis",
+		Phase:     "Progressing",
+		Weight:    10,
+		Revision:  "abc",
+	}
+
+	thread, err := bot.PostThread(event, nil)
+	require.NoError(t, err)
+	require.Equal(t, &Thread{Channel: "C123", Timestamp: "1503435956.000247"}, thread)
+	require.Equal(t, []string{"/chat.postMessage", "/chat.postMessage"}, calls)
+	require.Equal(t, "general", payloads[0].Channel)
+	require.Equal(t, "10%", payloads[0].Attachments[0].Fields[1].Value)
+	require.Equal(t, "1503435956.000247", payloads[1].ThreadTs)
+	require.Equal(t, "Starting canary analysis", payloads[1].Attachments[0].Text)
+
+	calls, payloads = nil, nil
+	event.Message = "Advance podinfo.test canary weight 20"
+	event.Weight = 20
+	_, err = bot.PostThread(event, thread)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/chat.update", "/chat.postMessage"}, calls)
+	require.Equal(t, "C123", payloads[0].Channel)
+	require.Equal(t, "1503435956.000247", payloads[0].Ts)
+	require.Equal(t, "20%", payloads[0].Attachments[0].Fields[1].Value)
+	require.Equal(t, "1503435956.000247", payloads[1].ThreadTs)
+}
+
+func TestSlackBot_PostError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+	}))
+	defer ts.Close()
+
+	bot, err := NewSlackBot(ts.URL, "xoxb-token", "flagger", "general")
+	require.NoError(t, err)
+
+	err = bot.Post("podinfo", "test", "test", nil, "info")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "channel_not_found")
+}
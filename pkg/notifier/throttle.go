@@ -0,0 +1,168 @@
+package notifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ThrottleOptions defines how the repeated alerts of a canary revision are suppressed
+type ThrottleOptions struct {
+	// Window during which identical messages are sent once
+	Window time.Duration
+
+	// Limit is the maximum number of messages sent within the window
+	Limit int
+
+	// DigestInterval at which the suppressed messages are sent as a single digest
+	DigestInterval time.Duration
+}
+
+// ThrottleResult is the outcome of filtering an event
+type ThrottleResult string
+
+const (
+	ThrottleSent       ThrottleResult = "sent"
+	ThrottleSuppressed ThrottleResult = "suppressed"
+	ThrottleDigest     ThrottleResult = "digest"
+)
+
+// Throttler keeps the alerts sent and suppressed per provider and canary revision
+type Throttler struct {
+	mu      sync.Mutex
+	entries map[string]*throttleEntry
+	now     func() time.Time
+}
+
+type throttleEntry struct {
+	canary     string
+	revision   string
+	lastSent   map[string]time.Time
+	sent       []time.Time
+	suppressed map[string]int
+	lastDigest time.Time
+}
+
+// NewThrottler returns an empty throttler
+func NewThrottler() *Throttler {
+	return &Throttler{
+		entries: make(map[string]*throttleEntry),
+		now:     time.Now,
+	}
+}
+
+// Filter returns the event to be sent to the provider, the result and the number of suppressed messages
+// waiting for the next digest. Phase transitions are always sent, the other events are suppressed when
+// an identical message was sent within the window or when the limit is reached. The suppressed messages
+// are collapsed into a digest that is returned when the digest interval has elapsed, or appended to the
+// next event sent once the interval has elapsed, the analysis has ended or a new revision is detected.
+func (t *Throttler) Filter(provider string, event Event, opts ThrottleOptions) (Event, ThrottleResult, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	key := fmt.Sprintf("%s/%s", provider, event.Key)
+	entry, ok := t.entries[key]
+	if !ok || entry.revision != event.Revision {
+		// the messages suppressed for the previous revision are flushed with the first event of the new one
+		if ok && len(entry.suppressed) > 0 {
+			event.Message = fmt.Sprintf("%s\n%s", event.Message, entry.digest(now))
+		}
+		entry = &throttleEntry{
+			canary:     event.Key,
+			revision:   event.Revision,
+			lastSent:   make(map[string]time.Time),
+			suppressed: make(map[string]int),
+		}
+		t.entries[key] = entry
+	}
+
+	if opts.Window > 0 {
+		sent := entry.sent[:0]
+		for _, ts := range entry.sent {
+			if now.Sub(ts) < opts.Window {
+				sent = append(sent, ts)
+			}
+		}
+		entry.sent = sent
+	}
+
+	last, found := entry.lastSent[event.Message]
+	duplicate := opts.Window > 0 && found && now.Sub(last) < opts.Window
+	limited := opts.Window > 0 && opts.Limit > 0 && len(entry.sent) >= opts.Limit
+	if isTransition(event.Kind) || (!duplicate && !limited) {
+		entry.record(event.Message, now)
+		if len(entry.suppressed) > 0 && (isTerminal(event.Kind) || entry.digestDue(now, opts)) {
+			event.Message = fmt.Sprintf("%s\n%s", event.Message, entry.digest(now))
+			return event, ThrottleDigest, 0
+		}
+		return event, ThrottleSent, len(entry.suppressed)
+	}
+
+	if len(entry.suppressed) == 0 {
+		entry.lastDigest = now
+	}
+	entry.suppressed[event.Message]++
+
+	if entry.digestDue(now, opts) {
+		digest := event
+		digest.Message = entry.digest(now)
+		return digest, ThrottleDigest, 0
+	}
+
+	return event, ThrottleSuppressed, len(entry.suppressed)
+}
+
+// Forget removes the throttle state of a canary
+func (t *Throttler) Forget(canary string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, entry := range t.entries {
+		if entry.canary == canary {
+			delete(t.entries, key)
+		}
+	}
+}
+
+func (e *throttleEntry) record(message string, now time.Time) {
+	e.lastSent[message] = now
+	e.sent = append(e.sent, now)
+}
+
+// digestDue returns true when the digest interval has elapsed since the first suppressed message
+func (e *throttleEntry) digestDue(now time.Time, opts ThrottleOptions) bool {
+	return opts.DigestInterval > 0 && now.Sub(e.lastDigest) >= opts.DigestInterval
+}
+
+// digest collapses the suppressed messages into a single message and resets them
+func (e *throttleEntry) digest(now time.Time) string {
+	messages := make([]string, 0, len(e.suppressed))
+	total := 0
+	for message, count := range e.suppressed {
+		messages = append(messages, fmt.Sprintf("%s (x%d)", message, count))
+		total += count
+	}
+	sort.Strings(messages)
+	elapsed := now.Sub(e.lastDigest).Round(time.Second)
+
+	e.suppressed = make(map[string]int)
+	e.lastDigest = now
+	return fmt.Sprintf("%d alerts suppressed in the last %v: %s", total, elapsed, strings.Join(messages, ", "))
+}
+
+// isTransition returns true for the events that are never throttled
+func isTransition(kind EventKind) bool {
+	switch kind {
+	case EventKindInitialized, EventKindStarted, EventKindPromoted, EventKindRolledBack:
+		return true
+	}
+	return false
+}
+
+// isTerminal returns true for the events that end the analysis of a revision
+func isTerminal(kind EventKind) bool {
+	return kind == EventKindPromoted || kind == EventKindRolledBack
+}
@@ -0,0 +1,140 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottler_Filter(t *testing.T) {
+	now := time.Now()
+	throttler := NewThrottler()
+	throttler.now = func() time.Time { return now }
+
+	opts := ThrottleOptions{
+		Window:         10 * time.Minute,
+		DigestInterval: 5 * time.Minute,
+	}
+	waiting := Event{
+		Name:      "podinfo",
+		Namespace: "test",
+		Message:   "Canary is waiting for approval.",
+		Kind:      EventKindWaiting,
+		Key:       "podinfo.test",
+		Revision:  "abc",
+	}
+
+	_, result, _ := throttler.Filter("slack", waiting, opts)
+	require.Equal(t, ThrottleSent, result)
+
+	// identical messages are suppressed within the window
+	now = now.Add(time.Minute)
+	_, result, pending := throttler.Filter("slack", waiting, opts)
+	require.Equal(t, ThrottleSuppressed, result)
+	require.Equal(t, 1, pending)
+
+	// other providers are not affected
+	_, result, _ = throttler.Filter("teams", waiting, opts)
+	require.Equal(t, ThrottleSent, result)
+
+	// phase transitions are always sent
+	started := waiting
+	started.Kind = EventKindStarted
+	_, result, _ = throttler.Filter("slack", started, opts)
+	require.Equal(t, ThrottleSent, result)
+
+	// suppressed messages are collapsed into a digest after the interval
+	now = now.Add(5 * time.Minute)
+	digest, result, pending := throttler.Filter("slack", waiting, opts)
+	require.Equal(t, ThrottleDigest, result)
+	require.Equal(t, 0, pending)
+	require.Equal(t, "2 alerts suppressed in the last 5m0s: Canary is waiting for approval. (x2)", digest.Message)
+
+	// a new revision resets the state
+	waiting.Revision = "def"
+	_, result, _ = throttler.Filter("slack", waiting, opts)
+	require.Equal(t, ThrottleSent, result)
+}
+
+func TestThrottler_FilterFlush(t *testing.T) {
+	now := time.Now()
+	throttler := NewThrottler()
+	throttler.now = func() time.Time { return now }
+
+	opts := ThrottleOptions{
+		Window:         10 * time.Minute,
+		DigestInterval: 5 * time.Minute,
+	}
+	halted := Event{
+		Message:  "Halt advancement podinfo-primary.test waiting for rollout to finish",
+		Kind:     EventKindHalted,
+		Key:      "podinfo.test",
+		Revision: "abc",
+	}
+
+	_, result, _ := throttler.Filter("slack", halted, opts)
+	require.Equal(t, ThrottleSent, result)
+	now = now.Add(time.Minute)
+	_, result, _ = throttler.Filter("slack", halted, opts)
+	require.Equal(t, ThrottleSuppressed, result)
+
+	// the pending digest is appended to the next message sent after the interval
+	now = now.Add(5 * time.Minute)
+	other := halted
+	other.Message = "Halt advancement, canary is not ready"
+	sent, result, pending := throttler.Filter("slack", other, opts)
+	require.Equal(t, ThrottleDigest, result)
+	require.Equal(t, 0, pending)
+	require.Equal(t, "Halt advancement, canary is not ready\n"+
+		"1 alerts suppressed in the last 5m0s: Halt advancement podinfo-primary.test waiting for rollout to finish (x1)", sent.Message)
+
+	// the pending digest is flushed at the end of the analysis
+	now = now.Add(time.Minute)
+	_, result, _ = throttler.Filter("slack", halted, opts)
+	require.Equal(t, ThrottleSuppressed, result)
+	promoted := halted
+	promoted.Message = "Canary analysis completed successfully, promotion finished."
+	promoted.Kind = EventKindPromoted
+	sent, result, _ = throttler.Filter("slack", promoted, opts)
+	require.Equal(t, ThrottleDigest, result)
+	require.Contains(t, sent.Message, "1 alerts suppressed in the last 0s")
+
+	// the pending digest of the previous revision is flushed with the first event of the new one
+	now = now.Add(time.Minute)
+	_, result, _ = throttler.Filter("slack", halted, opts)
+	require.Equal(t, ThrottleSuppressed, result)
+	started := halted
+	started.Message = "New revision detected, starting canary analysis."
+	started.Kind = EventKindStarted
+	started.Revision = "def"
+	sent, result, _ = throttler.Filter("slack", started, opts)
+	require.Equal(t, ThrottleSent, result)
+	require.Contains(t, sent.Message, "1 alerts suppressed in the last 0s")
+}
+
+func TestThrottler_FilterLimit(t *testing.T) {
+	now := time.Now()
+	throttler := NewThrottler()
+	throttler.now = func() time.Time { return now }
+
+	opts := ThrottleOptions{
+		Window: time.Minute,
+		Limit:  2,
+	}
+	event := Event{Key: "podinfo.test", Revision: "abc"}
+
+	for i, expected := range []ThrottleResult{ThrottleSent, ThrottleSent, ThrottleSuppressed} {
+		event.Message = string(rune('a' + i))
+		_, result, _ := throttler.Filter("slack", event, opts)
+		require.Equal(t, expected, result)
+	}
+
+	now = now.Add(time.Minute)
+	event.Message = "d"
+	_, result, _ := throttler.Filter("slack", event, opts)
+	require.Equal(t, ThrottleSent, result)
+
+	throttler.Forget("podinfo.test")
+	require.Empty(t, throttler.entries)
+}
@@ -0,0 +1,94 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WeCom holds the group robot webhook URL and the optional signing secret
+type WeCom struct {
+	URL    string
+	Secret string
+}
+
+// WeComPayload holds the markdown message
+type WeComPayload struct {
+	MsgType  string        `json:"msgtype"`
+	Markdown WeComMarkdown `json:"markdown"`
+}
+
+type WeComMarkdown struct {
+	Content string `json:"content"`
+}
+
+type weComResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// NewWeCom validates the WeCom URL and returns a WeCom object
+func NewWeCom(hookURL string, secret string) (*WeCom, error) {
+	_, err := url.ParseRequestURI(hookURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WeCom webhook URL %s", hookURL)
+	}
+
+	return &WeCom{
+		URL:    hookURL,
+		Secret: secret,
+	}, nil
+}
+
+// Post WeCom markdown message
+func (s *WeCom) Post(workload string, namespace string, message string, fields []Field, severity string) error {
+	color := "info"
+	switch severity {
+	case "warn":
+		color = "comment"
+	case "error":
+		color = "warning"
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("**%s.%s**\n", workload, namespace))
+	content.WriteString(fmt.Sprintf("> <font color=\"%s\">%s</font>\n", color, message))
+	for _, f := range fields {
+		content.WriteString(fmt.Sprintf("> %s: <font color=\"comment\">%s</font>\n", f.Name, f.Value))
+	}
+
+	payload := WeComPayload{
+		MsgType: "markdown",
+		Markdown: WeComMarkdown{
+			Content: content.String(),
+		},
+	}
+
+	address := s.URL
+	if s.Secret != "" {
+		u, err := url.Parse(s.URL)
+		if err != nil {
+			return fmt.Errorf("invalid WeCom webhook URL %s", s.URL)
+		}
+		timestamp := time.Now().UnixNano() / int64(time.Millisecond)
+		q := u.Query()
+		q.Set("timestamp", strconv.FormatInt(timestamp, 10))
+		q.Set("sign", hmacSign(timestamp, s.Secret))
+		u.RawQuery = q.Encode()
+		address = u.String()
+	}
+
+	body, err := postMessageWithResponse(address, payload)
+	if err != nil {
+		return err
+	}
+
+	var res weComResponse
+	if err := json.Unmarshal(body, &res); err == nil && res.ErrCode != 0 {
+		return fmt.Errorf("sending notification failed: %d %s", res.ErrCode, res.ErrMsg)
+	}
+	return nil
+}
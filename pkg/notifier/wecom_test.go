@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeCom_Post(t *testing.T) {
+	fields := []Field{
+		{Name: "name1", Value: "value1"},
+		{Name: "name2", Value: "value2"},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "key", r.URL.Query().Get("key"))
+
+		timestamp, err := strconv.ParseInt(r.URL.Query().Get("timestamp"), 10, 64)
+		require.NoError(t, err)
+		require.Equal(t, hmacSign(timestamp, "secret"), r.URL.Query().Get("sign"))
+
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var payload = WeComPayload{}
+		err = json.Unmarshal(b, &payload)
+		require.NoError(t, err)
+		require.Equal(t, "markdown", payload.MsgType)
+		require.True(t, strings.HasPrefix(payload.Markdown.Content, "**podinfo.test**\n> <font color=\"warning\">test</font>"))
+		require.Contains(t, payload.Markdown.Content, "name2: <font color=\"comment\">value2</font>")
+
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer ts.Close()
+
+	wecom, err := NewWeCom(ts.URL+"?key=key", "secret")
+	require.NoError(t, err)
+
+	err = wecom.Post("podinfo", "test", "test", fields, "error")
+	require.NoError(t, err)
+}
+
+func TestWeCom_PostError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errcode":93000,"errmsg":"invalid webhook url"}`))
+	}))
+	defer ts.Close()
+
+	wecom, err := NewWeCom(ts.URL, "")
+	require.NoError(t, err)
+
+	err = wecom.Post("podinfo", "test", "test", nil, "info")
+	require.Error(t, err)
+}
+
+func TestWeCom_Sign(t *testing.T) {
+	require.Equal(t, "XHSnLTbboLLBCrXfAQRHx6W9LkLB43RYwgcsOS2j3vs=", hmacSign(1600000000000, "secret"))
+}
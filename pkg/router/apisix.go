@@ -0,0 +1,381 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	istiov1alpha1 "github.com/weaveworks/flagger/pkg/apis/istio/common/v1alpha1"
+	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
+)
+
+// apisixRouteGVR is the APISIX ingress controller route CRD
+var apisixRouteGVR = schema.GroupVersionResource{
+	Group:    "apisix.apache.org",
+	Version:  "v2",
+	Resource: "apisixroutes",
+}
+
+// apisixMatch holds the APISIX route match fields managed by Flagger
+type apisixMatch struct {
+	Hosts []string     `json:"hosts,omitempty"`
+	Paths []string     `json:"paths"`
+	Exprs []apisixExpr `json:"exprs,omitempty"`
+}
+
+type apisixExpr struct {
+	Subject apisixExprSubject `json:"subject"`
+	Op      string            `json:"op"`
+	Value   string            `json:"value"`
+}
+
+type apisixExprSubject struct {
+	Scope string `json:"scope"`
+	Name  string `json:"name"`
+}
+
+type apisixBackend struct {
+	ServiceName string `json:"serviceName"`
+	ServicePort int32  `json:"servicePort"`
+	Weight      int    `json:"weight"`
+}
+
+// apisixRule holds the APISIX route rule fields managed by Flagger,
+// the other rule fields such as plugins and authentication are left to users
+type apisixRule struct {
+	Name     string          `json:"name"`
+	Priority int             `json:"priority,omitempty"`
+	Match    apisixMatch     `json:"match"`
+	Backends []apisixBackend `json:"backends"`
+}
+
+// ApisixRouter is managing ApisixRoute objects
+type ApisixRouter struct {
+	kubeClient    kubernetes.Interface
+	apisixClient  dynamic.Interface
+	flaggerClient clientset.Interface
+	logger        *zap.SugaredLogger
+}
+
+// Reconcile creates or updates the ApisixRoute
+func (ar *ApisixRouter) Reconcile(canary *flaggerv1.Canary) error {
+	if ar.apisixClient == nil {
+		return fmt.Errorf("ApisixRoute dynamic client is not configured")
+	}
+
+	apexName, _, _ := canary.GetServiceNames()
+
+	route, err := ar.apisixClient.Resource(apisixRouteGVR).Namespace(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		route = &unstructured.Unstructured{}
+		route.SetAPIVersion(apisixRouteGVR.GroupVersion().String())
+		route.SetKind("ApisixRoute")
+		route.SetName(apexName)
+		route.SetNamespace(canary.Namespace)
+		route.SetOwnerReferences([]metav1.OwnerReference{
+			*metav1.NewControllerRef(canary, schema.GroupVersionKind{
+				Group:   flaggerv1.SchemeGroupVersion.Group,
+				Version: flaggerv1.SchemeGroupVersion.Version,
+				Kind:    flaggerv1.CanaryKind,
+			}),
+		})
+		if err := ar.setRules(route, ar.makeRules(canary, 100, 0)); err != nil {
+			return fmt.Errorf("ApisixRoute %s.%s create error: %w", apexName, canary.Namespace, err)
+		}
+
+		_, err = ar.apisixClient.Resource(apisixRouteGVR).Namespace(canary.Namespace).Create(route, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("ApisixRoute %s.%s create error: %w", apexName, canary.Namespace, err)
+		}
+		ar.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+			Infof("ApisixRoute %s.%s created", apexName, canary.Namespace)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("ApisixRoute %s.%s get query error: %w", apexName, canary.Namespace, err)
+	}
+
+	// update the ApisixRoute but keep the current weights
+	primaryWeight, canaryWeight := ar.getWeights(canary, route)
+	if primaryWeight == 0 && canaryWeight == 0 {
+		primaryWeight = 100
+	}
+
+	clone := route.DeepCopy()
+	if err := ar.setRules(clone, ar.makeRules(canary, primaryWeight, canaryWeight)); err != nil {
+		return fmt.Errorf("ApisixRoute %s.%s update error: %w", apexName, canary.Namespace, err)
+	}
+
+	if !equality.Semantic.DeepEqual(route.Object["spec"], clone.Object["spec"]) {
+		_, err = ar.apisixClient.Resource(apisixRouteGVR).Namespace(canary.Namespace).Update(clone, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("ApisixRoute %s.%s update error: %w", apexName, canary.Namespace, err)
+		}
+		ar.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+			Infof("ApisixRoute %s.%s updated", apexName, canary.Namespace)
+	}
+
+	return nil
+}
+
+// GetRoutes returns the backend weights for primary and canary
+func (ar *ApisixRouter) GetRoutes(canary *flaggerv1.Canary) (
+	primaryWeight int,
+	canaryWeight int,
+	mirrored bool,
+	err error,
+) {
+	apexName, _, _ := canary.GetServiceNames()
+
+	route, err := ar.apisixClient.Resource(apisixRouteGVR).Namespace(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if err != nil {
+		err = fmt.Errorf("ApisixRoute %s.%s get query error: %w", apexName, canary.Namespace, err)
+		return
+	}
+
+	primaryWeight, canaryWeight = ar.getWeights(canary, route)
+	if primaryWeight == 0 && canaryWeight == 0 {
+		err = fmt.Errorf("ApisixRoute %s.%s does not contain backends for %s-primary and %s-canary",
+			apexName, canary.Namespace, apexName, apexName)
+	}
+	return
+}
+
+// SetRoutes updates the backend weights for primary and canary
+func (ar *ApisixRouter) SetRoutes(
+	canary *flaggerv1.Canary,
+	primaryWeight int,
+	canaryWeight int,
+	_ bool,
+) error {
+	apexName, _, _ := canary.GetServiceNames()
+
+	if primaryWeight == 0 && canaryWeight == 0 {
+		return fmt.Errorf("ApisixRoute %s.%s update failed: no valid weights", apexName, canary.Namespace)
+	}
+
+	route, err := ar.apisixClient.Resource(apisixRouteGVR).Namespace(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("ApisixRoute %s.%s query error: %w", apexName, canary.Namespace, err)
+	}
+
+	clone := route.DeepCopy()
+	if err := ar.setRules(clone, ar.makeRules(canary, primaryWeight, canaryWeight)); err != nil {
+		return fmt.Errorf("ApisixRoute %s.%s update error: %w", apexName, canary.Namespace, err)
+	}
+
+	_, err = ar.apisixClient.Resource(apisixRouteGVR).Namespace(canary.Namespace).Update(clone, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("ApisixRoute %s.%s update error: %w", apexName, canary.Namespace, err)
+	}
+	return nil
+}
+
+func (ar *ApisixRouter) Finalize(_ *flaggerv1.Canary) error {
+	return nil
+}
+
+// makeRules returns the weighted rule for progressive traffic shifting,
+// for A/B testing the canary rule matches the analysis expressions with a higher priority
+// and the default rule routes all the other requests to primary
+func (ar *ApisixRouter) makeRules(canary *flaggerv1.Canary, primaryWeight int, canaryWeight int) []apisixRule {
+	apexName, primaryName, canaryName := canary.GetServiceNames()
+	port := canary.Spec.Service.Port
+
+	match := apisixMatch{
+		Hosts: canary.Spec.Service.Hosts,
+		Paths: []string{ar.makePath(canary)},
+	}
+	backends := []apisixBackend{
+		{ServiceName: primaryName, ServicePort: port, Weight: primaryWeight},
+		{ServiceName: canaryName, ServicePort: port, Weight: canaryWeight},
+	}
+
+	if len(canary.GetAnalysis().Match) == 0 {
+		return []apisixRule{
+			{
+				Name:     apexName,
+				Match:    match,
+				Backends: backends,
+			},
+		}
+	}
+
+	// APISIX ANDs the expressions of a rule, each analysis match gets its own rule
+	var rules []apisixRule
+	for i, m := range canary.GetAnalysis().Match {
+		canaryMatch := match
+		canaryMatch.Exprs = ar.makeExprs(m.Headers, m.QueryParams)
+		rules = append(rules, apisixRule{
+			Name:     fmt.Sprintf("%s-canary-%d", apexName, i),
+			Priority: 1,
+			Match:    canaryMatch,
+			Backends: backends,
+		})
+	}
+
+	return append(rules, apisixRule{
+		Name:  apexName,
+		Match: match,
+		Backends: []apisixBackend{
+			{ServiceName: primaryName, ServicePort: port, Weight: 100},
+		},
+	})
+}
+
+// makeExprs converts the header, cookie and query match conditions to APISIX expressions,
+// a cookie header with an exact value matches the requests having that cookie set to always
+func (ar *ApisixRouter) makeExprs(headers map[string]istiov1alpha1.StringMatch, queryParams map[string]istiov1alpha1.StringMatch) []apisixExpr {
+	var exprs []apisixExpr
+	for _, name := range sortedKeys(headers) {
+		m := headers[name]
+		if strings.ToLower(name) == "cookie" && m.Exact != "" {
+			exprs = append(exprs, apisixExpr{
+				Subject: apisixExprSubject{Scope: "Cookie", Name: m.Exact},
+				Op:      "Equal",
+				Value:   "always",
+			})
+			continue
+		}
+		exprs = append(exprs, apisixStringExpr("Header", name, m))
+	}
+	for _, name := range sortedKeys(queryParams) {
+		exprs = append(exprs, apisixStringExpr("Query", name, queryParams[name]))
+	}
+	return exprs
+}
+
+// makePath returns the canary service URI prefix in the APISIX wildcard format
+func (ar *ApisixRouter) makePath(canary *flaggerv1.Canary) string {
+	if len(canary.Spec.Service.Match) > 0 && canary.Spec.Service.Match[0].Uri != nil {
+		uri := canary.Spec.Service.Match[0].Uri
+		switch {
+		case uri.Exact != "":
+			return uri.Exact
+		case uri.Prefix != "":
+			return strings.TrimSuffix(uri.Prefix, "/") + "/*"
+		}
+	}
+	return "/*"
+}
+
+// getWeights reads the primary and canary weights from the rule that routes to canary,
+// APISIX defaults the backend weight to 100
+func (ar *ApisixRouter) getWeights(canary *flaggerv1.Canary, route *unstructured.Unstructured) (primaryWeight int, canaryWeight int) {
+	_, primaryName, canaryName := canary.GetServiceNames()
+
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "http")
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		canaryWeight, ok = apisixBackendWeight(rule, canaryName)
+		if !ok {
+			continue
+		}
+		primaryWeight, _ = apisixBackendWeight(rule, primaryName)
+		return
+	}
+	return 0, 0
+}
+
+// setRules replaces the rules of the route while keeping the fields
+// not managed by Flagger, such as plugins, of the existing rules with the same name,
+// the A/B testing rules get the fields not managed by Flagger from the apex rule
+func (ar *ApisixRouter) setRules(route *unstructured.Unstructured, rules []apisixRule) error {
+	apexName := route.GetName()
+	existing := make(map[string]map[string]interface{})
+	current, _, _ := unstructured.NestedSlice(route.Object, "spec", "http")
+	for _, r := range current {
+		if rule, ok := r.(map[string]interface{}); ok {
+			if name, ok := rule["name"].(string); ok {
+				existing[name] = rule
+			}
+		}
+	}
+
+	var http []interface{}
+	for _, rule := range rules {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&rule)
+		if err != nil {
+			return err
+		}
+
+		merged, ok := existing[rule.Name]
+		if apex, found := existing[apexName]; found && strings.HasPrefix(rule.Name, apexName+"-canary-") {
+			merged, ok = runtime.DeepCopyJSON(apex), true
+			merged["name"] = rule.Name
+		}
+		if !ok {
+			http = append(http, obj)
+			continue
+		}
+		merged = runtime.DeepCopyJSON(merged)
+
+		// keep the user defined match fields such as methods
+		match, _ := merged["match"].(map[string]interface{})
+		if match == nil {
+			match = make(map[string]interface{})
+		}
+		delete(match, "hosts")
+		delete(match, "exprs")
+		for k, v := range obj["match"].(map[string]interface{}) {
+			match[k] = v
+		}
+		merged["match"] = match
+		merged["backends"] = obj["backends"]
+		delete(merged, "priority")
+		if p, ok := obj["priority"]; ok {
+			merged["priority"] = p
+		}
+		http = append(http, merged)
+	}
+
+	return unstructured.SetNestedSlice(route.Object, http, "spec", "http")
+}
+
+// apisixBackendWeight returns the weight of a rule backend and false if the backend is missing
+func apisixBackendWeight(rule map[string]interface{}, serviceName string) (int, bool) {
+	backends, _, _ := unstructured.NestedSlice(rule, "backends")
+	for _, b := range backends {
+		backend, ok := b.(map[string]interface{})
+		if !ok || backend["serviceName"] != serviceName {
+			continue
+		}
+		weight, found, _ := unstructured.NestedInt64(backend, "weight")
+		if !found {
+			return 100, true
+		}
+		return int(weight), true
+	}
+	return 0, false
+}
+
+// apisixStringExpr converts an Istio string match to an APISIX expression
+func apisixStringExpr(scope string, name string, m istiov1alpha1.StringMatch) apisixExpr {
+	expr := apisixExpr{
+		Subject: apisixExprSubject{Scope: scope, Name: name},
+		Op:      "Equal",
+		Value:   m.Exact,
+	}
+	switch {
+	case m.Prefix != "":
+		expr.Op, expr.Value = "RegexMatch", "^"+regexp.QuoteMeta(m.Prefix)
+	case m.Suffix != "":
+		expr.Op, expr.Value = "RegexMatch", regexp.QuoteMeta(m.Suffix)+"$"
+	case m.Regex != "":
+		expr.Op, expr.Value = "RegexMatch", m.Regex
+	}
+	return expr
+}
@@ -0,0 +1,233 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+
+	istiov1alpha1 "github.com/weaveworks/flagger/pkg/apis/istio/common/v1alpha1"
+	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
+)
+
+func newApisixRouter(mocks fixture, objects ...runtime.Object) *ApisixRouter {
+	return &ApisixRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		kubeClient:    mocks.kubeClient,
+		apisixClient:  fakeDynamic.NewSimpleDynamicClient(runtime.NewScheme(), objects...),
+	}
+}
+
+func getApisixRules(t *testing.T, router *ApisixRouter, name string) []map[string]interface{} {
+	route, err := router.apisixClient.Resource(apisixRouteGVR).Namespace("default").Get(name, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	http, _, err := unstructured.NestedSlice(route.Object, "spec", "http")
+	require.NoError(t, err)
+	var rules []map[string]interface{}
+	for _, r := range http {
+		rules = append(rules, r.(map[string]interface{}))
+	}
+	return rules
+}
+
+func TestApisixRouter_Reconcile(t *testing.T) {
+	mocks := newFixture(nil)
+	router := newApisixRouter(mocks)
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	// test insert
+	rules := getApisixRules(t, router, "podinfo")
+	require.Len(t, rules, 1)
+	assert.Equal(t, "podinfo", rules[0]["name"])
+
+	paths, _, _ := unstructured.NestedStringSlice(rules[0], "match", "paths")
+	assert.Equal(t, []string{"/podinfo/*"}, paths)
+
+	backends, _, _ := unstructured.NestedSlice(rules[0], "backends")
+	require.Len(t, backends, 2)
+	assert.Equal(t, "podinfo-primary", backends[0].(map[string]interface{})["serviceName"])
+	assert.Equal(t, int64(9898), backends[0].(map[string]interface{})["servicePort"])
+	assert.Equal(t, int64(100), backends[0].(map[string]interface{})["weight"])
+	assert.Equal(t, "podinfo-canary", backends[1].(map[string]interface{})["serviceName"])
+	assert.Equal(t, int64(0), backends[1].(map[string]interface{})["weight"])
+
+	// test update keeps the weights
+	err = router.SetRoutes(mocks.canary, 70, 30, false)
+	require.NoError(t, err)
+
+	cd := mocks.canary.DeepCopy()
+	cd.Spec.Service.Hosts = []string{"app.example.com"}
+	err = router.Reconcile(cd)
+	require.NoError(t, err)
+
+	rules = getApisixRules(t, router, "podinfo")
+	hosts, _, _ := unstructured.NestedStringSlice(rules[0], "match", "hosts")
+	assert.Equal(t, []string{"app.example.com"}, hosts)
+
+	p, c, _, err := router.GetRoutes(cd)
+	require.NoError(t, err)
+	assert.Equal(t, 70, p)
+	assert.Equal(t, 30, c)
+}
+
+func TestApisixRouter_Routes(t *testing.T) {
+	mocks := newFixture(nil)
+	router := newApisixRouter(mocks)
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	p, c, m, err := router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 100, p)
+	assert.Equal(t, 0, c)
+	assert.False(t, m)
+
+	err = router.SetRoutes(mocks.canary, 50, 50, false)
+	require.NoError(t, err)
+
+	p, c, _, err = router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 50, p)
+	assert.Equal(t, 50, c)
+
+	err = router.SetRoutes(mocks.canary, 0, 0, false)
+	require.Error(t, err)
+}
+
+func TestApisixRouter_ABTest(t *testing.T) {
+	mocks := newFixture(nil)
+	router := newApisixRouter(mocks)
+
+	canary := mocks.abtest.DeepCopy()
+	canary.Spec.Analysis.Match = []istiov1alpha3.HTTPMatchRequest{
+		{
+			Headers: map[string]istiov1alpha1.StringMatch{
+				"x-user-type": {Exact: "test"},
+				"user-agent":  {Prefix: "Mozilla"},
+			},
+		},
+		{
+			Headers: map[string]istiov1alpha1.StringMatch{
+				"cookie": {Exact: "canary"},
+			},
+		},
+	}
+
+	err := router.Reconcile(canary)
+	require.NoError(t, err)
+
+	rules := getApisixRules(t, router, "abtest")
+	require.Len(t, rules, 3)
+	assert.Equal(t, "abtest-canary-0", rules[0]["name"])
+	assert.Equal(t, int64(1), rules[0]["priority"])
+
+	exprs, _, _ := unstructured.NestedSlice(rules[0], "match", "exprs")
+	require.Len(t, exprs, 2)
+	assert.Equal(t, map[string]interface{}{
+		"subject": map[string]interface{}{"scope": "Header", "name": "user-agent"},
+		"op":      "RegexMatch",
+		"value":   "^Mozilla",
+	}, exprs[0])
+	assert.Equal(t, map[string]interface{}{
+		"subject": map[string]interface{}{"scope": "Header", "name": "x-user-type"},
+		"op":      "Equal",
+		"value":   "test",
+	}, exprs[1])
+
+	exprs, _, _ = unstructured.NestedSlice(rules[1], "match", "exprs")
+	require.Len(t, exprs, 1)
+	assert.Equal(t, map[string]interface{}{
+		"subject": map[string]interface{}{"scope": "Cookie", "name": "canary"},
+		"op":      "Equal",
+		"value":   "always",
+	}, exprs[0])
+
+	// the default rule routes all traffic to primary
+	backends, _, _ := unstructured.NestedSlice(rules[2], "backends")
+	require.Len(t, backends, 1)
+	assert.Equal(t, "abtest-primary", backends[0].(map[string]interface{})["serviceName"])
+
+	err = router.SetRoutes(canary, 0, 100, false)
+	require.NoError(t, err)
+
+	p, c, _, err := router.GetRoutes(canary)
+	require.NoError(t, err)
+	assert.Equal(t, 0, p)
+	assert.Equal(t, 100, c)
+}
+
+func TestApisixRouter_Plugins(t *testing.T) {
+	mocks := newFixture(nil)
+	router := newApisixRouter(mocks)
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	// add a plugin and a method match to the route
+	route, err := router.apisixClient.Resource(apisixRouteGVR).Namespace("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	http, _, _ := unstructured.NestedSlice(route.Object, "spec", "http")
+	rule := http[0].(map[string]interface{})
+	rule["plugins"] = []interface{}{
+		map[string]interface{}{"name": "limit-count", "enable": true},
+	}
+	require.NoError(t, unstructured.SetNestedStringSlice(rule, []string{"GET"}, "match", "methods"))
+	require.NoError(t, unstructured.SetNestedSlice(route.Object, http, "spec", "http"))
+	_, err = router.apisixClient.Resource(apisixRouteGVR).Namespace("default").Update(route, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	err = router.SetRoutes(mocks.canary, 60, 40, false)
+	require.NoError(t, err)
+	err = router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	rules := getApisixRules(t, router, "podinfo")
+	require.Len(t, rules, 1)
+	plugins, _, _ := unstructured.NestedSlice(rules[0], "plugins")
+	require.Len(t, plugins, 1)
+	assert.Equal(t, "limit-count", plugins[0].(map[string]interface{})["name"])
+	methods, _, _ := unstructured.NestedStringSlice(rules[0], "match", "methods")
+	assert.Equal(t, []string{"GET"}, methods)
+
+	p, c, _, err := router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 60, p)
+	assert.Equal(t, 40, c)
+
+	// the A/B testing rules get the plugins and the method match of the apex rule
+	canary := mocks.canary.DeepCopy()
+	canary.Spec.Analysis.Match = []istiov1alpha3.HTTPMatchRequest{
+		{
+			Headers: map[string]istiov1alpha1.StringMatch{
+				"x-user-type": {Exact: "test"},
+			},
+		},
+	}
+	err = router.Reconcile(canary)
+	require.NoError(t, err)
+
+	rules = getApisixRules(t, router, "podinfo")
+	require.Len(t, rules, 2)
+	assert.Equal(t, "podinfo-canary-0", rules[0]["name"])
+	assert.Equal(t, "podinfo", rules[1]["name"])
+	for _, rule := range rules {
+		plugins, _, _ := unstructured.NestedSlice(rule, "plugins")
+		require.Len(t, plugins, 1)
+		assert.Equal(t, "limit-count", plugins[0].(map[string]interface{})["name"])
+		methods, _, _ := unstructured.NestedStringSlice(rule, "match", "methods")
+		assert.Equal(t, []string{"GET"}, methods)
+	}
+	exprs, _, _ := unstructured.NestedSlice(rules[0], "match", "exprs")
+	assert.Len(t, exprs, 1)
+	_, found, _ := unstructured.NestedSlice(rules[1], "match", "exprs")
+	assert.False(t, found)
+}
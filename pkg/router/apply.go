@@ -0,0 +1,203 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+)
+
+// FieldManager is the name Flagger uses to apply the routing objects server-side
+const FieldManager = "flagger"
+
+// DriftError is returned when the fields of a routing object managed by Flagger
+// have been changed by another field manager
+type DriftError struct {
+	Kind      string
+	Name      string
+	Namespace string
+	// Conflicts contains the changed fields and their manager
+	Conflicts []string
+	// Reasserted is true when the Flagger fields have been applied again
+	Reasserted bool
+}
+
+func (e *DriftError) Error() string {
+	action := "halting until the changes are reverted"
+	if e.Reasserted {
+		action = "fields reasserted"
+	}
+	return fmt.Sprintf("%s %s.%s drift detected, %s: %s",
+		e.Kind, e.Name, e.Namespace, action, strings.Join(e.Conflicts, ", "))
+}
+
+// IsReasserted returns true if the error is a drift that has been overwritten
+func IsReasserted(err error) bool {
+	var drift *DriftError
+	return errors.As(err, &drift) && drift.Reasserted
+}
+
+// serverSideApplier applies the routing objects with the Flagger field manager
+// and detects the changes made by others to the fields it owns
+type serverSideApplier struct {
+	client dynamic.Interface
+	logger *zap.SugaredLogger
+}
+
+// apply patches the object with the fields generated by Flagger,
+// the live object is nil if it doesn't exist.
+// Objects that are not yet managed with server-side apply are adopted by forcing the ownership
+// of the fields previously set with updates.
+func (sa *serverSideApplier) apply(canary *flaggerv1.Canary, gvr schema.GroupVersionResource,
+	obj runtime.Object, live metav1.Object, force bool) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return fmt.Errorf("apply failed: %w", err)
+	}
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	name, namespace := accessor.GetName(), accessor.GetNamespace()
+
+	data, err := makeApplyPatch(obj)
+	if err != nil {
+		return fmt.Errorf("%s %s.%s marshal error: %w", kind, name, namespace, err)
+	}
+
+	if live == nil || !isAppliedByFlagger(live) {
+		force = true
+	}
+
+	err = sa.patch(gvr, name, namespace, data, force)
+	if err == nil {
+		if live == nil {
+			sa.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+				Infof("%s %s.%s created", kind, name, namespace)
+		}
+		return nil
+	}
+	if !apierrors.IsConflict(err) {
+		return fmt.Errorf("%s %s.%s apply error: %w", kind, name, namespace, err)
+	}
+
+	drift := &DriftError{
+		Kind:      kind,
+		Name:      name,
+		Namespace: namespace,
+		Conflicts: conflictsOf(err),
+	}
+	if canary.Spec.DriftPolicy == flaggerv1.DriftPolicyReport {
+		return drift
+	}
+
+	if err := sa.patch(gvr, name, namespace, data, true); err != nil {
+		return fmt.Errorf("%s %s.%s apply error: %w", kind, name, namespace, err)
+	}
+	drift.Reasserted = true
+	return drift
+}
+
+func (sa *serverSideApplier) patch(gvr schema.GroupVersionResource, name, namespace string, data []byte, force bool) error {
+	_, err := sa.client.Resource(gvr).Namespace(namespace).Patch(name, types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	})
+	return err
+}
+
+// makeApplyObjectMeta returns the metadata of an applied object,
+// the objects created by others are not controlled by the canary
+func makeApplyObjectMeta(canary *flaggerv1.Canary, name string, live metav1.Object) metav1.ObjectMeta {
+	objectMeta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: canary.Namespace,
+	}
+	if live == nil || metav1.IsControlledBy(live, canary) {
+		objectMeta.OwnerReferences = []metav1.OwnerReference{
+			*metav1.NewControllerRef(canary, schema.GroupVersionKind{
+				Group:   flaggerv1.SchemeGroupVersion.Group,
+				Version: flaggerv1.SchemeGroupVersion.Version,
+				Kind:    flaggerv1.CanaryKind,
+			}),
+		}
+	}
+	return objectMeta
+}
+
+// statusWeights returns the weights Flagger has set according to the canary status, the routing objects
+// are reconciled with these weights so that the changes made by others to the live weights are detected
+func statusWeights(canary *flaggerv1.Canary) (primaryWeight int, canaryWeight int, mirrored bool) {
+	switch canary.Status.Phase {
+	case flaggerv1.CanaryPhaseProgressing, flaggerv1.CanaryPhaseWaiting, flaggerv1.CanaryPhasePromoting:
+	default:
+		return 100, 0, false
+	}
+
+	analysis := canary.GetAnalysis()
+	if analysis == nil || analysis.Iterations < 1 {
+		return 100 - canary.Status.CanaryWeight, canary.Status.CanaryWeight, false
+	}
+
+	switch {
+	case len(analysis.Match) > 0 && canary.Status.Iterations > 0:
+		// A/B testing routes the matching requests to canary from the first iteration
+		return 0, 100, false
+	case canary.Status.Iterations > analysis.Iterations:
+		// blue/green routes all traffic to canary after the last iteration
+		return 0, 100, false
+	case analysis.Mirror && canary.Status.Iterations > 0:
+		return 100, 0, true
+	}
+	return 100, 0, false
+}
+
+// makeApplyPatch returns the object JSON without the status and the fields set by the API server
+func makeApplyPatch(obj runtime.Object) ([]byte, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var content map[string]interface{}
+	if err := json.Unmarshal(b, &content); err != nil {
+		return nil, err
+	}
+	delete(content, "status")
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+	return json.Marshal(content)
+}
+
+// isAppliedByFlagger returns true if Flagger owns fields of the object with server-side apply
+func isAppliedByFlagger(obj metav1.Object) bool {
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager == FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply {
+			return true
+		}
+	}
+	return false
+}
+
+// conflictsOf returns the conflicting fields of an apply error
+func conflictsOf(err error) []string {
+	var conflicts []string
+	if status, ok := err.(apierrors.APIStatus); ok && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			conflicts = append(conflicts, fmt.Sprintf("%s %s", cause.Field, cause.Message))
+		}
+	}
+	if len(conflicts) == 0 {
+		conflicts = append(conflicts, err.Error())
+	}
+	return conflicts
+}
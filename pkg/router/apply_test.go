@@ -0,0 +1,329 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeDiscovery "k8s.io/client-go/discovery/fake"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+	k8sTesting "k8s.io/client-go/testing"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
+	contourv1 "github.com/weaveworks/flagger/pkg/apis/projectcontour/v1"
+	smiv1alpha1 "github.com/weaveworks/flagger/pkg/apis/smi/v1alpha1"
+	smiv1alpha2 "github.com/weaveworks/flagger/pkg/apis/smi/v1alpha2"
+)
+
+// applyRecorder records the apply patches and returns a field manager conflict for the first conflicts patches
+type applyRecorder struct {
+	patches   []map[string]interface{}
+	conflicts int
+}
+
+func newApplier(mocks fixture, recorder *applyRecorder) *serverSideApplier {
+	client := fakeDynamic.NewSimpleDynamicClient(runtime.NewScheme())
+	client.PrependReactor("patch", "*", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8sTesting.PatchAction)
+		if len(recorder.patches) < recorder.conflicts {
+			recorder.patches = append(recorder.patches, nil)
+			return true, nil, apierrors.NewApplyConflict([]metav1.StatusCause{
+				{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Message: `conflict with "kubectl-edit"`,
+					Field:   ".spec.http",
+				},
+			}, "Apply failed with 1 conflict")
+		}
+
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal(patch.GetPatch(), &obj); err != nil {
+			return true, nil, err
+		}
+		recorder.patches = append(recorder.patches, obj)
+		return true, &unstructured.Unstructured{Object: obj}, nil
+	})
+
+	return &serverSideApplier{
+		client: client,
+		logger: mocks.logger,
+	}
+}
+
+func newAppliedVirtualService(mocks fixture) *istiov1alpha3.VirtualService {
+	return &istiov1alpha3.VirtualService{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: istiov1alpha3.SchemeGroupVersion.String(),
+			Kind:       "VirtualService",
+		},
+		ObjectMeta: makeApplyObjectMeta(mocks.canary, "podinfo", nil),
+		Spec:       makeVirtualServiceSpec(mocks.canary, 100, 0, false),
+	}
+}
+
+func TestServerSideApplier_Apply(t *testing.T) {
+	mocks := newFixture(nil)
+	recorder := &applyRecorder{}
+	applier := newApplier(mocks, recorder)
+
+	err := applier.apply(mocks.canary, istioVirtualServiceGVR, newAppliedVirtualService(mocks), nil, false)
+	require.NoError(t, err)
+	require.Len(t, recorder.patches, 1)
+
+	patch := recorder.patches[0]
+	assert.Equal(t, "VirtualService", patch["kind"])
+	assert.NotContains(t, patch, "status")
+	assert.NotContains(t, patch["metadata"], "creationTimestamp")
+	owners, _, _ := unstructured.NestedSlice(patch, "metadata", "ownerReferences")
+	assert.Len(t, owners, 1)
+}
+
+func TestServerSideApplier_Drift(t *testing.T) {
+	mocks := newFixture(nil)
+	live := &metav1.ObjectMeta{
+		Name:      "podinfo",
+		Namespace: "default",
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			{Manager: FieldManager, Operation: metav1.ManagedFieldsOperationApply},
+			{Manager: "kubectl-edit", Operation: metav1.ManagedFieldsOperationUpdate},
+		},
+	}
+
+	// the Flagger fields are applied again by default
+	recorder := &applyRecorder{conflicts: 1}
+	applier := newApplier(mocks, recorder)
+
+	err := applier.apply(mocks.canary, istioVirtualServiceGVR, newAppliedVirtualService(mocks), live, false)
+	require.Error(t, err)
+	assert.True(t, IsReasserted(err))
+	assert.Contains(t, err.Error(), ".spec.http")
+	assert.Len(t, recorder.patches, 2)
+
+	// the drift is only reported with the Report policy
+	mocks.canary.Spec.DriftPolicy = flaggerv1.DriftPolicyReport
+	recorder = &applyRecorder{conflicts: 1}
+	applier = newApplier(mocks, recorder)
+
+	err = applier.apply(mocks.canary, istioVirtualServiceGVR, newAppliedVirtualService(mocks), live, false)
+	var drift *DriftError
+	require.True(t, errors.As(err, &drift))
+	assert.False(t, drift.Reasserted)
+	assert.Equal(t, "VirtualService", drift.Kind)
+	assert.Len(t, recorder.patches, 1)
+}
+
+func TestIstioRouter_ServerSideApply(t *testing.T) {
+	mocks := newFixture(nil)
+	router := &IstioRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		istioClient:   mocks.meshClient,
+		kubeClient:    mocks.kubeClient,
+	}
+
+	// a virtual service created before server-side apply was enabled
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+	err = router.SetRoutes(mocks.canary, 60, 40, false)
+	require.NoError(t, err)
+
+	recorder := &applyRecorder{}
+	router.applier = newApplier(mocks, recorder)
+
+	// the weights recorded in the canary status are applied on reconciliation
+	mocks.canary.Status.Phase = flaggerv1.CanaryPhaseProgressing
+	mocks.canary.Status.CanaryWeight = 20
+	err = router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+	require.Len(t, recorder.patches, 3)
+
+	vs := &istiov1alpha3.VirtualService{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(recorder.patches[2], vs)
+	require.NoError(t, err)
+	assert.Equal(t, "podinfo", vs.Name)
+	assert.Len(t, vs.OwnerReferences, 1)
+	require.Len(t, vs.Spec.Http, 1)
+	assert.Equal(t, 80, vs.Spec.Http[0].Route[0].Weight)
+	assert.Equal(t, 20, vs.Spec.Http[0].Route[1].Weight)
+
+	// the weights are applied
+	err = router.SetRoutes(mocks.canary, 10, 90, true)
+	require.NoError(t, err)
+	require.Len(t, recorder.patches, 4)
+
+	vs = &istiov1alpha3.VirtualService{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(recorder.patches[3], vs)
+	require.NoError(t, err)
+	assert.Equal(t, 10, vs.Spec.Http[0].Route[0].Weight)
+	assert.Equal(t, 90, vs.Spec.Http[0].Route[1].Weight)
+	assert.Equal(t, "podinfo-canary", vs.Spec.Http[0].Mirror.Host)
+}
+
+func TestContourRouter_ServerSideApply(t *testing.T) {
+	mocks := newFixture(nil)
+	recorder := &applyRecorder{}
+	router := &ContourRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		contourClient: mocks.meshClient,
+		kubeClient:    mocks.kubeClient,
+		applier:       newApplier(mocks, recorder),
+	}
+
+	// the weights recorded in the canary status are applied on reconciliation
+	mocks.canary.Status.Phase = flaggerv1.CanaryPhaseProgressing
+	mocks.canary.Status.CanaryWeight = 30
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+	require.Len(t, recorder.patches, 1)
+
+	proxy := &contourv1.HTTPProxy{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(recorder.patches[0], proxy)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTPProxy", proxy.Kind)
+	assert.Len(t, proxy.OwnerReferences, 1)
+	services := proxy.Spec.Routes[0].Services
+	require.Len(t, services, 2)
+	assert.Equal(t, uint32(70), services[0].Weight)
+	assert.Equal(t, uint32(30), services[1].Weight)
+
+	// the changes made by others to the weights are reported
+	mocks.canary.Spec.DriftPolicy = flaggerv1.DriftPolicyReport
+	recorder.conflicts = 2
+	err = router.Reconcile(mocks.canary)
+	var drift *DriftError
+	require.True(t, errors.As(err, &drift))
+	assert.Equal(t, "HTTPProxy", drift.Kind)
+	assert.False(t, drift.Reasserted)
+}
+
+func TestSmiRouter_ServerSideApply(t *testing.T) {
+	canary := newTestSMICanary()
+	mocks := newFixture(canary)
+	recorder := &applyRecorder{}
+	router := &SmiRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		smiClient:     mocks.meshClient,
+		kubeClient:    mocks.kubeClient,
+		applier:       newApplier(mocks, recorder),
+		splitGVR:      smiTrafficSplitGVR,
+	}
+
+	// the primary receives all the traffic outside of the analysis
+	canary.Status.Phase = flaggerv1.CanaryPhaseSucceeded
+	canary.Status.CanaryWeight = 50
+	err := router.Reconcile(canary)
+	require.NoError(t, err)
+	require.Len(t, recorder.patches, 1)
+
+	ts := &smiv1alpha1.TrafficSplit{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(recorder.patches[0], ts)
+	require.NoError(t, err)
+	assert.Equal(t, smiv1alpha1.SchemeGroupVersion.String(), ts.APIVersion)
+	require.Len(t, ts.Spec.Backends, 2)
+	assert.Equal(t, int64(0), ts.Spec.Backends[0].Weight.Value())
+	assert.Equal(t, int64(100), ts.Spec.Backends[1].Weight.Value())
+
+	// the traffic split is applied with the version served by the cluster
+	router.splitGVR = smiV1alpha2TrafficSplitGVR
+	err = router.SetRoutes(canary, 60, 40, false)
+	require.NoError(t, err)
+	require.Len(t, recorder.patches, 2)
+
+	tsv2 := &smiv1alpha2.TrafficSplit{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(recorder.patches[1], tsv2)
+	require.NoError(t, err)
+	assert.Equal(t, smiv1alpha2.SchemeGroupVersion.String(), tsv2.APIVersion)
+	require.Len(t, tsv2.Spec.Backends, 2)
+	assert.Equal(t, 40, tsv2.Spec.Backends[0].Weight)
+	assert.Equal(t, 60, tsv2.Spec.Backends[1].Weight)
+}
+
+func TestFactory_TrafficSplitGVR(t *testing.T) {
+	mocks := newFixture(nil)
+	mocks.meshClient.Discovery().(*fakeDiscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: smiV1alpha2TrafficSplitGVR.GroupVersion().String(),
+			APIResources: []metav1.APIResource{{Name: "trafficsplits"}},
+		},
+	}
+
+	factory := NewFactory(nil, mocks.kubeClient, mocks.flaggerClient, "", mocks.logger, mocks.meshClient, nil, nil)
+	assert.Equal(t, smiTrafficSplitGVR, factory.trafficSplitGVR())
+
+	factory = NewFactory(nil, mocks.kubeClient, mocks.flaggerClient, "", mocks.logger, mocks.meshClient, nil,
+		fakeDynamic.NewSimpleDynamicClient(runtime.NewScheme()))
+	assert.Equal(t, smiV1alpha2TrafficSplitGVR, factory.trafficSplitGVR())
+}
+
+func TestStatusWeights(t *testing.T) {
+	mocks := newFixture(nil)
+	cd := mocks.canary.DeepCopy()
+	cd.Status.CanaryWeight = 30
+
+	tests := []struct {
+		name     string
+		phase    flaggerv1.CanaryPhase
+		mutate   func(analysis *flaggerv1.CanaryAnalysis, status *flaggerv1.CanaryStatus)
+		primary  int
+		canary   int
+		mirrored bool
+	}{
+		{name: "initialized", phase: flaggerv1.CanaryPhaseInitialized, primary: 100},
+		{name: "failed", phase: flaggerv1.CanaryPhaseFailed, primary: 100},
+		{name: "progressing", phase: flaggerv1.CanaryPhaseProgressing, primary: 70, canary: 30},
+		{name: "finalising", phase: flaggerv1.CanaryPhaseFinalising, primary: 100},
+		{
+			name:  "A/B testing",
+			phase: flaggerv1.CanaryPhaseProgressing,
+			mutate: func(analysis *flaggerv1.CanaryAnalysis, status *flaggerv1.CanaryStatus) {
+				analysis.Iterations = 10
+				analysis.Match = []istiov1alpha3.HTTPMatchRequest{{}}
+				status.Iterations = 1
+			},
+			canary: 100,
+		},
+		{
+			name:  "blue/green mirroring",
+			phase: flaggerv1.CanaryPhaseProgressing,
+			mutate: func(analysis *flaggerv1.CanaryAnalysis, status *flaggerv1.CanaryStatus) {
+				analysis.Iterations = 10
+				analysis.Mirror = true
+				status.Iterations = 5
+			},
+			primary:  100,
+			mirrored: true,
+		},
+		{
+			name:  "blue/green promotion",
+			phase: flaggerv1.CanaryPhasePromoting,
+			mutate: func(analysis *flaggerv1.CanaryAnalysis, status *flaggerv1.CanaryStatus) {
+				analysis.Iterations = 10
+				status.Iterations = 11
+			},
+			canary: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cd.DeepCopy()
+			c.Status.Phase = tt.phase
+			if tt.mutate != nil {
+				tt.mutate(c.GetAnalysis(), &c.Status)
+			}
+			p, w, m := statusWeights(c)
+			assert.Equal(t, tt.primary, p)
+			assert.Equal(t, tt.canary, w)
+			assert.Equal(t, tt.mirrored, m)
+		})
+	}
+}
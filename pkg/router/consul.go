@@ -0,0 +1,467 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	istiov1alpha1 "github.com/weaveworks/flagger/pkg/apis/istio/common/v1alpha1"
+	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
+)
+
+const (
+	consulServiceResolverKind = "service-resolver"
+	consulServiceSplitterKind = "service-splitter"
+	consulServiceRouterKind   = "service-router"
+
+	consulPrimarySubset = "primary"
+	consulCanarySubset  = "canary"
+)
+
+// consulServiceResolver groups the instances of the apex service in primary and canary subsets
+type consulServiceResolver struct {
+	Kind          string                         `json:"Kind"`
+	Name          string                         `json:"Name"`
+	DefaultSubset string                         `json:"DefaultSubset,omitempty"`
+	Subsets       map[string]consulServiceSubset `json:"Subsets,omitempty"`
+}
+
+type consulServiceSubset struct {
+	Filter      string `json:"Filter,omitempty"`
+	OnlyPassing bool   `json:"OnlyPassing,omitempty"`
+}
+
+// consulServiceSplitter splits the traffic of the apex service between the primary and canary subsets
+type consulServiceSplitter struct {
+	Kind   string               `json:"Kind"`
+	Name   string               `json:"Name"`
+	Splits []consulServiceSplit `json:"Splits"`
+}
+
+type consulServiceSplit struct {
+	Weight        float32 `json:"Weight"`
+	ServiceSubset string  `json:"ServiceSubset,omitempty"`
+}
+
+// consulServiceRouter routes the requests matching the A/B testing conditions to a subset
+type consulServiceRouter struct {
+	Kind   string               `json:"Kind"`
+	Name   string               `json:"Name"`
+	Routes []consulServiceRoute `json:"Routes"`
+}
+
+type consulServiceRoute struct {
+	Match       *consulServiceRouteMatch       `json:"Match,omitempty"`
+	Destination *consulServiceRouteDestination `json:"Destination,omitempty"`
+}
+
+type consulServiceRouteMatch struct {
+	HTTP *consulServiceRouteHTTPMatch `json:"HTTP,omitempty"`
+}
+
+type consulServiceRouteHTTPMatch struct {
+	PathExact  string                        `json:"PathExact,omitempty"`
+	PathPrefix string                        `json:"PathPrefix,omitempty"`
+	PathRegex  string                        `json:"PathRegex,omitempty"`
+	Header     []consulServiceRouteHTTPParam `json:"Header,omitempty"`
+	QueryParam []consulServiceRouteHTTPParam `json:"QueryParam,omitempty"`
+}
+
+type consulServiceRouteHTTPParam struct {
+	Name   string `json:"Name"`
+	Exact  string `json:"Exact,omitempty"`
+	Prefix string `json:"Prefix,omitempty"`
+	Suffix string `json:"Suffix,omitempty"`
+	Regex  string `json:"Regex,omitempty"`
+}
+
+type consulServiceRouteDestination struct {
+	ServiceSubset string `json:"ServiceSubset,omitempty"`
+}
+
+// consulClient writes the config entries with the Consul HTTP API
+type consulClient struct {
+	address    string
+	token      string
+	httpClient *http.Client
+}
+
+// newConsulClient returns a Consul client configured with
+// the CONSUL_HTTP_ADDR and CONSUL_HTTP_TOKEN environment variables
+func newConsulClient() *consulClient {
+	address := os.Getenv("CONSUL_HTTP_ADDR")
+	if address == "" {
+		address = "127.0.0.1:8500"
+	}
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		address = "http://" + address
+	}
+
+	return &consulClient{
+		address:    strings.TrimSuffix(address, "/"),
+		token:      os.Getenv("CONSUL_HTTP_TOKEN"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// getConfigEntry reads the config entry into out, returns false if it doesn't exist
+func (cc *consulClient) getConfigEntry(kind string, name string, out interface{}) (bool, error) {
+	body, status, err := cc.do(http.MethodGet, fmt.Sprintf("/v1/config/%s/%s", kind, name), nil)
+	if err != nil {
+		return false, err
+	}
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	if status != http.StatusOK {
+		return false, fmt.Errorf("status %d: %s", status, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return false, fmt.Errorf("unmarshal error: %w", err)
+	}
+	return true, nil
+}
+
+// setConfigEntry creates or updates the config entry
+func (cc *consulClient) setConfigEntry(entry interface{}) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal error: %w", err)
+	}
+
+	body, status, err := cc.do(http.MethodPut, "/v1/config", data)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("status %d: %s", status, string(body))
+	}
+	if strings.TrimSpace(string(body)) != "true" {
+		return fmt.Errorf("config entry not written: %s", string(body))
+	}
+	return nil
+}
+
+// deleteConfigEntry removes the config entry, deleting a missing entry is not an error
+func (cc *consulClient) deleteConfigEntry(kind string, name string) error {
+	body, status, err := cc.do(http.MethodDelete, fmt.Sprintf("/v1/config/%s/%s", kind, name), nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusNotFound {
+		return fmt.Errorf("status %d: %s", status, string(body))
+	}
+	return nil
+}
+
+func (cc *consulClient) do(method string, path string, data []byte) ([]byte, int, error) {
+	req, err := http.NewRequest(method, cc.address+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cc.token != "" {
+		req.Header.Set("X-Consul-Token", cc.token)
+	}
+
+	resp, err := cc.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading response body failed: %w", err)
+	}
+	return body, resp.StatusCode, nil
+}
+
+// ConsulRouter is managing the Consul service resolver, splitter and router config entries
+type ConsulRouter struct {
+	kubeClient    kubernetes.Interface
+	flaggerClient clientset.Interface
+	consulClient  *consulClient
+	logger        *zap.SugaredLogger
+}
+
+// Reconcile creates or updates the Consul config entries of the apex service,
+// the weights of an existing service splitter are kept
+func (cr *ConsulRouter) Reconcile(canary *flaggerv1.Canary) error {
+	apexName, _, _ := canary.GetServiceNames()
+
+	if err := cr.reconcileServiceResolver(canary); err != nil {
+		return err
+	}
+
+	splitter := &consulServiceSplitter{}
+	found, err := cr.consulClient.getConfigEntry(consulServiceSplitterKind, apexName, splitter)
+	if err != nil {
+		return fmt.Errorf("ServiceSplitter %s get query error: %w", apexName, err)
+	}
+	if !found {
+		if err := cr.consulClient.setConfigEntry(cr.makeServiceSplitter(canary, 100, 0)); err != nil {
+			return fmt.Errorf("ServiceSplitter %s create error: %w", apexName, err)
+		}
+		cr.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+			Infof("ServiceSplitter %s created", apexName)
+	}
+
+	return cr.reconcileServiceRouter(canary)
+}
+
+func (cr *ConsulRouter) reconcileServiceResolver(canary *flaggerv1.Canary) error {
+	apexName, _, _ := canary.GetServiceNames()
+	newResolver := cr.makeServiceResolver(canary)
+
+	resolver := &consulServiceResolver{}
+	found, err := cr.consulClient.getConfigEntry(consulServiceResolverKind, apexName, resolver)
+	if err != nil {
+		return fmt.Errorf("ServiceResolver %s get query error: %w", apexName, err)
+	}
+	if found && cmp.Diff(newResolver, resolver) == "" {
+		return nil
+	}
+
+	if err := cr.consulClient.setConfigEntry(newResolver); err != nil {
+		return fmt.Errorf("ServiceResolver %s update error: %w", apexName, err)
+	}
+	cr.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+		Infof("ServiceResolver %s updated", apexName)
+	return nil
+}
+
+// reconcileServiceRouter creates the service router for A/B testing and removes it otherwise,
+// the subset of the matched requests is kept
+func (cr *ConsulRouter) reconcileServiceRouter(canary *flaggerv1.Canary) error {
+	apexName, _, _ := canary.GetServiceNames()
+
+	router := &consulServiceRouter{}
+	found, err := cr.consulClient.getConfigEntry(consulServiceRouterKind, apexName, router)
+	if err != nil {
+		return fmt.Errorf("ServiceRouter %s get query error: %w", apexName, err)
+	}
+
+	if len(canary.GetAnalysis().Match) == 0 {
+		if found {
+			if err := cr.consulClient.deleteConfigEntry(consulServiceRouterKind, apexName); err != nil {
+				return fmt.Errorf("ServiceRouter %s delete error: %w", apexName, err)
+			}
+		}
+		return nil
+	}
+
+	subset := consulPrimarySubset
+	if found && consulRouterSubset(router) == consulCanarySubset {
+		subset = consulCanarySubset
+	}
+
+	newRouter := cr.makeServiceRouter(canary, subset)
+	if found && cmp.Diff(newRouter, router) == "" {
+		return nil
+	}
+
+	if err := cr.consulClient.setConfigEntry(newRouter); err != nil {
+		return fmt.Errorf("ServiceRouter %s update error: %w", apexName, err)
+	}
+	cr.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+		Infof("ServiceRouter %s updated", apexName)
+	return nil
+}
+
+// GetRoutes returns the split weights of primary and canary,
+// for A/B testing the weights reflect the subset of the matched requests
+func (cr *ConsulRouter) GetRoutes(canary *flaggerv1.Canary) (
+	primaryWeight int,
+	canaryWeight int,
+	mirrored bool,
+	err error,
+) {
+	apexName, _, _ := canary.GetServiceNames()
+
+	if len(canary.GetAnalysis().Match) > 0 {
+		router := &consulServiceRouter{}
+		found, err := cr.consulClient.getConfigEntry(consulServiceRouterKind, apexName, router)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("ServiceRouter %s get query error: %w", apexName, err)
+		}
+		if !found {
+			return 0, 0, false, fmt.Errorf("ServiceRouter %s not found", apexName)
+		}
+		if consulRouterSubset(router) == consulCanarySubset {
+			return 0, 100, false, nil
+		}
+		return 100, 0, false, nil
+	}
+
+	splitter := &consulServiceSplitter{}
+	found, err := cr.consulClient.getConfigEntry(consulServiceSplitterKind, apexName, splitter)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("ServiceSplitter %s get query error: %w", apexName, err)
+	}
+	if !found {
+		return 0, 0, false, fmt.Errorf("ServiceSplitter %s not found", apexName)
+	}
+
+	for _, split := range splitter.Splits {
+		switch split.ServiceSubset {
+		case consulPrimarySubset:
+			primaryWeight = int(split.Weight)
+		case consulCanarySubset:
+			canaryWeight = int(split.Weight)
+		}
+	}
+
+	if primaryWeight == 0 && canaryWeight == 0 {
+		err = fmt.Errorf("ServiceSplitter %s does not contain splits for the primary and canary subsets", apexName)
+	}
+	return
+}
+
+// SetRoutes updates the split weights of primary and canary,
+// for A/B testing the matched requests are routed to canary while it has a weight
+func (cr *ConsulRouter) SetRoutes(
+	canary *flaggerv1.Canary,
+	primaryWeight int,
+	canaryWeight int,
+	_ bool,
+) error {
+	apexName, _, _ := canary.GetServiceNames()
+
+	if primaryWeight == 0 && canaryWeight == 0 {
+		return fmt.Errorf("ServiceSplitter %s update failed: no valid weights", apexName)
+	}
+
+	if len(canary.GetAnalysis().Match) > 0 {
+		subset := consulPrimarySubset
+		if canaryWeight > 0 {
+			subset = consulCanarySubset
+		}
+		if err := cr.consulClient.setConfigEntry(cr.makeServiceRouter(canary, subset)); err != nil {
+			return fmt.Errorf("ServiceRouter %s update error: %w", apexName, err)
+		}
+		return nil
+	}
+
+	if err := cr.consulClient.setConfigEntry(cr.makeServiceSplitter(canary, primaryWeight, canaryWeight)); err != nil {
+		return fmt.Errorf("ServiceSplitter %s update error: %w", apexName, err)
+	}
+	return nil
+}
+
+// Finalize removes the config entries, the traffic is routed to all the instances of the apex service
+func (cr *ConsulRouter) Finalize(canary *flaggerv1.Canary) error {
+	apexName, _, _ := canary.GetServiceNames()
+
+	for _, kind := range []string{consulServiceRouterKind, consulServiceSplitterKind, consulServiceResolverKind} {
+		if err := cr.consulClient.deleteConfigEntry(kind, apexName); err != nil {
+			return fmt.Errorf("%s %s delete error: %w", kind, apexName, err)
+		}
+	}
+	return nil
+}
+
+// makeServiceResolver returns the primary and canary subsets,
+// the instances are selected by the name of the pod registered by consul-k8s
+func (cr *ConsulRouter) makeServiceResolver(canary *flaggerv1.Canary) *consulServiceResolver {
+	apexName, _, _ := canary.GetServiceNames()
+	targetName := canary.Spec.TargetRef.Name
+	primaryPods := fmt.Sprintf("^%s-primary-", targetName)
+
+	return &consulServiceResolver{
+		Kind:          consulServiceResolverKind,
+		Name:          apexName,
+		DefaultSubset: consulPrimarySubset,
+		Subsets: map[string]consulServiceSubset{
+			consulPrimarySubset: {
+				Filter:      fmt.Sprintf(`Service.Meta["pod-name"] matches "%s"`, primaryPods),
+				OnlyPassing: true,
+			},
+			consulCanarySubset: {
+				Filter: fmt.Sprintf(`Service.Meta["pod-name"] matches "^%s-" and Service.Meta["pod-name"] not matches "%s"`,
+					targetName, primaryPods),
+				OnlyPassing: true,
+			},
+		},
+	}
+}
+
+func (cr *ConsulRouter) makeServiceSplitter(canary *flaggerv1.Canary, primaryWeight int, canaryWeight int) *consulServiceSplitter {
+	apexName, _, _ := canary.GetServiceNames()
+
+	return &consulServiceSplitter{
+		Kind: consulServiceSplitterKind,
+		Name: apexName,
+		Splits: []consulServiceSplit{
+			{
+				Weight:        float32(primaryWeight),
+				ServiceSubset: consulPrimarySubset,
+			},
+			{
+				Weight:        float32(canaryWeight),
+				ServiceSubset: consulCanarySubset,
+			},
+		},
+	}
+}
+
+// makeServiceRouter returns a route to the subset for each match condition,
+// the other requests are handled by the service splitter
+func (cr *ConsulRouter) makeServiceRouter(canary *flaggerv1.Canary, subset string) *consulServiceRouter {
+	apexName, _, _ := canary.GetServiceNames()
+
+	router := &consulServiceRouter{
+		Kind: consulServiceRouterKind,
+		Name: apexName,
+	}
+	for _, match := range canary.GetAnalysis().Match {
+		httpMatch := &consulServiceRouteHTTPMatch{}
+		if match.Uri != nil {
+			httpMatch.PathExact = match.Uri.Exact
+			httpMatch.PathPrefix = match.Uri.Prefix
+			httpMatch.PathRegex = match.Uri.Regex
+		}
+		for _, name := range sortedKeys(match.Headers) {
+			httpMatch.Header = append(httpMatch.Header, makeConsulHTTPParam(name, match.Headers[name]))
+		}
+		for _, name := range sortedKeys(match.QueryParams) {
+			httpMatch.QueryParam = append(httpMatch.QueryParam, makeConsulHTTPParam(name, match.QueryParams[name]))
+		}
+
+		router.Routes = append(router.Routes, consulServiceRoute{
+			Match: &consulServiceRouteMatch{HTTP: httpMatch},
+			Destination: &consulServiceRouteDestination{
+				ServiceSubset: subset,
+			},
+		})
+	}
+	return router
+}
+
+func makeConsulHTTPParam(name string, match istiov1alpha1.StringMatch) consulServiceRouteHTTPParam {
+	return consulServiceRouteHTTPParam{
+		Name:   name,
+		Exact:  match.Exact,
+		Prefix: match.Prefix,
+		Suffix: match.Suffix,
+		Regex:  match.Regex,
+	}
+}
+
+// consulRouterSubset returns the subset of the first route
+func consulRouterSubset(router *consulServiceRouter) string {
+	if len(router.Routes) > 0 && router.Routes[0].Destination != nil {
+		return router.Routes[0].Destination.ServiceSubset
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+package router
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	istiov1alpha1 "github.com/weaveworks/flagger/pkg/apis/istio/common/v1alpha1"
+	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
+)
+
+// consulServer is a stand-in for the Consul config entries HTTP API
+type consulServer struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func (cs *consulServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if r.Header.Get("X-Consul-Token") != "secret" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPut && r.URL.Path == "/v1/config":
+		body, _ := ioutil.ReadAll(r.Body)
+		entry := struct{ Kind, Name string }{}
+		if err := json.Unmarshal(body, &entry); err != nil || entry.Kind == "" || entry.Name == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		cs.entries[entry.Kind+"/"+entry.Name] = body
+		w.Write([]byte("true"))
+	case strings.HasPrefix(r.URL.Path, "/v1/config/"):
+		key := strings.TrimPrefix(r.URL.Path, "/v1/config/")
+		body, ok := cs.entries[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(cs.entries, key)
+			return
+		}
+		w.Write(body)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (cs *consulServer) entry(t *testing.T, kind string, name string, out interface{}) bool {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	body, ok := cs.entries[kind+"/"+name]
+	if ok {
+		require.NoError(t, json.Unmarshal(body, out))
+	}
+	return ok
+}
+
+func newConsulFixture(t *testing.T) (fixture, *consulServer, *ConsulRouter) {
+	mocks := newFixture(nil)
+	server := &consulServer{entries: map[string][]byte{}}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	router := &ConsulRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		kubeClient:    mocks.kubeClient,
+		consulClient: &consulClient{
+			address:    ts.URL,
+			token:      "secret",
+			httpClient: ts.Client(),
+		},
+	}
+	return mocks, server, router
+}
+
+func TestConsulRouter_Reconcile(t *testing.T) {
+	mocks, server, router := newConsulFixture(t)
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	resolver := &consulServiceResolver{}
+	require.True(t, server.entry(t, consulServiceResolverKind, "podinfo", resolver))
+	assert.Equal(t, consulPrimarySubset, resolver.DefaultSubset)
+	assert.Equal(t, `Service.Meta["pod-name"] matches "^podinfo-primary-"`, resolver.Subsets[consulPrimarySubset].Filter)
+	assert.Contains(t, resolver.Subsets[consulCanarySubset].Filter, `not matches "^podinfo-primary-"`)
+
+	splitter := &consulServiceSplitter{}
+	require.True(t, server.entry(t, consulServiceSplitterKind, "podinfo", splitter))
+	require.Len(t, splitter.Splits, 2)
+	assert.Equal(t, float32(100), splitter.Splits[0].Weight)
+
+	// the router is only created for A/B testing
+	assert.False(t, server.entry(t, consulServiceRouterKind, "podinfo", &consulServiceRouter{}))
+
+	// the weights are kept on reconciliation
+	err = router.SetRoutes(mocks.canary, 70, 30, false)
+	require.NoError(t, err)
+	err = router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	p, c, m, err := router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 70, p)
+	assert.Equal(t, 30, c)
+	assert.False(t, m)
+
+	err = router.SetRoutes(mocks.canary, 0, 0, false)
+	require.Error(t, err)
+
+	// the config entries are removed on finalization
+	err = router.Finalize(mocks.canary)
+	require.NoError(t, err)
+	assert.Len(t, server.entries, 0)
+}
+
+func TestConsulRouter_ABTest(t *testing.T) {
+	mocks, server, router := newConsulFixture(t)
+	mocks.canary.Spec.Analysis.Iterations = 10
+	mocks.canary.Spec.Analysis.Match = []istiov1alpha3.HTTPMatchRequest{
+		{
+			Headers: map[string]istiov1alpha1.StringMatch{
+				"x-canary": {
+					Exact: "insider",
+				},
+				"user-agent": {
+					Regex: ".*Firefox.*",
+				},
+			},
+		},
+	}
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	serviceRouter := &consulServiceRouter{}
+	require.True(t, server.entry(t, consulServiceRouterKind, "podinfo", serviceRouter))
+	require.Len(t, serviceRouter.Routes, 1)
+	headers := serviceRouter.Routes[0].Match.HTTP.Header
+	require.Len(t, headers, 2)
+	assert.Equal(t, "user-agent", headers[0].Name)
+	assert.Equal(t, ".*Firefox.*", headers[0].Regex)
+	assert.Equal(t, "insider", headers[1].Exact)
+	assert.Equal(t, consulPrimarySubset, serviceRouter.Routes[0].Destination.ServiceSubset)
+
+	// the matched requests are routed to canary during the analysis
+	err = router.SetRoutes(mocks.canary, 0, 100, false)
+	require.NoError(t, err)
+
+	p, c, _, err := router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 0, p)
+	assert.Equal(t, 100, c)
+
+	// the subset is kept on reconciliation
+	err = router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+	require.True(t, server.entry(t, consulServiceRouterKind, "podinfo", serviceRouter))
+	assert.Equal(t, consulCanarySubset, serviceRouter.Routes[0].Destination.ServiceSubset)
+
+	// the unmatched requests are routed to primary
+	splitter := &consulServiceSplitter{}
+	require.True(t, server.entry(t, consulServiceSplitterKind, "podinfo", splitter))
+	assert.Equal(t, float32(100), splitter.Splits[0].Weight)
+	assert.Equal(t, float32(0), splitter.Splits[1].Weight)
+
+	// the router is removed when A/B testing is disabled
+	mocks.canary.Spec.Analysis.Match = nil
+	err = router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+	assert.False(t, server.entry(t, consulServiceRouterKind, "podinfo", serviceRouter))
+}
+
+func TestConsulRouter_Unauthorized(t *testing.T) {
+	mocks, _, router := newConsulFixture(t)
+	router.consulClient.token = ""
+
+	err := router.Reconcile(mocks.canary)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+}
@@ -22,112 +22,26 @@ type ContourRouter struct {
 	contourClient clientset.Interface
 	flaggerClient clientset.Interface
 	logger        *zap.SugaredLogger
+	applier       *serverSideApplier
 }
 
+var (
+	contourHTTPProxyGVR = schema.GroupVersionResource{
+		Group:    "projectcontour.io",
+		Version:  "v1",
+		Resource: "httpproxies",
+	}
+)
+
 // Reconcile creates or updates the HTTP proxy
 func (cr *ContourRouter) Reconcile(canary *flaggerv1.Canary) error {
-	apexName, primaryName, canaryName := canary.GetServiceNames()
+	apexName, _, _ := canary.GetServiceNames()
 
-	newSpec := contourv1.HTTPProxySpec{
-		Routes: []contourv1.Route{
-			{
-				Conditions: []contourv1.Condition{
-					{
-						Prefix: cr.makePrefix(canary),
-					},
-				},
-				TimeoutPolicy: cr.makeTimeoutPolicy(canary),
-				RetryPolicy:   cr.makeRetryPolicy(canary),
-				Services: []contourv1.Service{
-					{
-						Name:   primaryName,
-						Port:   int(canary.Spec.Service.Port),
-						Weight: uint32(100),
-						RequestHeadersPolicy: &contourv1.HeadersPolicy{
-							Set: []contourv1.HeaderValue{
-								cr.makeLinkerdHeaderValue(canary, primaryName),
-							},
-						},
-					},
-					{
-						Name:   canaryName,
-						Port:   int(canary.Spec.Service.Port),
-						Weight: uint32(0),
-						RequestHeadersPolicy: &contourv1.HeadersPolicy{
-							Set: []contourv1.HeaderValue{
-								cr.makeLinkerdHeaderValue(canary, canaryName),
-							},
-						},
-					},
-				},
-			},
-		},
+	if cr.applier != nil {
+		return cr.applyHTTPProxy(canary, 0, 0, false)
 	}
 
-	if len(canary.GetAnalysis().Match) > 0 {
-		newSpec = contourv1.HTTPProxySpec{
-			Routes: []contourv1.Route{
-				{
-					Conditions:    cr.makeConditions(canary),
-					TimeoutPolicy: cr.makeTimeoutPolicy(canary),
-					RetryPolicy:   cr.makeRetryPolicy(canary),
-					Services: []contourv1.Service{
-						{
-							Name:   primaryName,
-							Port:   int(canary.Spec.Service.Port),
-							Weight: uint32(100),
-							RequestHeadersPolicy: &contourv1.HeadersPolicy{
-								Set: []contourv1.HeaderValue{
-									cr.makeLinkerdHeaderValue(canary, primaryName),
-								},
-							},
-						},
-						{
-							Name:   canaryName,
-							Port:   int(canary.Spec.Service.Port),
-							Weight: uint32(0),
-							RequestHeadersPolicy: &contourv1.HeadersPolicy{
-								Set: []contourv1.HeaderValue{
-									cr.makeLinkerdHeaderValue(canary, canaryName),
-								},
-							},
-						},
-					},
-				},
-				{
-					Conditions: []contourv1.Condition{
-						{
-							Prefix: cr.makePrefix(canary),
-						},
-					},
-					TimeoutPolicy: cr.makeTimeoutPolicy(canary),
-					RetryPolicy:   cr.makeRetryPolicy(canary),
-					Services: []contourv1.Service{
-						{
-							Name:   primaryName,
-							Port:   int(canary.Spec.Service.Port),
-							Weight: uint32(100),
-							RequestHeadersPolicy: &contourv1.HeadersPolicy{
-								Set: []contourv1.HeaderValue{
-									cr.makeLinkerdHeaderValue(canary, primaryName),
-								},
-							},
-						},
-						{
-							Name:   canaryName,
-							Port:   int(canary.Spec.Service.Port),
-							Weight: uint32(0),
-							RequestHeadersPolicy: &contourv1.HeadersPolicy{
-								Set: []contourv1.HeaderValue{
-									cr.makeLinkerdHeaderValue(canary, canaryName),
-								},
-							},
-						},
-					},
-				},
-			},
-		}
-	}
+	newSpec := cr.makeSpec(canary, 100, 0)
 
 	proxy, err := cr.contourClient.ProjectcontourV1().HTTPProxies(canary.Namespace).Get(apexName, metav1.GetOptions{})
 	if errors.IsNotFound(err) {
@@ -221,18 +135,35 @@ func (cr *ContourRouter) SetRoutes(
 	canaryWeight int,
 	_ bool,
 ) error {
-	apexName, primaryName, canaryName := canary.GetServiceNames()
+	apexName, _, _ := canary.GetServiceNames()
 
 	if primaryWeight == 0 && canaryWeight == 0 {
 		return fmt.Errorf("HTTPProxy %s.%s update failed: no valid weights", apexName, canary.Namespace)
 	}
 
+	if cr.applier != nil {
+		return cr.applyHTTPProxy(canary, primaryWeight, canaryWeight, true)
+	}
+
 	proxy, err := cr.contourClient.ProjectcontourV1().HTTPProxies(canary.Namespace).Get(apexName, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("HTTPProxy %s.%s query error: %w", apexName, canary.Namespace, err)
 	}
 
-	proxy.Spec = contourv1.HTTPProxySpec{
+	proxy.Spec = cr.makeSpec(canary, primaryWeight, canaryWeight)
+
+	_, err = cr.contourClient.ProjectcontourV1().HTTPProxies(canary.Namespace).Update(proxy)
+	if err != nil {
+		return fmt.Errorf("HTTPProxy %s.%s update error: %w", apexName, canary.Namespace, err)
+	}
+	return nil
+}
+
+// makeSpec returns the HTTP proxy spec with the specified weights
+func (cr *ContourRouter) makeSpec(canary *flaggerv1.Canary, primaryWeight int, canaryWeight int) contourv1.HTTPProxySpec {
+	_, primaryName, canaryName := canary.GetServiceNames()
+
+	spec := contourv1.HTTPProxySpec{
 		Routes: []contourv1.Route{
 			{
 				Conditions: []contourv1.Condition{
@@ -268,7 +199,7 @@ func (cr *ContourRouter) SetRoutes(
 	}
 
 	if len(canary.GetAnalysis().Match) > 0 {
-		proxy.Spec = contourv1.HTTPProxySpec{
+		spec = contourv1.HTTPProxySpec{
 			Routes: []contourv1.Route{
 				{
 					Conditions:    cr.makeConditions(canary),
@@ -332,11 +263,35 @@ func (cr *ContourRouter) SetRoutes(
 		}
 	}
 
-	_, err = cr.contourClient.ProjectcontourV1().HTTPProxies(canary.Namespace).Update(proxy)
-	if err != nil {
-		return fmt.Errorf("HTTPProxy %s.%s update error: %w", apexName, canary.Namespace, err)
+	return spec
+}
+
+// applyHTTPProxy applies the generated HTTP proxy with server-side apply,
+// the weights are taken from the canary status when no weights are specified
+func (cr *ContourRouter) applyHTTPProxy(canary *flaggerv1.Canary, primaryWeight int, canaryWeight int, force bool) error {
+	apexName, _, _ := canary.GetServiceNames()
+
+	var live metav1.Object
+	proxy, err := cr.contourClient.ProjectcontourV1().HTTPProxies(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if err == nil {
+		live = proxy
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("HTTPProxy %s.%s get query error: %w", apexName, canary.Namespace, err)
 	}
-	return nil
+
+	if primaryWeight == 0 && canaryWeight == 0 {
+		primaryWeight, canaryWeight, _ = statusWeights(canary)
+	}
+
+	obj := &contourv1.HTTPProxy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: contourv1.SchemeGroupVersion.String(),
+			Kind:       "HTTPProxy",
+		},
+		ObjectMeta: makeApplyObjectMeta(canary, apexName, live),
+		Spec:       cr.makeSpec(canary, primaryWeight, canaryWeight),
+	}
+	return cr.applier.apply(canary, contourHTTPProxyGVR, obj, live, force)
 }
 
 func (cr *ContourRouter) makePrefix(canary *flaggerv1.Canary) string {
@@ -116,7 +116,7 @@ func (e *ExtKubernetesDefaultRouter) reconcileService(canary *flaggerv1.Canary,
 
 	portName := canary.Spec.Service.PortName
 	if portName == "" {
-		portName = "http"
+		portName = string(canary.GetAppProtocol())
 	}
 
 	targetPort := intstr.IntOrString{
@@ -205,6 +205,10 @@ func (e *ExtKubernetesDefaultRouter) reconcileService(canary *flaggerv1.Canary,
 			}
 		}
 
+		if apexName, _, _ := canary.GetServiceNames(); c.keepApexSelector && name == apexName {
+			svcSpec.Selector = svc.Spec.Selector
+		}
+
 		portsDiff := cmp.Diff(svcSpec.Ports, svc.Spec.Ports, cmpopts.SortSlices(sortPorts))
 		selectorsDiff := cmp.Diff(svcSpec.Selector, svc.Spec.Selector)
 		if portsDiff != "" || selectorsDiff != "" {
@@ -3,11 +3,15 @@ package router
 import (
 	"github.com/weaveworks/flagger/pkg/internal"
 	"strings"
+	"sync"
 
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
 	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
 )
 
@@ -15,34 +19,105 @@ type Factory struct {
 	kubeConfig               *restclient.Config
 	kubeClient               kubernetes.Interface
 	meshClient               clientset.Interface
+	meshDynamicClient        dynamic.Interface
+	dynamicClient            dynamic.Interface
 	flaggerClient            clientset.Interface
 	ingressAnnotationsPrefix string
 	logger                   *zap.SugaredLogger
+	applyClient              dynamic.Interface
+	splitOnce                sync.Once
+	splitGVR                 schema.GroupVersionResource
+	ingressOnce              sync.Once
+	ingressV1                bool
 }
 
 func NewFactory(kubeConfig *restclient.Config, kubeClient kubernetes.Interface,
 	flaggerClient clientset.Interface,
 	ingressAnnotationsPrefix string,
 	logger *zap.SugaredLogger,
-	meshClient clientset.Interface) *Factory {
+	meshClient clientset.Interface,
+	meshDynamicClient dynamic.Interface,
+	applyClient dynamic.Interface) *Factory {
+	var dynamicClient dynamic.Interface
+	if kubeConfig != nil {
+		dynamicClient = dynamic.NewForConfigOrDie(kubeConfig)
+	}
+
 	return &Factory{
 		kubeConfig:               kubeConfig,
 		meshClient:               meshClient,
+		meshDynamicClient:        meshDynamicClient,
+		dynamicClient:            dynamicClient,
 		kubeClient:               kubeClient,
 		flaggerClient:            flaggerClient,
 		ingressAnnotationsPrefix: ingressAnnotationsPrefix,
 		logger:                   logger,
+		applyClient:              applyClient,
+	}
+}
+
+// serverSideApplier returns nil if the routing objects are not applied server-side
+func (factory *Factory) serverSideApplier() *serverSideApplier {
+	if factory.applyClient == nil {
+		return nil
+	}
+	return &serverSideApplier{
+		client: factory.applyClient,
+		logger: factory.logger,
 	}
 }
 
+// trafficSplitGVR returns the newest TrafficSplit version served by the mesh cluster,
+// the API discovery runs once and only when the routing objects are applied server-side
+func (factory *Factory) trafficSplitGVR() schema.GroupVersionResource {
+	factory.splitOnce.Do(func() {
+		factory.splitGVR = smiTrafficSplitGVR
+		if factory.applyClient == nil || factory.meshClient == nil {
+			return
+		}
+		resources, err := factory.meshClient.Discovery().ServerResourcesForGroupVersion(smiV1alpha2TrafficSplitGVR.GroupVersion().String())
+		if err != nil {
+			return
+		}
+		for _, r := range resources.APIResources {
+			if r.Name == smiV1alpha2TrafficSplitGVR.Resource {
+				factory.splitGVR = smiV1alpha2TrafficSplitGVR
+			}
+		}
+	})
+	return factory.splitGVR
+}
+
+// isIngressV1 discovers if the cluster serves networking.k8s.io/v1 Ingresses,
+// the API discovery runs once and on older clusters the router falls back to networking.k8s.io/v1beta1
+func (factory *Factory) isIngressV1() bool {
+	factory.ingressOnce.Do(func() {
+		if factory.dynamicClient == nil {
+			return
+		}
+		resources, err := factory.kubeClient.Discovery().ServerResourcesForGroupVersion(ingressV1GVR.GroupVersion().String())
+		if err != nil {
+			return
+		}
+		for _, r := range resources.APIResources {
+			if r.Name == ingressV1GVR.Resource {
+				factory.ingressV1 = true
+			}
+		}
+	})
+	return factory.ingressV1
+}
+
 // KubernetesRouter returns a KubernetesRouter interface implementation
-func (factory *Factory) KubernetesRouter(kind string, labelSelector string, annotations map[string]string, ports map[string]int32) KubernetesRouter {
+func (factory *Factory) KubernetesRouter(kind string, provider string, labelSelector string, annotations map[string]string, ports map[string]int32) KubernetesRouter {
 	if internal.IsNoopRoute() {
 		return &KubernetesNoopRouter{}
 	}
 	switch kind {
 	case "Service":
 		return &KubernetesNoopRouter{}
+	case flaggerv1.KnativeServiceKind:
+		return &KubernetesNoopRouter{}
 	default: // Daemonset or Deployment
 		return &ExtKubernetesDefaultRouter{
 			innerK8sRouter: &KubernetesDefaultRouter{
@@ -52,6 +127,8 @@ func (factory *Factory) KubernetesRouter(kind string, labelSelector string, anno
 				labelSelector: labelSelector,
 				annotations:   annotations,
 				ports:         ports,
+				// the kubernetes-replicas router selects both the primary and canary pods
+				keepApexSelector: provider == "kubernetes-replicas",
 			},
 		}
 	}
@@ -73,11 +150,19 @@ func (factory *Factory) innerMeshRouter(provider string) Interface {
 		return &NopRouter{}
 	case provider == "kubernetes":
 		return &NopRouter{}
+	case provider == "kubernetes-replicas":
+		return &KubernetesReplicasRouter{
+			logger:        factory.logger,
+			flaggerClient: factory.flaggerClient,
+			kubeClient:    factory.kubeClient,
+		}
 	case provider == "nginx":
 		return &IngressRouter{
 			logger:            factory.logger,
 			kubeClient:        factory.kubeClient,
+			ingressClient:     factory.dynamicClient,
 			annotationsPrefix: factory.ingressAnnotationsPrefix,
+			ingressV1:         factory.isIngressV1(),
 		}
 	case provider == "appmesh":
 		return &AppMeshRouter{
@@ -95,7 +180,9 @@ func (factory *Factory) innerMeshRouter(provider string) Interface {
 					flaggerClient: factory.flaggerClient,
 					kubeClient:    factory.kubeClient,
 					smiClient:     factory.meshClient,
+					applier:       factory.serverSideApplier(),
 					targetMesh:    "cse",
+					splitGVR:      factory.trafficSplitGVR(),
 				},
 			}
 		}
@@ -104,7 +191,9 @@ func (factory *Factory) innerMeshRouter(provider string) Interface {
 			flaggerClient: factory.flaggerClient,
 			kubeClient:    factory.kubeClient,
 			smiClient:     factory.meshClient,
+			applier:       factory.serverSideApplier(),
 			targetMesh:    mesh,
+			splitGVR:      factory.trafficSplitGVR(),
 		}
 	case provider == "linkerd":
 		return &SmiRouter{
@@ -112,7 +201,9 @@ func (factory *Factory) innerMeshRouter(provider string) Interface {
 			flaggerClient: factory.flaggerClient,
 			kubeClient:    factory.kubeClient,
 			smiClient:     factory.meshClient,
+			applier:       factory.serverSideApplier(),
 			targetMesh:    "linkerd",
+			splitGVR:      factory.trafficSplitGVR(),
 		}
 	case provider == "contour":
 		return &ContourRouter{
@@ -120,6 +211,42 @@ func (factory *Factory) innerMeshRouter(provider string) Interface {
 			flaggerClient: factory.flaggerClient,
 			kubeClient:    factory.kubeClient,
 			contourClient: factory.meshClient,
+			applier:       factory.serverSideApplier(),
+		}
+	case provider == "consul":
+		return &ConsulRouter{
+			logger:        factory.logger,
+			flaggerClient: factory.flaggerClient,
+			kubeClient:    factory.kubeClient,
+			consulClient:  newConsulClient(),
+		}
+	case provider == "gatewayapi":
+		return &GatewayAPIRouter{
+			logger:        factory.logger,
+			flaggerClient: factory.flaggerClient,
+			kubeClient:    factory.kubeClient,
+			gatewayClient: factory.meshClient,
+		}
+	case provider == "traefik":
+		return &TraefikRouter{
+			logger:        factory.logger,
+			flaggerClient: factory.flaggerClient,
+			kubeClient:    factory.kubeClient,
+			traefikClient: factory.dynamicClient,
+		}
+	case provider == "knative":
+		return &KnativeRouter{
+			logger:        factory.logger,
+			flaggerClient: factory.flaggerClient,
+			kubeClient:    factory.kubeClient,
+			knativeClient: factory.dynamicClient,
+		}
+	case provider == "apisix":
+		return &ApisixRouter{
+			logger:        factory.logger,
+			flaggerClient: factory.flaggerClient,
+			kubeClient:    factory.kubeClient,
+			apisixClient:  factory.dynamicClient,
 		}
 	case strings.HasPrefix(provider, "gloo"):
 		upstreamDiscoveryNs := "gloo-system"
@@ -146,6 +273,8 @@ func (factory *Factory) innerMeshRouter(provider string) Interface {
 			flaggerClient: factory.flaggerClient,
 			kubeClient:    factory.kubeClient,
 			istioClient:   factory.meshClient,
+			dynamicClient: factory.meshDynamicClient,
+			applier:       factory.serverSideApplier(),
 		}
 	case strings.HasPrefix(provider, "supergloo:linkerd"):
 		return &SmiRouter{
@@ -153,7 +282,9 @@ func (factory *Factory) innerMeshRouter(provider string) Interface {
 			flaggerClient: factory.flaggerClient,
 			kubeClient:    factory.kubeClient,
 			smiClient:     factory.meshClient,
+			applier:       factory.serverSideApplier(),
 			targetMesh:    "linkerd",
+			splitGVR:      factory.trafficSplitGVR(),
 		}
 	default:
 		return &IstioRouter{
@@ -161,6 +292,8 @@ func (factory *Factory) innerMeshRouter(provider string) Interface {
 			flaggerClient: factory.flaggerClient,
 			kubeClient:    factory.kubeClient,
 			istioClient:   factory.meshClient,
+			dynamicClient: factory.meshDynamicClient,
+			applier:       factory.serverSideApplier(),
 		}
 	}
 }
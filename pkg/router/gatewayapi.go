@@ -0,0 +1,415 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	gatewayv1beta1 "github.com/weaveworks/flagger/pkg/apis/gatewayapi/v1beta1"
+	istiov1alpha1 "github.com/weaveworks/flagger/pkg/apis/istio/common/v1alpha1"
+	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
+)
+
+// GatewayAPIRouter is managing Gateway API HTTPRoute objects
+type GatewayAPIRouter struct {
+	kubeClient    kubernetes.Interface
+	gatewayClient clientset.Interface
+	flaggerClient clientset.Interface
+	logger        *zap.SugaredLogger
+}
+
+// Reconcile creates or updates the HTTPRoute
+func (gr *GatewayAPIRouter) Reconcile(canary *flaggerv1.Canary) error {
+	apexName, _, _ := canary.GetServiceNames()
+
+	newSpec := gatewayv1beta1.HTTPRouteSpec{
+		CommonRouteSpec: gatewayv1beta1.CommonRouteSpec{
+			ParentRefs: gr.makeParentRefs(canary),
+		},
+		Hostnames: gr.makeHostnames(canary),
+		Rules:     gr.makeRules(canary, 100, 0),
+	}
+
+	route, err := gr.gatewayClient.GatewayV1beta1().HTTPRoutes(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		route = &gatewayv1beta1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      apexName,
+				Namespace: canary.Namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(canary, schema.GroupVersionKind{
+						Group:   flaggerv1.SchemeGroupVersion.Group,
+						Version: flaggerv1.SchemeGroupVersion.Version,
+						Kind:    flaggerv1.CanaryKind,
+					}),
+				},
+			},
+			Spec: newSpec,
+		}
+
+		_, err = gr.gatewayClient.GatewayV1beta1().HTTPRoutes(canary.Namespace).Create(route)
+		if err != nil {
+			return fmt.Errorf("HTTPRoute %s.%s create error: %w", apexName, canary.Namespace, err)
+		}
+		gr.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+			Infof("HTTPRoute %s.%s created", route.GetName(), canary.Namespace)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("HTTPRoute %s.%s get query error: %w", apexName, canary.Namespace, err)
+	}
+
+	// update HTTPRoute but keep the original backend weights
+	if route != nil {
+		if diff := cmp.Diff(
+			newSpec,
+			route.Spec,
+			cmpopts.IgnoreFields(gatewayv1beta1.BackendRef{}, "Weight"),
+		); diff != "" {
+			clone := route.DeepCopy()
+			clone.Spec = newSpec
+
+			// store the original spec to revert the HTTPRoute on canary deletion,
+			// unless it was applied with kubectl
+			if _, ok := clone.Annotations[kubectlAnnotation]; !ok {
+				if _, ok := clone.Annotations[configAnnotation]; !ok {
+					b, err := json.Marshal(route.Spec)
+					if err != nil {
+						gr.logger.Warnf("Unable to marshal HTTPRoute %s for orig-configuration annotation", route.Name)
+					}
+
+					if clone.ObjectMeta.Annotations == nil {
+						clone.ObjectMeta.Annotations = make(map[string]string)
+					}
+					clone.ObjectMeta.Annotations[configAnnotation] = string(b)
+				}
+			}
+
+			_, err = gr.gatewayClient.GatewayV1beta1().HTTPRoutes(canary.Namespace).Update(clone)
+			if err != nil {
+				return fmt.Errorf("HTTPRoute %s.%s update error: %w", apexName, canary.Namespace, err)
+			}
+			gr.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+				Infof("HTTPRoute %s.%s updated", route.GetName(), canary.Namespace)
+		}
+	}
+
+	return nil
+}
+
+// GetRoutes returns the backend weights for primary and canary
+func (gr *GatewayAPIRouter) GetRoutes(canary *flaggerv1.Canary) (
+	primaryWeight int,
+	canaryWeight int,
+	mirrored bool,
+	err error,
+) {
+	apexName, primaryName, canaryName := canary.GetServiceNames()
+
+	route, err := gr.gatewayClient.GatewayV1beta1().HTTPRoutes(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if err != nil {
+		err = fmt.Errorf("HTTPRoute %s.%s get query error: %w", apexName, canary.Namespace, err)
+		return
+	}
+
+	found := false
+	for _, rule := range route.Spec.Rules {
+		for _, backend := range rule.BackendRefs {
+			if string(backend.Name) == canaryName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+
+		for _, backend := range rule.BackendRefs {
+			weight := 1
+			if backend.Weight != nil {
+				weight = int(*backend.Weight)
+			}
+			switch string(backend.Name) {
+			case primaryName:
+				primaryWeight = weight
+			case canaryName:
+				canaryWeight = weight
+			}
+		}
+		break
+	}
+
+	if !found {
+		err = fmt.Errorf("HTTPRoute %s.%s does not contain a backend for %s", apexName, canary.Namespace, canaryName)
+	}
+
+	return
+}
+
+// SetRoutes updates the backend weights for primary and canary
+func (gr *GatewayAPIRouter) SetRoutes(
+	canary *flaggerv1.Canary,
+	primaryWeight int,
+	canaryWeight int,
+	_ bool,
+) error {
+	apexName, _, _ := canary.GetServiceNames()
+
+	if primaryWeight == 0 && canaryWeight == 0 {
+		return fmt.Errorf("HTTPRoute %s.%s update failed: no valid weights", apexName, canary.Namespace)
+	}
+
+	route, err := gr.gatewayClient.GatewayV1beta1().HTTPRoutes(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("HTTPRoute %s.%s query error: %w", apexName, canary.Namespace, err)
+	}
+
+	clone := route.DeepCopy()
+	clone.Spec.Rules = gr.makeRules(canary, primaryWeight, canaryWeight)
+
+	_, err = gr.gatewayClient.GatewayV1beta1().HTTPRoutes(canary.Namespace).Update(clone)
+	if err != nil {
+		return fmt.Errorf("HTTPRoute %s.%s update error: %w", apexName, canary.Namespace, err)
+	}
+	return nil
+}
+
+// Finalize reverts the HTTPRoute to the spec stored before Flagger took over
+func (gr *GatewayAPIRouter) Finalize(canary *flaggerv1.Canary) error {
+	apexName, _, _ := canary.GetServiceNames()
+
+	route, err := gr.gatewayClient.GatewayV1beta1().HTTPRoutes(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("HTTPRoute %s.%s get query error: %w", apexName, canary.Namespace, err)
+	}
+
+	var storedSpec gatewayv1beta1.HTTPRouteSpec
+	if a, ok := route.ObjectMeta.Annotations[kubectlAnnotation]; ok {
+		var storedRoute gatewayv1beta1.HTTPRoute
+		if err := json.Unmarshal([]byte(a), &storedRoute); err != nil {
+			return fmt.Errorf("HTTPRoute %s.%s failed to unMarshal annotation %s",
+				apexName, canary.Namespace, kubectlAnnotation)
+		}
+		storedSpec = storedRoute.Spec
+	} else if a, ok := route.ObjectMeta.Annotations[configAnnotation]; ok {
+		if err := json.Unmarshal([]byte(a), &storedSpec); err != nil {
+			return fmt.Errorf("HTTPRoute %s.%s failed to unMarshal annotation %s",
+				apexName, canary.Namespace, configAnnotation)
+		}
+	} else {
+		gr.logger.Warnf("HTTPRoute %s.%s original configuration not found, unable to revert", apexName, canary.Namespace)
+		return nil
+	}
+
+	clone := route.DeepCopy()
+	clone.Spec = storedSpec
+	delete(clone.Annotations, configAnnotation)
+
+	_, err = gr.gatewayClient.GatewayV1beta1().HTTPRoutes(canary.Namespace).Update(clone)
+	if err != nil {
+		return fmt.Errorf("HTTPRoute %s.%s update error: %w", apexName, canary.Namespace, err)
+	}
+	return nil
+}
+
+// makeRules returns the weighted rule for progressive traffic shifting,
+// for A/B testing the canary rule matches the analysis conditions and
+// the default rule routes all the other requests to primary
+func (gr *GatewayAPIRouter) makeRules(canary *flaggerv1.Canary, primaryWeight int, canaryWeight int) []gatewayv1beta1.HTTPRouteRule {
+	_, primaryName, canaryName := canary.GetServiceNames()
+
+	pathMatch := gr.makePathMatch(canary)
+	backends := []gatewayv1beta1.HTTPBackendRef{
+		gr.makeBackendRef(canary, primaryName, primaryWeight),
+		gr.makeBackendRef(canary, canaryName, canaryWeight),
+	}
+
+	if len(canary.GetAnalysis().Match) == 0 {
+		return []gatewayv1beta1.HTTPRouteRule{
+			{
+				Matches:     []gatewayv1beta1.HTTPRouteMatch{{Path: pathMatch}},
+				BackendRefs: backends,
+				Timeouts:    gr.makeTimeouts(canary),
+			},
+		}
+	}
+
+	return []gatewayv1beta1.HTTPRouteRule{
+		{
+			Matches:     gr.makeMatches(canary, pathMatch),
+			BackendRefs: backends,
+			Timeouts:    gr.makeTimeouts(canary),
+		},
+		{
+			Matches: []gatewayv1beta1.HTTPRouteMatch{{Path: pathMatch}},
+			BackendRefs: []gatewayv1beta1.HTTPBackendRef{
+				gr.makeBackendRef(canary, primaryName, 100),
+			},
+			Timeouts: gr.makeTimeouts(canary),
+		},
+	}
+}
+
+// makeMatches converts the analysis match conditions to HTTPRoute matches,
+// the conditions of a match are ANDed while the matches are ORed
+func (gr *GatewayAPIRouter) makeMatches(canary *flaggerv1.Canary, pathMatch *gatewayv1beta1.HTTPPathMatch) []gatewayv1beta1.HTTPRouteMatch {
+	var matches []gatewayv1beta1.HTTPRouteMatch
+	for _, m := range canary.GetAnalysis().Match {
+		match := gatewayv1beta1.HTTPRouteMatch{
+			Path: pathMatch,
+		}
+
+		for _, name := range sortedKeys(m.Headers) {
+			matchType, value := gatewayStringMatch(m.Headers[name])
+			headerType := gatewayv1beta1.HeaderMatchType(matchType)
+			match.Headers = append(match.Headers, gatewayv1beta1.HTTPHeaderMatch{
+				Type:  &headerType,
+				Name:  gatewayv1beta1.HTTPHeaderName(name),
+				Value: value,
+			})
+		}
+
+		for _, name := range sortedKeys(m.QueryParams) {
+			matchType, value := gatewayStringMatch(m.QueryParams[name])
+			queryType := gatewayv1beta1.QueryParamMatchType(matchType)
+			match.QueryParams = append(match.QueryParams, gatewayv1beta1.HTTPQueryParamMatch{
+				Type:  &queryType,
+				Name:  gatewayv1beta1.HTTPHeaderName(name),
+				Value: value,
+			})
+		}
+
+		if m.Method != nil && m.Method.Exact != "" {
+			method := gatewayv1beta1.HTTPMethod(strings.ToUpper(m.Method.Exact))
+			match.Method = &method
+		}
+
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// makePathMatch returns the path match of the canary service URI, defaults to the / prefix
+func (gr *GatewayAPIRouter) makePathMatch(canary *flaggerv1.Canary) *gatewayv1beta1.HTTPPathMatch {
+	pathType := gatewayv1beta1.PathMatchPathPrefix
+	value := "/"
+
+	if len(canary.Spec.Service.Match) > 0 && canary.Spec.Service.Match[0].Uri != nil {
+		uri := canary.Spec.Service.Match[0].Uri
+		switch {
+		case uri.Exact != "":
+			pathType, value = gatewayv1beta1.PathMatchExact, uri.Exact
+		case uri.Prefix != "":
+			pathType, value = gatewayv1beta1.PathMatchPathPrefix, uri.Prefix
+		case uri.Regex != "":
+			pathType, value = gatewayv1beta1.PathMatchRegularExpression, uri.Regex
+		}
+	}
+
+	return &gatewayv1beta1.HTTPPathMatch{
+		Type:  &pathType,
+		Value: &value,
+	}
+}
+
+// makeParentRefs returns the Gateways the route is attached to,
+// without gateways the route is attached to the apex service for mesh traffic
+func (gr *GatewayAPIRouter) makeParentRefs(canary *flaggerv1.Canary) []gatewayv1beta1.ParentReference {
+	var refs []gatewayv1beta1.ParentReference
+	for _, gateway := range canary.Spec.Service.Gateways {
+		if gateway == "mesh" {
+			continue
+		}
+		ref := gatewayv1beta1.ParentReference{
+			Name: gatewayv1beta1.ObjectName(gateway),
+		}
+		if parts := strings.SplitN(gateway, "/", 2); len(parts) == 2 {
+			ns := gatewayv1beta1.Namespace(parts[0])
+			ref.Namespace = &ns
+			ref.Name = gatewayv1beta1.ObjectName(parts[1])
+		}
+		refs = append(refs, ref)
+	}
+
+	if len(refs) == 0 {
+		apexName, _, _ := canary.GetServiceNames()
+		group := gatewayv1beta1.Group("")
+		kind := gatewayv1beta1.Kind("Service")
+		port := gatewayv1beta1.PortNumber(canary.Spec.Service.Port)
+		refs = append(refs, gatewayv1beta1.ParentReference{
+			Group: &group,
+			Kind:  &kind,
+			Name:  gatewayv1beta1.ObjectName(apexName),
+			Port:  &port,
+		})
+	}
+
+	return refs
+}
+
+func (gr *GatewayAPIRouter) makeHostnames(canary *flaggerv1.Canary) []gatewayv1beta1.Hostname {
+	var hostnames []gatewayv1beta1.Hostname
+	for _, host := range canary.Spec.Service.Hosts {
+		hostnames = append(hostnames, gatewayv1beta1.Hostname(host))
+	}
+	return hostnames
+}
+
+func (gr *GatewayAPIRouter) makeBackendRef(canary *flaggerv1.Canary, name string, weight int) gatewayv1beta1.HTTPBackendRef {
+	port := gatewayv1beta1.PortNumber(canary.Spec.Service.Port)
+	w := int32(weight)
+	return gatewayv1beta1.HTTPBackendRef{
+		BackendRef: gatewayv1beta1.BackendRef{
+			BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+				Name: gatewayv1beta1.ObjectName(name),
+				Port: &port,
+			},
+			Weight: &w,
+		},
+	}
+}
+
+func (gr *GatewayAPIRouter) makeTimeouts(canary *flaggerv1.Canary) *gatewayv1beta1.HTTPRouteTimeouts {
+	if canary.Spec.Service.Timeout != "" {
+		timeout := gatewayv1beta1.Duration(canary.Spec.Service.Timeout)
+		return &gatewayv1beta1.HTTPRouteTimeouts{
+			Request: &timeout,
+		}
+	}
+	return nil
+}
+
+// gatewayStringMatch converts an Istio string match to an exact or regular expression match,
+// the Gateway API has no prefix and suffix matches for headers and query params
+func gatewayStringMatch(m istiov1alpha1.StringMatch) (string, string) {
+	switch {
+	case m.Prefix != "":
+		return "RegularExpression", "^" + regexp.QuoteMeta(m.Prefix) + ".*"
+	case m.Suffix != "":
+		return "RegularExpression", ".*" + regexp.QuoteMeta(m.Suffix) + "$"
+	case m.Regex != "":
+		return "RegularExpression", m.Regex
+	default:
+		return "Exact", m.Exact
+	}
+}
+
+// sortedKeys returns the string match keys in a stable order
+func sortedKeys(m map[string]istiov1alpha1.StringMatch) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,223 @@
+package router
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gatewayv1beta1 "github.com/weaveworks/flagger/pkg/apis/gatewayapi/v1beta1"
+	istiov1alpha1 "github.com/weaveworks/flagger/pkg/apis/istio/common/v1alpha1"
+	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
+)
+
+func TestGatewayAPIRouter_Reconcile(t *testing.T) {
+	mocks := newFixture(nil)
+	router := &GatewayAPIRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		gatewayClient: mocks.meshClient,
+		kubeClient:    mocks.kubeClient,
+	}
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	// test insert
+	route, err := router.gatewayClient.GatewayV1beta1().HTTPRoutes("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	require.Len(t, route.Spec.ParentRefs, 1)
+	assert.Equal(t, gatewayv1beta1.ObjectName("public-gateway.istio"), route.Spec.ParentRefs[0].Name)
+
+	require.Len(t, route.Spec.Rules, 1)
+	rule := route.Spec.Rules[0]
+	assert.Equal(t, "/podinfo", *rule.Matches[0].Path.Value)
+	require.Len(t, rule.BackendRefs, 2)
+	assert.Equal(t, gatewayv1beta1.ObjectName("podinfo-primary"), rule.BackendRefs[0].Name)
+	assert.Equal(t, int32(100), *rule.BackendRefs[0].Weight)
+	assert.Equal(t, gatewayv1beta1.ObjectName("podinfo-canary"), rule.BackendRefs[1].Name)
+	assert.Equal(t, int32(0), *rule.BackendRefs[1].Weight)
+
+	// test update
+	cd, err := mocks.flaggerClient.FlaggerV1beta1().Canaries("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	cdClone := cd.DeepCopy()
+	cdClone.Spec.Service.Timeout = "1m"
+	cdClone.Spec.Service.Gateways = []string{"gateway-system/public"}
+	cdClone.Spec.Service.Hosts = []string{"app.example.com"}
+	canary, err := mocks.flaggerClient.FlaggerV1beta1().Canaries("default").Update(cdClone)
+	require.NoError(t, err)
+
+	err = router.Reconcile(canary)
+	require.NoError(t, err)
+
+	route, err = router.gatewayClient.GatewayV1beta1().HTTPRoutes("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, gatewayv1beta1.Duration("1m"), *route.Spec.Rules[0].Timeouts.Request)
+	assert.Equal(t, gatewayv1beta1.ObjectName("public"), route.Spec.ParentRefs[0].Name)
+	assert.Equal(t, gatewayv1beta1.Namespace("gateway-system"), *route.Spec.ParentRefs[0].Namespace)
+	assert.Equal(t, []gatewayv1beta1.Hostname{"app.example.com"}, route.Spec.Hostnames)
+}
+
+func TestGatewayAPIRouter_Mesh(t *testing.T) {
+	mocks := newFixture(nil)
+	router := &GatewayAPIRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		gatewayClient: mocks.meshClient,
+		kubeClient:    mocks.kubeClient,
+	}
+
+	err := router.Reconcile(mocks.abtest)
+	require.NoError(t, err)
+
+	route, err := router.gatewayClient.GatewayV1beta1().HTTPRoutes("default").Get("abtest", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	require.Len(t, route.Spec.ParentRefs, 1)
+	assert.Equal(t, gatewayv1beta1.Kind("Service"), *route.Spec.ParentRefs[0].Kind)
+	assert.Equal(t, gatewayv1beta1.ObjectName("abtest"), route.Spec.ParentRefs[0].Name)
+	assert.Equal(t, gatewayv1beta1.PortNumber(9898), *route.Spec.ParentRefs[0].Port)
+}
+
+func TestGatewayAPIRouter_Routes(t *testing.T) {
+	mocks := newFixture(nil)
+	router := &GatewayAPIRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		gatewayClient: mocks.meshClient,
+		kubeClient:    mocks.kubeClient,
+	}
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	p, c, m, err := router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 100, p)
+	assert.Equal(t, 0, c)
+	assert.False(t, m)
+
+	err = router.SetRoutes(mocks.canary, 60, 40, false)
+	require.NoError(t, err)
+
+	p, c, _, err = router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 60, p)
+	assert.Equal(t, 40, c)
+
+	err = router.SetRoutes(mocks.canary, 0, 0, false)
+	require.Error(t, err)
+}
+
+func TestGatewayAPIRouter_ABTest(t *testing.T) {
+	mocks := newFixture(nil)
+	router := &GatewayAPIRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		gatewayClient: mocks.meshClient,
+		kubeClient:    mocks.kubeClient,
+	}
+
+	canary := mocks.abtest.DeepCopy()
+	canary.Spec.Analysis.Match = []istiov1alpha3.HTTPMatchRequest{
+		{
+			Headers: map[string]istiov1alpha1.StringMatch{
+				"x-user-type": {Exact: "test"},
+				"user-agent":  {Prefix: "Mozilla"},
+			},
+		},
+		{
+			QueryParams: map[string]istiov1alpha1.StringMatch{
+				"canary": {Exact: "true"},
+			},
+		},
+	}
+
+	err := router.Reconcile(canary)
+	require.NoError(t, err)
+
+	route, err := router.gatewayClient.GatewayV1beta1().HTTPRoutes("default").Get("abtest", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, route.Spec.Rules, 2)
+
+	matches := route.Spec.Rules[0].Matches
+	require.Len(t, matches, 2)
+	require.Len(t, matches[0].Headers, 2)
+	assert.Equal(t, gatewayv1beta1.HTTPHeaderName("user-agent"), matches[0].Headers[0].Name)
+	assert.Equal(t, gatewayv1beta1.HeaderMatchRegularExpression, *matches[0].Headers[0].Type)
+	assert.Equal(t, "^Mozilla.*", matches[0].Headers[0].Value)
+	assert.Equal(t, gatewayv1beta1.HTTPHeaderName("x-user-type"), matches[0].Headers[1].Name)
+	assert.Equal(t, gatewayv1beta1.HeaderMatchExact, *matches[0].Headers[1].Type)
+	assert.Equal(t, "test", matches[0].Headers[1].Value)
+	require.Len(t, matches[1].QueryParams, 1)
+	assert.Equal(t, gatewayv1beta1.HTTPHeaderName("canary"), matches[1].QueryParams[0].Name)
+	assert.Equal(t, "true", matches[1].QueryParams[0].Value)
+
+	// the default rule routes all traffic to primary
+	require.Len(t, route.Spec.Rules[1].BackendRefs, 1)
+	assert.Equal(t, gatewayv1beta1.ObjectName("abtest-primary"), route.Spec.Rules[1].BackendRefs[0].Name)
+
+	err = router.SetRoutes(canary, 0, 100, false)
+	require.NoError(t, err)
+
+	p, c, _, err := router.GetRoutes(canary)
+	require.NoError(t, err)
+	assert.Equal(t, 0, p)
+	assert.Equal(t, 100, c)
+}
+
+func TestGatewayAPIRouter_Finalize(t *testing.T) {
+	mocks := newFixture(nil)
+	router := &GatewayAPIRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		gatewayClient: mocks.meshClient,
+		kubeClient:    mocks.kubeClient,
+	}
+
+	// an existing HTTPRoute created by the user
+	port := gatewayv1beta1.PortNumber(9898)
+	userSpec := gatewayv1beta1.HTTPRouteSpec{
+		Rules: []gatewayv1beta1.HTTPRouteRule{
+			{
+				BackendRefs: []gatewayv1beta1.HTTPBackendRef{
+					{BackendRef: gatewayv1beta1.BackendRef{
+						BackendObjectReference: gatewayv1beta1.BackendObjectReference{
+							Name: "podinfo",
+							Port: &port,
+						},
+					}},
+				},
+			},
+		},
+	}
+	_, err := mocks.meshClient.GatewayV1beta1().HTTPRoutes("default").Create(&gatewayv1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "default"},
+		Spec:       userSpec,
+	})
+	require.NoError(t, err)
+
+	err = router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	route, err := router.gatewayClient.GatewayV1beta1().HTTPRoutes("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, route.Spec.Rules[0].BackendRefs, 2)
+
+	stored := gatewayv1beta1.HTTPRouteSpec{}
+	require.NoError(t, json.Unmarshal([]byte(route.Annotations[configAnnotation]), &stored))
+	assert.Equal(t, userSpec, stored)
+
+	err = router.Finalize(mocks.canary)
+	require.NoError(t, err)
+
+	route, err = router.gatewayClient.GatewayV1beta1().HTTPRoutes("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, userSpec, route.Spec)
+	assert.NotContains(t, route.Annotations, configAnnotation)
+}
@@ -2,24 +2,38 @@ package router
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
-	"github.com/google/go-cmp/cmp"
 	"go.uber.org/zap"
 	"k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
 )
 
+// ingressV1GVR is the Ingress API served by Kubernetes 1.19 and newer
+var ingressV1GVR = schema.GroupVersionResource{
+	Group:    "networking.k8s.io",
+	Version:  "v1",
+	Resource: "ingresses",
+}
+
 type IngressRouter struct {
 	kubeClient        kubernetes.Interface
+	ingressClient     dynamic.Interface
 	annotationsPrefix string
 	logger            *zap.SugaredLogger
+	ingressV1         bool
 }
 
 func (i *IngressRouter) Reconcile(canary *flaggerv1.Canary) error {
@@ -31,68 +45,60 @@ func (i *IngressRouter) Reconcile(canary *flaggerv1.Canary) error {
 	canaryName := fmt.Sprintf("%s-canary", apexName)
 	canaryIngressName := fmt.Sprintf("%s-canary", canary.Spec.IngressRef.Name)
 
-	ingress, err := i.kubeClient.NetworkingV1beta1().Ingresses(canary.Namespace).Get(canary.Spec.IngressRef.Name, metav1.GetOptions{})
+	ingress, err := i.getIngress(canary.Namespace, canary.Spec.IngressRef.Name)
 	if err != nil {
 		return fmt.Errorf("ingress %s.%s get query error: %w", canary.Spec.IngressRef.Name, canary.Namespace, err)
 	}
 
-	ingressClone := ingress.DeepCopy()
-
-	// change backend to <deployment-name>-canary
-	backendExists := false
-	for k, v := range ingressClone.Spec.Rules {
-		for x, y := range v.HTTP.Paths {
-			if y.Backend.ServiceName == apexName {
-				ingressClone.Spec.Rules[k].HTTP.Paths[x].Backend.ServiceName = canaryName
-				backendExists = true
-				break
-			}
-		}
-	}
-
-	if !backendExists {
+	// change the backends of all rules and hosts to <deployment-name>-canary
+	spec, _, _ := unstructured.NestedMap(ingress.Object, "spec")
+	if !setIngressBackends(spec, apexName, canaryName) {
 		return fmt.Errorf("backend %s not found in ingress %s", apexName, canary.Spec.IngressRef.Name)
 	}
 
-	canaryIngress, err := i.kubeClient.NetworkingV1beta1().Ingresses(canary.Namespace).Get(canaryIngressName, metav1.GetOptions{})
+	canaryIngress, err := i.getIngress(canary.Namespace, canaryIngressName)
 
 	if errors.IsNotFound(err) {
-		ing := &v1beta1.Ingress{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      canaryIngressName,
-				Namespace: canary.Namespace,
-				OwnerReferences: []metav1.OwnerReference{
-					*metav1.NewControllerRef(canary, schema.GroupVersionKind{
-						Group:   flaggerv1.SchemeGroupVersion.Group,
-						Version: flaggerv1.SchemeGroupVersion.Version,
-						Kind:    flaggerv1.CanaryKind,
-					}),
-				},
-				Annotations: i.makeAnnotations(ingressClone.Annotations),
-				Labels:      ingressClone.Labels,
-			},
-			Spec: ingressClone.Spec,
+		ing := &unstructured.Unstructured{}
+		ing.SetAPIVersion(ingress.GetAPIVersion())
+		ing.SetKind("Ingress")
+		ing.SetName(canaryIngressName)
+		ing.SetNamespace(canary.Namespace)
+		ing.SetOwnerReferences([]metav1.OwnerReference{
+			*metav1.NewControllerRef(canary, schema.GroupVersionKind{
+				Group:   flaggerv1.SchemeGroupVersion.Group,
+				Version: flaggerv1.SchemeGroupVersion.Version,
+				Kind:    flaggerv1.CanaryKind,
+			}),
+		})
+		ing.SetAnnotations(i.makeAnnotations(ingress.GetAnnotations()))
+		ing.SetLabels(ingress.GetLabels())
+		if err := unstructured.SetNestedMap(ing.Object, spec, "spec"); err != nil {
+			return fmt.Errorf("ingress %s.%s create error: %w", canaryIngressName, canary.Namespace, err)
 		}
 
-		_, err := i.kubeClient.NetworkingV1beta1().Ingresses(canary.Namespace).Create(ing)
+		err := i.createIngress(ing)
 		if err != nil {
-			return fmt.Errorf("ingress %s.%s create error: %w", ing.Name, ing.Namespace, err)
+			return fmt.Errorf("ingress %s.%s create error: %w", canaryIngressName, canary.Namespace, err)
 		}
 
 		i.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
-			Infof("Ingress %s.%s created", ing.GetName(), canary.Namespace)
+			Infof("Ingress %s.%s created", canaryIngressName, canary.Namespace)
 		return nil
 	} else if err != nil {
 		return fmt.Errorf("ingress %s.%s query error: %w", canaryIngressName, canary.Namespace, err)
 	}
 
-	if diff := cmp.Diff(ingressClone.Spec, canaryIngress.Spec); diff != "" {
+	canarySpec, _, _ := unstructured.NestedMap(canaryIngress.Object, "spec")
+	if !equality.Semantic.DeepEqual(spec, canarySpec) {
 		iClone := canaryIngress.DeepCopy()
-		iClone.Spec = ingressClone.Spec
+		if err := unstructured.SetNestedMap(iClone.Object, spec, "spec"); err != nil {
+			return fmt.Errorf("ingress %s.%s update error: %w", canaryIngressName, iClone.GetNamespace(), err)
+		}
 
-		_, err := i.kubeClient.NetworkingV1beta1().Ingresses(canary.Namespace).Update(iClone)
+		err := i.updateIngress(iClone)
 		if err != nil {
-			return fmt.Errorf("ingress %s.%s update error: %w", canaryIngressName, iClone.Namespace, err)
+			return fmt.Errorf("ingress %s.%s update error: %w", canaryIngressName, iClone.GetNamespace(), err)
 		}
 
 		i.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
@@ -109,7 +115,7 @@ func (i *IngressRouter) GetRoutes(canary *flaggerv1.Canary) (
 	err error,
 ) {
 	canaryIngressName := fmt.Sprintf("%s-canary", canary.Spec.IngressRef.Name)
-	canaryIngress, err := i.kubeClient.NetworkingV1beta1().Ingresses(canary.Namespace).Get(canaryIngressName, metav1.GetOptions{})
+	canaryIngress, err := i.getIngress(canary.Namespace, canaryIngressName)
 	if err != nil {
 		err = fmt.Errorf("ingress %s.%s get query error: %w", canaryIngressName, canary.Namespace, err)
 		return
@@ -117,7 +123,7 @@ func (i *IngressRouter) GetRoutes(canary *flaggerv1.Canary) (
 
 	// A/B testing
 	if len(canary.GetAnalysis().Match) > 0 {
-		for k := range canaryIngress.Annotations {
+		for k := range canaryIngress.GetAnnotations() {
 			if k == i.GetAnnotationWithPrefix("canary-by-cookie") || k == i.GetAnnotationWithPrefix("canary-by-header") {
 				return 0, 100, false, nil
 			}
@@ -125,7 +131,7 @@ func (i *IngressRouter) GetRoutes(canary *flaggerv1.Canary) (
 	}
 
 	// Canary
-	for k, v := range canaryIngress.Annotations {
+	for k, v := range canaryIngress.GetAnnotations() {
 		if k == i.GetAnnotationWithPrefix("canary-weight") {
 			val, errAtoi := strconv.Atoi(v)
 			if errAtoi != nil {
@@ -150,43 +156,36 @@ func (i *IngressRouter) SetRoutes(
 	_ bool,
 ) error {
 	canaryIngressName := fmt.Sprintf("%s-canary", canary.Spec.IngressRef.Name)
-	canaryIngress, err := i.kubeClient.NetworkingV1beta1().Ingresses(canary.Namespace).Get(canaryIngressName, metav1.GetOptions{})
+	canaryIngress, err := i.getIngress(canary.Namespace, canaryIngressName)
 	if err != nil {
 		return fmt.Errorf("ingress %s.%s get query error: %w", canaryIngressName, canary.Namespace, err)
 	}
 
 	iClone := canaryIngress.DeepCopy()
+	annotations := iClone.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
 
 	// A/B testing
 	if len(canary.GetAnalysis().Match) > 0 {
-		var cookie, header, headerValue string
-		for _, m := range canary.GetAnalysis().Match {
-			for k, v := range m.Headers {
-				if k == "cookie" {
-					cookie = v.Exact
-				} else {
-					header = k
-					headerValue = v.Exact
-				}
-			}
-		}
-
-		iClone.Annotations = i.makeHeaderAnnotations(iClone.Annotations, header, headerValue, cookie)
+		annotations = i.makeHeaderAnnotations(annotations, canary.GetAnalysis().Match)
 	} else {
 		// canary
-		iClone.Annotations[i.GetAnnotationWithPrefix("canary-weight")] = fmt.Sprintf("%v", canaryWeight)
+		annotations[i.GetAnnotationWithPrefix("canary-weight")] = fmt.Sprintf("%v", canaryWeight)
 	}
 
 	// toggle canary
 	if canaryWeight > 0 {
-		iClone.Annotations[i.GetAnnotationWithPrefix("canary")] = "true"
+		annotations[i.GetAnnotationWithPrefix("canary")] = "true"
 	} else {
-		iClone.Annotations = i.makeAnnotations(iClone.Annotations)
+		annotations = i.makeAnnotations(annotations)
 	}
+	iClone.SetAnnotations(annotations)
 
-	_, err = i.kubeClient.NetworkingV1beta1().Ingresses(canary.Namespace).Update(iClone)
+	err = i.updateIngress(iClone)
 	if err != nil {
-		return fmt.Errorf("ingress %s.%s update error %v", iClone.Name, iClone.Namespace, err)
+		return fmt.Errorf("ingress %s.%s update error %v", iClone.GetName(), iClone.GetNamespace(), err)
 	}
 
 	return nil
@@ -207,11 +206,13 @@ func (i *IngressRouter) makeAnnotations(annotations map[string]string) map[strin
 	return res
 }
 
+// makeHeaderAnnotations maps the analysis match conditions to the NGINX canary annotations,
+// NGINX supports a single header and a single cookie, the first ones found are used
 func (i *IngressRouter) makeHeaderAnnotations(annotations map[string]string,
-	header string, headerValue string, cookie string) map[string]string {
+	match []istiov1alpha3.HTTPMatchRequest) map[string]string {
 	res := make(map[string]string)
 	for k, v := range annotations {
-		if !strings.Contains(v, i.GetAnnotationWithPrefix("canary")) {
+		if !strings.Contains(k, i.GetAnnotationWithPrefix("canary")) {
 			res[k] = v
 		}
 	}
@@ -219,16 +220,34 @@ func (i *IngressRouter) makeHeaderAnnotations(annotations map[string]string,
 	res[i.GetAnnotationWithPrefix("canary")] = "true"
 	res[i.GetAnnotationWithPrefix("canary-weight")] = "0"
 
-	if cookie != "" {
-		res[i.GetAnnotationWithPrefix("canary-by-cookie")] = cookie
-	}
-
-	if header != "" {
-		res[i.GetAnnotationWithPrefix("canary-by-header")] = header
-	}
+	var cookie, header string
+	for _, m := range match {
+		for _, k := range sortedKeys(m.Headers) {
+			v := m.Headers[k]
+			if k == "cookie" {
+				if cookie == "" && v.Exact != "" {
+					cookie = v.Exact
+					res[i.GetAnnotationWithPrefix("canary-by-cookie")] = cookie
+				}
+				continue
+			}
+			if header != "" {
+				continue
+			}
 
-	if headerValue != "" {
-		res[i.GetAnnotationWithPrefix("canary-by-header-value")] = headerValue
+			header = k
+			res[i.GetAnnotationWithPrefix("canary-by-header")] = header
+			switch {
+			case v.Exact != "":
+				res[i.GetAnnotationWithPrefix("canary-by-header-value")] = v.Exact
+			case v.Prefix != "":
+				res[i.GetAnnotationWithPrefix("canary-by-header-pattern")] = "^" + regexp.QuoteMeta(v.Prefix) + ".*"
+			case v.Suffix != "":
+				res[i.GetAnnotationWithPrefix("canary-by-header-pattern")] = ".*" + regexp.QuoteMeta(v.Suffix) + "$"
+			case v.Regex != "":
+				res[i.GetAnnotationWithPrefix("canary-by-header-pattern")] = v.Regex
+			}
+		}
 	}
 
 	return res
@@ -241,3 +260,99 @@ func (i *IngressRouter) GetAnnotationWithPrefix(suffix string) string {
 func (i *IngressRouter) Finalize(_ *flaggerv1.Canary) error {
 	return nil
 }
+
+func (i *IngressRouter) getIngress(namespace string, name string) (*unstructured.Unstructured, error) {
+	if i.ingressV1 {
+		return i.ingressClient.Resource(ingressV1GVR).Namespace(namespace).Get(name, metav1.GetOptions{})
+	}
+
+	ingress, err := i.kubeClient.NetworkingV1beta1().Ingresses(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ingress)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: obj}
+	u.SetAPIVersion(v1beta1.SchemeGroupVersion.String())
+	u.SetKind("Ingress")
+	return u, nil
+}
+
+func (i *IngressRouter) createIngress(ingress *unstructured.Unstructured) error {
+	if i.ingressV1 {
+		_, err := i.ingressClient.Resource(ingressV1GVR).Namespace(ingress.GetNamespace()).Create(ingress, metav1.CreateOptions{})
+		return err
+	}
+
+	ing := &v1beta1.Ingress{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(ingress.Object, ing); err != nil {
+		return err
+	}
+	_, err := i.kubeClient.NetworkingV1beta1().Ingresses(ing.Namespace).Create(ing)
+	return err
+}
+
+func (i *IngressRouter) updateIngress(ingress *unstructured.Unstructured) error {
+	if i.ingressV1 {
+		_, err := i.ingressClient.Resource(ingressV1GVR).Namespace(ingress.GetNamespace()).Update(ingress, metav1.UpdateOptions{})
+		return err
+	}
+
+	ing := &v1beta1.Ingress{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(ingress.Object, ing); err != nil {
+		return err
+	}
+	_, err := i.kubeClient.NetworkingV1beta1().Ingresses(ing.Namespace).Update(ing)
+	return err
+}
+
+// setIngressBackends replaces the apex service with the canary service in the default backend
+// and in the paths of all rules, it handles both the v1beta1 and the v1 backend formats
+func setIngressBackends(spec map[string]interface{}, apexName string, canaryName string) bool {
+	found := false
+	for _, field := range []string{"backend", "defaultBackend"} {
+		if backend, ok := spec[field].(map[string]interface{}); ok {
+			found = setIngressBackend(backend, apexName, canaryName) || found
+		}
+	}
+
+	rules, _ := spec["rules"].([]interface{})
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		paths, _, _ := unstructured.NestedSlice(rule, "http", "paths")
+		for _, p := range paths {
+			path, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if backend, ok := path["backend"].(map[string]interface{}); ok {
+				found = setIngressBackend(backend, apexName, canaryName) || found
+			}
+		}
+		if len(paths) > 0 {
+			_ = unstructured.SetNestedSlice(rule, paths, "http", "paths")
+		}
+	}
+
+	return found
+}
+
+func setIngressBackend(backend map[string]interface{}, apexName string, canaryName string) bool {
+	// networking.k8s.io/v1beta1
+	if backend["serviceName"] == apexName {
+		backend["serviceName"] = canaryName
+		return true
+	}
+
+	// networking.k8s.io/v1
+	if service, ok := backend["service"].(map[string]interface{}); ok && service["name"] == apexName {
+		service["name"] = canaryName
+		return true
+	}
+	return false
+}
@@ -8,6 +8,13 @@ import (
 	"github.com/stretchr/testify/require"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	istiov1alpha1 "github.com/weaveworks/flagger/pkg/apis/istio/common/v1alpha1"
+	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
 )
 
 func TestIngressRouter_Reconcile(t *testing.T) {
@@ -79,3 +86,164 @@ func TestIngressRouter_GetSetRoutes(t *testing.T) {
 	assert.Equal(t, "false", inCanary.Annotations[canaryAn])
 	assert.Equal(t, "0", inCanary.Annotations[canaryWeightAn])
 }
+
+func TestIngressRouter_ABTest(t *testing.T) {
+	mocks := newFixture(nil)
+	router := &IngressRouter{
+		logger:            mocks.logger,
+		kubeClient:        mocks.kubeClient,
+		annotationsPrefix: "nginx.ingress.kubernetes.io",
+	}
+
+	canary := mocks.ingressCanary.DeepCopy()
+	canary.Spec.Analysis.Match = []istiov1alpha3.HTTPMatchRequest{
+		{
+			Headers: map[string]istiov1alpha1.StringMatch{
+				"x-user-type": {Prefix: "insider"},
+			},
+		},
+		{
+			Headers: map[string]istiov1alpha1.StringMatch{
+				"cookie": {Exact: "canary"},
+			},
+		},
+	}
+
+	err := router.Reconcile(canary)
+	require.NoError(t, err)
+
+	err = router.SetRoutes(canary, 0, 100, false)
+	require.NoError(t, err)
+
+	inCanary, err := router.kubeClient.NetworkingV1beta1().Ingresses("default").Get("podinfo-canary", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "true", inCanary.Annotations["nginx.ingress.kubernetes.io/canary"])
+	assert.Equal(t, "x-user-type", inCanary.Annotations["nginx.ingress.kubernetes.io/canary-by-header"])
+	assert.Equal(t, "^insider.*", inCanary.Annotations["nginx.ingress.kubernetes.io/canary-by-header-pattern"])
+	assert.Equal(t, "canary", inCanary.Annotations["nginx.ingress.kubernetes.io/canary-by-cookie"])
+	assert.NotContains(t, inCanary.Annotations, "nginx.ingress.kubernetes.io/canary-by-header-value")
+
+	p, c, _, err := router.GetRoutes(canary)
+	require.NoError(t, err)
+	assert.Equal(t, 0, p)
+	assert.Equal(t, 100, c)
+
+	// test promotion removes the match annotations
+	err = router.SetRoutes(canary, 100, 0, false)
+	require.NoError(t, err)
+
+	inCanary, err = router.kubeClient.NetworkingV1beta1().Ingresses("default").Get("podinfo-canary", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "false", inCanary.Annotations["nginx.ingress.kubernetes.io/canary"])
+	assert.NotContains(t, inCanary.Annotations, "nginx.ingress.kubernetes.io/canary-by-header")
+	assert.NotContains(t, inCanary.Annotations, "nginx.ingress.kubernetes.io/canary-by-cookie")
+}
+
+func TestIngressRouter_V1(t *testing.T) {
+	mocks := newFixture(nil)
+
+	ingress := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "Ingress",
+		"metadata": map[string]interface{}{
+			"name":      "podinfo",
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"host": "app.example.com",
+					"http": map[string]interface{}{
+						"paths": []interface{}{
+							map[string]interface{}{
+								"path":     "/",
+								"pathType": "Prefix",
+								"backend": map[string]interface{}{
+									"service": map[string]interface{}{
+										"name": "podinfo",
+										"port": map[string]interface{}{"number": int64(9898)},
+									},
+								},
+							},
+						},
+					},
+				},
+				map[string]interface{}{
+					"host": "app.example.org",
+					"http": map[string]interface{}{
+						"paths": []interface{}{
+							map[string]interface{}{
+								"path":     "/api",
+								"pathType": "Prefix",
+								"backend": map[string]interface{}{
+									"service": map[string]interface{}{
+										"name": "podinfo",
+										"port": map[string]interface{}{"name": "http"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+
+	router := &IngressRouter{
+		logger:            mocks.logger,
+		kubeClient:        mocks.kubeClient,
+		ingressClient:     fakeDynamic.NewSimpleDynamicClient(runtime.NewScheme(), ingress),
+		annotationsPrefix: "nginx.ingress.kubernetes.io",
+		ingressV1:         true,
+	}
+
+	err := router.Reconcile(mocks.ingressCanary)
+	require.NoError(t, err)
+
+	inCanary, err := router.ingressClient.Resource(ingressV1GVR).Namespace("default").Get("podinfo-canary", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "false", inCanary.GetAnnotations()["nginx.ingress.kubernetes.io/canary"])
+
+	// test all rules and hosts point to canary
+	rules, _, _ := unstructured.NestedSlice(inCanary.Object, "spec", "rules")
+	require.Len(t, rules, 2)
+	for _, r := range rules {
+		paths, _, _ := unstructured.NestedSlice(r.(map[string]interface{}), "http", "paths")
+		name, _, _ := unstructured.NestedString(paths[0].(map[string]interface{}), "backend", "service", "name")
+		assert.Equal(t, "podinfo-canary", name)
+	}
+
+	err = router.SetRoutes(mocks.ingressCanary, 60, 40, false)
+	require.NoError(t, err)
+
+	p, c, _, err := router.GetRoutes(mocks.ingressCanary)
+	require.NoError(t, err)
+	assert.Equal(t, 60, p)
+	assert.Equal(t, 40, c)
+
+	// the v1beta1 API is not used
+	_, err = mocks.kubeClient.NetworkingV1beta1().Ingresses("default").Get("podinfo-canary", metav1.GetOptions{})
+	require.Error(t, err)
+}
+
+func TestFactory_IngressV1(t *testing.T) {
+	mocks := newFixture(nil)
+	mocks.kubeClient.(*fake.Clientset).Fake.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "networking.k8s.io/v1",
+			APIResources: []metav1.APIResource{{Name: "ingresses", Kind: "Ingress", Namespaced: true}},
+		},
+	}
+
+	factory := NewFactory(nil, mocks.kubeClient, mocks.flaggerClient, "", mocks.logger, mocks.meshClient, nil, nil)
+	assert.False(t, factory.isIngressV1())
+
+	factory = NewFactory(nil, mocks.kubeClient, mocks.flaggerClient, "", mocks.logger, mocks.meshClient, nil, nil)
+	factory.dynamicClient = fakeDynamic.NewSimpleDynamicClient(runtime.NewScheme())
+	router := factory.innerMeshRouter("nginx").(*IngressRouter)
+	assert.True(t, router.ingressV1)
+
+	// the discovery result is cached by the factory
+	mocks.kubeClient.(*fake.Clientset).Fake.Resources = nil
+	assert.True(t, factory.isIngressV1())
+}
@@ -10,6 +10,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
 	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
@@ -21,26 +22,47 @@ import (
 type IstioRouter struct {
 	kubeClient    kubernetes.Interface
 	istioClient   clientset.Interface
+	dynamicClient dynamic.Interface
 	flaggerClient clientset.Interface
 	logger        *zap.SugaredLogger
+	applier       *serverSideApplier
 }
 
+var (
+	istioDestinationRuleGVR = schema.GroupVersionResource{
+		Group:    "networking.istio.io",
+		Version:  "v1alpha3",
+		Resource: "destinationrules",
+	}
+)
+
 // Reconcile creates or updates the Istio virtual service and destination rules
 func (ir *IstioRouter) Reconcile(canary *flaggerv1.Canary) error {
 	_, primaryName, canaryName := canary.GetServiceNames()
 
-	if err := ir.reconcileDestinationRule(canary, canaryName); err != nil {
-		return fmt.Errorf("reconcileDestinationRule failed: %w", err)
+	// the reasserted drifts are reported after all objects are reconciled
+	var drift error
+	for _, name := range []string{canaryName, primaryName} {
+		if err := ir.reconcileDestinationRule(canary, name); err != nil {
+			if !IsReasserted(err) {
+				return fmt.Errorf("reconcileDestinationRule failed: %w", err)
+			}
+			drift = err
+		}
 	}
 
-	if err := ir.reconcileDestinationRule(canary, primaryName); err != nil {
-		return fmt.Errorf("reconcileDestinationRule failed: %w", err)
+	// patch the referenced route instead of generating a virtual service
+	if canary.Spec.Service.VirtualServiceRef != nil {
+		if err := ir.reconcileVirtualServiceRoute(canary); err != nil {
+			return fmt.Errorf("reconcileVirtualServiceRoute failed: %w", err)
+		}
+		return drift
 	}
 
 	if err := ir.reconcileVirtualService(canary); err != nil {
 		return fmt.Errorf("reconcileVirtualService failed: %w", err)
 	}
-	return nil
+	return drift
 }
 
 func (ir *IstioRouter) reconcileDestinationRule(canary *flaggerv1.Canary, name string) error {
@@ -49,6 +71,10 @@ func (ir *IstioRouter) reconcileDestinationRule(canary *flaggerv1.Canary, name s
 		TrafficPolicy: canary.Spec.Service.TrafficPolicy,
 	}
 
+	if ir.applier != nil {
+		return ir.applyDestinationRule(canary, name, newSpec)
+	}
+
 	destinationRule, err := ir.istioClient.NetworkingV1alpha3().DestinationRules(canary.Namespace).Get(name, metav1.GetOptions{})
 	// insert
 	if errors.IsNotFound(err) {
@@ -95,83 +121,14 @@ func (ir *IstioRouter) reconcileDestinationRule(canary *flaggerv1.Canary, name s
 }
 
 func (ir *IstioRouter) reconcileVirtualService(canary *flaggerv1.Canary) error {
-	apexName, primaryName, canaryName := canary.GetServiceNames()
-
-	// set hosts and add the ClusterIP service host if it doesn't exists
-	hosts := canary.Spec.Service.Hosts
-	var hasServiceHost bool
-	for _, h := range hosts {
-		if h == apexName || h == "*" {
-			hasServiceHost = true
-			break
-		}
-	}
-	if !hasServiceHost {
-		hosts = append(hosts, apexName)
-	}
-
-	// set gateways and add the mesh gateway if it doesn't exists
-	gateways := canary.Spec.Service.Gateways
-	var hasMeshGateway bool
-	for _, g := range gateways {
-		if g == "mesh" {
-			hasMeshGateway = true
-			break
-		}
-	}
+	apexName, _, _ := canary.GetServiceNames()
 
-	// set default mesh gateway if no gateway is specified
-	if !hasMeshGateway && len(canary.Spec.Service.Gateways) == 0 {
-		gateways = append(gateways, "mesh")
+	if ir.applier != nil {
+		return ir.applyVirtualService(canary, nil, false)
 	}
 
 	// create destinations with primary weight 100% and canary weight 0%
-	canaryRoute := []istiov1alpha3.DestinationWeight{
-		makeDestination(canary, primaryName, 100),
-		makeDestination(canary, canaryName, 0),
-	}
-
-	newSpec := istiov1alpha3.VirtualServiceSpec{
-		Hosts:    hosts,
-		Gateways: gateways,
-		Http: []istiov1alpha3.HTTPRoute{
-			{
-				Match:      canary.Spec.Service.Match,
-				Rewrite:    canary.Spec.Service.Rewrite,
-				Timeout:    canary.Spec.Service.Timeout,
-				Retries:    canary.Spec.Service.Retries,
-				CorsPolicy: canary.Spec.Service.CorsPolicy,
-				Headers:    canary.Spec.Service.Headers,
-				Route:      canaryRoute,
-			},
-		},
-	}
-
-	if len(canary.GetAnalysis().Match) > 0 {
-		canaryMatch := mergeMatchConditions(canary.GetAnalysis().Match, canary.Spec.Service.Match)
-		newSpec.Http = []istiov1alpha3.HTTPRoute{
-			{
-				Match:      canaryMatch,
-				Rewrite:    canary.Spec.Service.Rewrite,
-				Timeout:    canary.Spec.Service.Timeout,
-				Retries:    canary.Spec.Service.Retries,
-				CorsPolicy: canary.Spec.Service.CorsPolicy,
-				Headers:    canary.Spec.Service.Headers,
-				Route:      canaryRoute,
-			},
-			{
-				Match:      canary.Spec.Service.Match,
-				Rewrite:    canary.Spec.Service.Rewrite,
-				Timeout:    canary.Spec.Service.Timeout,
-				Retries:    canary.Spec.Service.Retries,
-				CorsPolicy: canary.Spec.Service.CorsPolicy,
-				Headers:    canary.Spec.Service.Headers,
-				Route: []istiov1alpha3.DestinationWeight{
-					makeDestination(canary, primaryName, 100),
-				},
-			},
-		}
-	}
+	newSpec := makeVirtualServiceSpec(canary, 100, 0, false)
 
 	virtualService, err := ir.istioClient.NetworkingV1alpha3().VirtualServices(canary.Namespace).Get(apexName, metav1.GetOptions{})
 	// insert
@@ -247,42 +204,18 @@ func (ir *IstioRouter) GetRoutes(canary *flaggerv1.Canary) (
 	mirrored bool,
 	err error,
 ) {
-	apexName, primaryName, canaryName := canary.GetServiceNames()
-	vs := &istiov1alpha3.VirtualService{}
-	vs, err = ir.istioClient.NetworkingV1alpha3().VirtualServices(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if canary.Spec.Service.VirtualServiceRef != nil {
+		return ir.getRouteRefWeights(canary)
+	}
+
+	apexName, _, _ := canary.GetServiceNames()
+	vs, err := ir.istioClient.NetworkingV1alpha3().VirtualServices(canary.Namespace).Get(apexName, metav1.GetOptions{})
 	if err != nil {
 		err = fmt.Errorf("VirtualService %s.%s get query error %v", apexName, canary.Namespace, err)
 		return
 	}
 
-	var httpRoute istiov1alpha3.HTTPRoute
-	for _, http := range vs.Spec.Http {
-		for _, r := range http.Route {
-			if r.Destination.Host == canaryName {
-				httpRoute = http
-				break
-			}
-		}
-	}
-
-	for _, route := range httpRoute.Route {
-		if route.Destination.Host == primaryName {
-			primaryWeight = route.Weight
-		}
-		if route.Destination.Host == canaryName {
-			canaryWeight = route.Weight
-		}
-	}
-	if httpRoute.Mirror != nil && httpRoute.Mirror.Host != "" {
-		mirrored = true
-	}
-
-	if primaryWeight == 0 && canaryWeight == 0 {
-		err = fmt.Errorf("VirtualService %s.%s does not contain routes for %s-primary and %s-canary",
-			apexName, canary.Namespace, apexName, apexName)
-	}
-
-	return
+	return getVirtualServiceWeights(canary, vs.Spec)
 }
 
 // SetRoutes updates the destinations weight for primary and canary
@@ -292,46 +225,155 @@ func (ir *IstioRouter) SetRoutes(
 	canaryWeight int,
 	mirrored bool,
 ) error {
-	apexName, primaryName, canaryName := canary.GetServiceNames()
+	if canary.Spec.Service.VirtualServiceRef != nil {
+		return ir.setRouteRefWeights(canary, primaryWeight, canaryWeight, mirrored)
+	}
+
+	if ir.applier != nil {
+		return ir.applyVirtualService(canary, &istioRoutes{primaryWeight, canaryWeight, mirrored}, true)
+	}
+
+	apexName, _, _ := canary.GetServiceNames()
 
 	vs, err := ir.istioClient.NetworkingV1alpha3().VirtualServices(canary.Namespace).Get(apexName, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("VirtualService %s.%s get query error %v", apexName, canary.Namespace, err)
 	}
 
+	newSpec := makeVirtualServiceSpec(canary, primaryWeight, canaryWeight, mirrored)
 	vsCopy := vs.DeepCopy()
+	vsCopy.Spec.Http = newSpec.Http
+	vsCopy.Spec.Tcp = newSpec.Tcp
+	vsCopy.Spec.Tls = newSpec.Tls
+
+	_, err = ir.istioClient.NetworkingV1alpha3().VirtualServices(canary.Namespace).Update(vsCopy)
+	if err != nil {
+		return fmt.Errorf("VirtualService %s.%s update failed: %w", apexName, canary.Namespace, err)
+	}
+	return nil
+}
+
+func (ir *IstioRouter) Finalize(canary *flaggerv1.Canary) error {
+	if canary.Spec.Service.VirtualServiceRef != nil {
+		return ir.finalizeRouteRef(canary)
+	}
+
+	// Need to see if I can get the annotation orig-configuration
+	apexName, _, _ := canary.GetServiceNames()
+
+	vs, err := ir.istioClient.NetworkingV1alpha3().VirtualServices(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("VirtualService %s.%s get query error: %w", apexName, canary.Namespace, err)
+	}
+
+	var storedSpec istiov1alpha3.VirtualServiceSpec
+	if a, ok := vs.ObjectMeta.Annotations[kubectlAnnotation]; ok {
+		var storedVS istiov1alpha3.VirtualService
+		if err := json.Unmarshal([]byte(a), &storedVS); err != nil {
+			return fmt.Errorf("VirtualService %s.%s failed to unMarshal annotation %s",
+				apexName, canary.Namespace, kubectlAnnotation)
+		}
+		storedSpec = storedVS.Spec
+	} else if a, ok := vs.ObjectMeta.Annotations[configAnnotation]; ok {
+		if err := json.Unmarshal([]byte(a), &storedSpec); err != nil {
+			return fmt.Errorf("VirtualService %s.%s failed to unMarshal annotation %s",
+				apexName, canary.Namespace, configAnnotation)
+		}
+	} else {
+		ir.logger.Warnf("VirtualService %s.%s original configuration not found, unable to revert", apexName, canary.Namespace)
+		return nil
+	}
+
+	clone := vs.DeepCopy()
+	clone.Spec = storedSpec
+
+	_, err = ir.istioClient.NetworkingV1alpha3().VirtualServices(canary.Namespace).Update(clone)
+	if err != nil {
+		return fmt.Errorf("VirtualService %s.%s update error: %w", apexName, canary.Namespace, err)
+	}
+	return nil
+}
+
+// makeVirtualServiceSpec returns the virtual service spec with the specified weights
+func makeVirtualServiceSpec(canary *flaggerv1.Canary, primaryWeight int, canaryWeight int, mirrored bool) istiov1alpha3.VirtualServiceSpec {
+	apexName, primaryName, canaryName := canary.GetServiceNames()
 
-	// weighted routing (progressive canary)
-	vsCopy.Spec.Http = []istiov1alpha3.HTTPRoute{
-		{
-			Match:      canary.Spec.Service.Match,
-			Rewrite:    canary.Spec.Service.Rewrite,
-			Timeout:    canary.Spec.Service.Timeout,
-			Retries:    canary.Spec.Service.Retries,
-			CorsPolicy: canary.Spec.Service.CorsPolicy,
-			Headers:    canary.Spec.Service.Headers,
-			Route: []istiov1alpha3.DestinationWeight{
-				makeDestination(canary, primaryName, primaryWeight),
-				makeDestination(canary, canaryName, canaryWeight),
+	// set hosts and add the ClusterIP service host if it doesn't exists
+	hosts := canary.Spec.Service.Hosts
+	var hasServiceHost bool
+	for _, h := range hosts {
+		if h == apexName || h == "*" {
+			hasServiceHost = true
+			break
+		}
+	}
+	if !hasServiceHost {
+		hosts = append(hosts, apexName)
+	}
+
+	// set gateways and add the mesh gateway if it doesn't exists
+	gateways := canary.Spec.Service.Gateways
+	var hasMeshGateway bool
+	for _, g := range gateways {
+		if g == "mesh" {
+			hasMeshGateway = true
+			break
+		}
+	}
+
+	// set default mesh gateway if no gateway is specified
+	if !hasMeshGateway && len(canary.Spec.Service.Gateways) == 0 {
+		gateways = append(gateways, "mesh")
+	}
+
+	// delegate virtual services are bound to hosts and gateways by the root virtual service
+	if canary.Spec.Service.Delegation {
+		hosts = nil
+		gateways = nil
+	}
+
+	canaryRoute := []istiov1alpha3.DestinationWeight{
+		makeDestination(canary, primaryName, primaryWeight),
+		makeDestination(canary, canaryName, canaryWeight),
+	}
+
+	newSpec := istiov1alpha3.VirtualServiceSpec{
+		Hosts:    hosts,
+		Gateways: gateways,
+		Http: []istiov1alpha3.HTTPRoute{
+			{
+				Match:      canary.Spec.Service.Match,
+				Rewrite:    canary.Spec.Service.Rewrite,
+				Timeout:    canary.Spec.Service.Timeout,
+				Retries:    canary.Spec.Service.Retries,
+				CorsPolicy: canary.Spec.Service.CorsPolicy,
+				Headers:    canary.Spec.Service.Headers,
+				Route:      canaryRoute,
 			},
 		},
 	}
 
+	// weighted routing of TCP connections (mirroring and A/B testing are not available)
+	if canary.IsL4() {
+		newSpec.Http = nil
+		setL4Routes(canary, &newSpec, canaryRoute)
+		return newSpec
+	}
+
 	if mirrored {
-		vsCopy.Spec.Http[0].Mirror = &istiov1alpha3.Destination{
+		newSpec.Http[0].Mirror = &istiov1alpha3.Destination{
 			Host: canaryName,
 		}
 
 		if mw := canary.GetAnalysis().MirrorWeight; mw > 0 {
-			vsCopy.Spec.Http[0].MirrorPercentage = &istiov1alpha3.Percent{Value: float64(mw)}
+			newSpec.Http[0].MirrorPercentage = &istiov1alpha3.Percent{Value: float64(mw)}
 		}
 	}
 
 	// fix routing (A/B testing)
 	if len(canary.GetAnalysis().Match) > 0 {
-		// merge the common routes with the canary ones
 		canaryMatch := mergeMatchConditions(canary.GetAnalysis().Match, canary.Spec.Service.Match)
-		vsCopy.Spec.Http = []istiov1alpha3.HTTPRoute{
+		newSpec.Http = []istiov1alpha3.HTTPRoute{
 			{
 				Match:      canaryMatch,
 				Rewrite:    canary.Spec.Service.Rewrite,
@@ -339,10 +381,7 @@ func (ir *IstioRouter) SetRoutes(
 				Retries:    canary.Spec.Service.Retries,
 				CorsPolicy: canary.Spec.Service.CorsPolicy,
 				Headers:    canary.Spec.Service.Headers,
-				Route: []istiov1alpha3.DestinationWeight{
-					makeDestination(canary, primaryName, primaryWeight),
-					makeDestination(canary, canaryName, canaryWeight),
-				},
+				Route:      canaryRoute,
 			},
 			{
 				Match:      canary.Spec.Service.Match,
@@ -358,48 +397,133 @@ func (ir *IstioRouter) SetRoutes(
 		}
 	}
 
-	vs, err = ir.istioClient.NetworkingV1alpha3().VirtualServices(canary.Namespace).Update(vsCopy)
-	if err != nil {
-		return fmt.Errorf("VirtualService %s.%s update failed: %w", apexName, canary.Namespace, err)
+	return newSpec
+}
+
+// getVirtualServiceWeights returns the destinations weight of the route that contains the canary host
+func getVirtualServiceWeights(canary *flaggerv1.Canary, spec istiov1alpha3.VirtualServiceSpec) (
+	primaryWeight int,
+	canaryWeight int,
+	mirrored bool,
+	err error,
+) {
+	apexName, primaryName, canaryName := canary.GetServiceNames()
+
+	if canary.IsL4() {
+		for _, route := range getL4Routes(canary, spec, canaryName) {
+			if route.Destination.Host == primaryName {
+				primaryWeight = route.Weight
+			}
+			if route.Destination.Host == canaryName {
+				canaryWeight = route.Weight
+			}
+		}
+
+		if primaryWeight == 0 && canaryWeight == 0 {
+			err = fmt.Errorf("VirtualService %s.%s does not contain %s routes for %s-primary and %s-canary",
+				apexName, canary.Namespace, canary.GetAppProtocol(), apexName, apexName)
+		}
+		return
 	}
-	return nil
+
+	var httpRoute istiov1alpha3.HTTPRoute
+	for _, http := range spec.Http {
+		for _, r := range http.Route {
+			if r.Destination.Host == canaryName {
+				httpRoute = http
+				break
+			}
+		}
+	}
+
+	for _, route := range httpRoute.Route {
+		if route.Destination.Host == primaryName {
+			primaryWeight = route.Weight
+		}
+		if route.Destination.Host == canaryName {
+			canaryWeight = route.Weight
+		}
+	}
+	if httpRoute.Mirror != nil && httpRoute.Mirror.Host != "" {
+		mirrored = true
+	}
+
+	if primaryWeight == 0 && canaryWeight == 0 {
+		err = fmt.Errorf("VirtualService %s.%s does not contain routes for %s-primary and %s-canary",
+			apexName, canary.Namespace, apexName, apexName)
+	}
+
+	return
 }
 
-func (ir *IstioRouter) Finalize(canary *flaggerv1.Canary) error {
-	// Need to see if I can get the annotation orig-configuration
+// istioRoutes are the destinations weight and mirroring applied to the virtual service
+type istioRoutes struct {
+	primaryWeight int
+	canaryWeight  int
+	mirrored      bool
+}
+
+func (ir *IstioRouter) applyDestinationRule(canary *flaggerv1.Canary, name string, spec istiov1alpha3.DestinationRuleSpec) error {
+	var live metav1.Object
+	destinationRule, err := ir.istioClient.NetworkingV1alpha3().DestinationRules(canary.Namespace).Get(name, metav1.GetOptions{})
+	if err == nil {
+		live = destinationRule
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("DestinationRule %s.%s get query error: %w", name, canary.Namespace, err)
+	}
+
+	obj := &istiov1alpha3.DestinationRule{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: istiov1alpha3.SchemeGroupVersion.String(),
+			Kind:       "DestinationRule",
+		},
+		ObjectMeta: makeApplyObjectMeta(canary, name, live),
+		Spec:       spec,
+	}
+	return ir.applier.apply(canary, istioDestinationRuleGVR, obj, live, false)
+}
+
+// applyVirtualService applies the generated virtual service with server-side apply,
+// the weights are taken from the canary status when no routes are specified
+func (ir *IstioRouter) applyVirtualService(canary *flaggerv1.Canary, routes *istioRoutes, force bool) error {
 	apexName, _, _ := canary.GetServiceNames()
 
-	vs, err := ir.istioClient.NetworkingV1alpha3().VirtualServices(canary.Namespace).Get(apexName, metav1.GetOptions{})
-	if err != nil {
+	var live metav1.Object
+	virtualService, err := ir.istioClient.NetworkingV1alpha3().VirtualServices(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if err == nil {
+		live = virtualService
+	} else if !errors.IsNotFound(err) {
 		return fmt.Errorf("VirtualService %s.%s get query error: %w", apexName, canary.Namespace, err)
 	}
 
-	var storedSpec istiov1alpha3.VirtualServiceSpec
-	if a, ok := vs.ObjectMeta.Annotations[kubectlAnnotation]; ok {
-		var storedVS istiov1alpha3.VirtualService
-		if err := json.Unmarshal([]byte(a), &storedVS); err != nil {
-			return fmt.Errorf("VirtualService %s.%s failed to unMarshal annotation %s",
-				apexName, canary.Namespace, kubectlAnnotation)
-		}
-		storedSpec = storedVS.Spec
-	} else if a, ok := vs.ObjectMeta.Annotations[configAnnotation]; ok {
-		if err := json.Unmarshal([]byte(a), &storedSpec); err != nil {
-			return fmt.Errorf("VirtualService %s.%s failed to unMarshal annotation %s",
-				apexName, canary.Namespace, configAnnotation)
-		}
-	} else {
-		ir.logger.Warnf("VirtualService %s.%s original configuration not found, unable to revert", apexName, canary.Namespace)
-		return nil
+	if routes == nil {
+		pw, cw, m := statusWeights(canary)
+		routes = &istioRoutes{pw, cw, m}
 	}
 
-	clone := vs.DeepCopy()
-	clone.Spec = storedSpec
+	obj := &istiov1alpha3.VirtualService{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: istiov1alpha3.SchemeGroupVersion.String(),
+			Kind:       "VirtualService",
+		},
+		ObjectMeta: makeApplyObjectMeta(canary, apexName, live),
+		Spec:       makeVirtualServiceSpec(canary, routes.primaryWeight, routes.canaryWeight, routes.mirrored),
+	}
 
-	_, err = ir.istioClient.NetworkingV1alpha3().VirtualServices(canary.Namespace).Update(clone)
-	if err != nil {
-		return fmt.Errorf("VirtualService %s.%s update error: %w", apexName, canary.Namespace, err)
+	// store the original spec of the virtual services created by others to be restored on finalization
+	if live != nil {
+		if a, ok := virtualService.Annotations[configAnnotation]; ok {
+			obj.Annotations = map[string]string{configAnnotation: a}
+		} else if _, ok := virtualService.Annotations[kubectlAnnotation]; !ok && !metav1.IsControlledBy(virtualService, canary) {
+			b, err := json.Marshal(virtualService.Spec)
+			if err != nil {
+				return fmt.Errorf("VirtualService %s.%s marshal error: %w", apexName, canary.Namespace, err)
+			}
+			obj.Annotations = map[string]string{configAnnotation: string(b)}
+		}
 	}
-	return nil
+
+	return ir.applier.apply(canary, istioVirtualServiceGVR, obj, live, force)
 }
 
 // mergeMatchConditions appends the URI match rules to canary conditions
@@ -415,6 +539,60 @@ func mergeMatchConditions(canary, defaults []istiov1alpha3.HTTPMatchRequest) []i
 	return canary
 }
 
+// setL4Routes replaces the tcp or tls routes of the virtual service with a single weighted route,
+// the TLS connections are matched on the SNI hosts of the virtual service
+func setL4Routes(canary *flaggerv1.Canary, spec *istiov1alpha3.VirtualServiceSpec, route []istiov1alpha3.DestinationWeight) {
+	switch canary.GetAppProtocol() {
+	case flaggerv1.AppProtocolTCP:
+		spec.Tls = nil
+		spec.Tcp = []istiov1alpha3.TCPRoute{
+			{
+				Match: []istiov1alpha3.L4MatchAttributes{
+					{Port: int(canary.Spec.Service.Port)},
+				},
+				Route: route,
+			},
+		}
+	case flaggerv1.AppProtocolTLS:
+		spec.Tcp = nil
+		spec.Tls = []istiov1alpha3.TLSRoute{
+			{
+				Match: []istiov1alpha3.TLSMatchAttributes{
+					{
+						SniHosts: spec.Hosts,
+						Port:     int(canary.Spec.Service.Port),
+					},
+				},
+				Route: route,
+			},
+		}
+	}
+}
+
+// getL4Routes returns the destinations of the tcp or tls route that contains the canary host
+func getL4Routes(canary *flaggerv1.Canary, spec istiov1alpha3.VirtualServiceSpec, canaryName string) []istiov1alpha3.DestinationWeight {
+	var routes [][]istiov1alpha3.DestinationWeight
+	switch canary.GetAppProtocol() {
+	case flaggerv1.AppProtocolTCP:
+		for _, tcp := range spec.Tcp {
+			routes = append(routes, tcp.Route)
+		}
+	case flaggerv1.AppProtocolTLS:
+		for _, tls := range spec.Tls {
+			routes = append(routes, tls.Route)
+		}
+	}
+
+	for _, route := range routes {
+		for _, r := range route {
+			if r.Destination.Host == canaryName {
+				return route
+			}
+		}
+	}
+	return nil
+}
+
 // makeDestination returns a an destination weight for the specified host
 func makeDestination(canary *flaggerv1.Canary, host string, weight int) istiov1alpha3.DestinationWeight {
 	dest := istiov1alpha3.DestinationWeight{
@@ -0,0 +1,344 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/util/retry"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
+)
+
+// istioVirtualServiceGVR is used to patch the virtual services that are not owned by Flagger,
+// the unstructured objects preserve the fields that are not part of the typed API
+var istioVirtualServiceGVR = schema.GroupVersionResource{
+	Group:    "networking.istio.io",
+	Version:  "v1alpha3",
+	Resource: "virtualservices",
+}
+
+// originalRouteAnnotation prefixes the annotation that stores the referenced route before Flagger changed it
+const originalRouteAnnotation = "flagger.kubernetes.io/original-route-"
+
+// istioRouteDestinations holds the fields of a referenced HTTP route managed by Flagger
+type istioRouteDestinations struct {
+	Match            []istiov1alpha3.HTTPMatchRequest  `json:"match,omitempty"`
+	Route            []istiov1alpha3.DestinationWeight `json:"route,omitempty"`
+	Mirror           *istiov1alpha3.Destination        `json:"mirror,omitempty"`
+	MirrorPercentage *istiov1alpha3.Percent            `json:"mirrorPercentage,omitempty"`
+}
+
+// reconcileVirtualServiceRoute routes the traffic of the referenced HTTP route to primary and canary,
+// the weights are kept if the route already points to both
+func (ir *IstioRouter) reconcileVirtualServiceRoute(canary *flaggerv1.Canary) error {
+	ref := canary.Spec.Service.VirtualServiceRef
+	return ir.updateRouteRef(canary, func(vs *unstructured.Unstructured, routes []interface{}, index int) ([]interface{}, error) {
+		// store the original route to be restored on finalization
+		key := originalRouteAnnotation + ref.RouteName
+		annotations := vs.GetAnnotations()
+		if _, ok := annotations[key]; !ok {
+			b, err := json.Marshal(routes[index])
+			if err != nil {
+				return nil, fmt.Errorf("route %s marshal error: %w", ref.RouteName, err)
+			}
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[key] = string(b)
+			vs.SetAnnotations(annotations)
+		}
+
+		primaryWeight, canaryWeight, mirrored, err := routeRefWeights(canary, routes, index)
+		if err != nil {
+			return nil, err
+		}
+		if primaryWeight == 0 && canaryWeight == 0 {
+			primaryWeight = 100
+		}
+
+		return setRouteRefWeights(canary, routes, index, primaryWeight, canaryWeight, mirrored)
+	})
+}
+
+// getRouteRefWeights returns the destinations weight of the referenced HTTP route
+func (ir *IstioRouter) getRouteRefWeights(canary *flaggerv1.Canary) (
+	primaryWeight int,
+	canaryWeight int,
+	mirrored bool,
+	err error,
+) {
+	ref := canary.Spec.Service.VirtualServiceRef
+	_, routes, index, err := ir.getRouteRef(canary)
+	if err != nil {
+		return
+	}
+
+	primaryWeight, canaryWeight, mirrored, err = routeRefWeights(canary, routes, index)
+	if err == nil && primaryWeight == 0 && canaryWeight == 0 {
+		apexName, _, _ := canary.GetServiceNames()
+		err = fmt.Errorf("VirtualService %s.%s route %s does not contain routes for %s-primary and %s-canary",
+			ref.Name, canary.Namespace, ref.RouteName, apexName, apexName)
+	}
+	return
+}
+
+// setRouteRefWeights updates the destinations weight of the referenced HTTP route
+func (ir *IstioRouter) setRouteRefWeights(
+	canary *flaggerv1.Canary,
+	primaryWeight int,
+	canaryWeight int,
+	mirrored bool,
+) error {
+	return ir.updateRouteRef(canary, func(_ *unstructured.Unstructured, routes []interface{}, index int) ([]interface{}, error) {
+		return setRouteRefWeights(canary, routes, index, primaryWeight, canaryWeight, mirrored)
+	})
+}
+
+// finalizeRouteRef restores the referenced HTTP route and removes the A/B testing route
+func (ir *IstioRouter) finalizeRouteRef(canary *flaggerv1.Canary) error {
+	ref := canary.Spec.Service.VirtualServiceRef
+	return ir.updateRouteRef(canary, func(vs *unstructured.Unstructured, routes []interface{}, index int) ([]interface{}, error) {
+		key := originalRouteAnnotation + ref.RouteName
+		annotations := vs.GetAnnotations()
+		a, ok := annotations[key]
+		if !ok {
+			ir.logger.Warnf("VirtualService %s.%s original route %s not found, unable to revert",
+				ref.Name, canary.Namespace, ref.RouteName)
+			return routes, nil
+		}
+
+		var original map[string]interface{}
+		if err := utiljson.Unmarshal([]byte(a), &original); err != nil {
+			return nil, fmt.Errorf("VirtualService %s.%s failed to unMarshal annotation %s", ref.Name, canary.Namespace, key)
+		}
+		routes[index] = original
+		routes, _ = removeHTTPRoute(routes, index, ref.RouteName+"-canary")
+
+		delete(annotations, key)
+		vs.SetAnnotations(annotations)
+		return routes, nil
+	})
+}
+
+// getRouteRef returns the referenced virtual service, its HTTP routes and the index of the named route
+func (ir *IstioRouter) getRouteRef(canary *flaggerv1.Canary) (*unstructured.Unstructured, []interface{}, int, error) {
+	ref := canary.Spec.Service.VirtualServiceRef
+	if ir.dynamicClient == nil {
+		return nil, nil, 0, fmt.Errorf("VirtualService dynamic client is not configured")
+	}
+
+	vs, err := ir.dynamicClient.Resource(istioVirtualServiceGVR).Namespace(canary.Namespace).Get(ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("VirtualService %s.%s get query error: %w", ref.Name, canary.Namespace, err)
+	}
+
+	routes, _, _ := unstructured.NestedSlice(vs.Object, "spec", "http")
+	if index := findHTTPRoute(routes, ref.RouteName); index >= 0 {
+		return vs, routes, index, nil
+	}
+	return nil, nil, 0, fmt.Errorf("VirtualService %s.%s does not contain a HTTP route named %s",
+		ref.Name, canary.Namespace, ref.RouteName)
+}
+
+// updateRouteRef applies the mutation to the HTTP routes of the referenced virtual service,
+// the virtual service is updated only if something changed and the update is retried on conflicts
+// with other canaries that manage routes of the same virtual service
+func (ir *IstioRouter) updateRouteRef(
+	canary *flaggerv1.Canary,
+	mutate func(vs *unstructured.Unstructured, routes []interface{}, index int) ([]interface{}, error),
+) error {
+	ref := canary.Spec.Service.VirtualServiceRef
+	var updated bool
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		vs, routes, index, err := ir.getRouteRef(canary)
+		if err != nil {
+			return err
+		}
+
+		original := vs.DeepCopy()
+		routes, err = mutate(vs, routes, index)
+		if err != nil {
+			return err
+		}
+		if err := unstructured.SetNestedSlice(vs.Object, routes, "spec", "http"); err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(original.Object, vs.Object) {
+			return nil
+		}
+
+		_, err = ir.dynamicClient.Resource(istioVirtualServiceGVR).Namespace(canary.Namespace).Update(vs, metav1.UpdateOptions{})
+		updated = err == nil
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("VirtualService %s.%s route %s update error: %w", ref.Name, canary.Namespace, ref.RouteName, err)
+	}
+
+	if updated {
+		ir.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+			Infof("VirtualService %s.%s route %s updated", ref.Name, canary.Namespace, ref.RouteName)
+	}
+	return nil
+}
+
+// routeRefWeights returns the weights of the A/B testing route if present, otherwise of the referenced route
+func routeRefWeights(canary *flaggerv1.Canary, routes []interface{}, index int) (
+	primaryWeight int,
+	canaryWeight int,
+	mirrored bool,
+	err error,
+) {
+	_, primaryName, canaryName := canary.GetServiceNames()
+	if len(canary.GetAnalysis().Match) > 0 {
+		if i := findHTTPRoute(routes, canary.Spec.Service.VirtualServiceRef.RouteName+"-canary"); i >= 0 {
+			index = i
+		}
+	}
+
+	obj, ok := routes[index].(map[string]interface{})
+	if !ok {
+		err = fmt.Errorf("invalid HTTP route %v", routes[index])
+		return
+	}
+
+	var route istiov1alpha3.HTTPRoute
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &route); err != nil {
+		return
+	}
+
+	for _, r := range route.Route {
+		if r.Destination.Host == primaryName {
+			primaryWeight = r.Weight
+		}
+		if r.Destination.Host == canaryName {
+			canaryWeight = r.Weight
+		}
+	}
+	if route.Mirror != nil && route.Mirror.Host != "" {
+		mirrored = true
+	}
+	return
+}
+
+// setRouteRefWeights points the referenced route to primary and canary, for A/B testing
+// a copy of the referenced route that matches the canary conditions is inserted in front of it
+func setRouteRefWeights(
+	canary *flaggerv1.Canary,
+	routes []interface{},
+	index int,
+	primaryWeight int,
+	canaryWeight int,
+	mirrored bool,
+) ([]interface{}, error) {
+	_, primaryName, canaryName := canary.GetServiceNames()
+	canaryRouteName := canary.Spec.Service.VirtualServiceRef.RouteName + "-canary"
+	routes, index = removeHTTPRoute(routes, index, canaryRouteName)
+
+	weighted := []istiov1alpha3.DestinationWeight{
+		makeDestination(canary, primaryName, primaryWeight),
+		makeDestination(canary, canaryName, canaryWeight),
+	}
+
+	if len(canary.GetAnalysis().Match) == 0 {
+		dest := istioRouteDestinations{Route: weighted}
+		if mirrored {
+			dest.Mirror = &istiov1alpha3.Destination{Host: canaryName}
+			if mw := canary.GetAnalysis().MirrorWeight; mw > 0 {
+				dest.MirrorPercentage = &istiov1alpha3.Percent{Value: float64(mw)}
+			}
+		}
+		return routes, setRouteDestinations(routes[index], dest)
+	}
+
+	obj, ok := routes[index].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid HTTP route %v", routes[index])
+	}
+	var route istiov1alpha3.HTTPRoute
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &route); err != nil {
+		return nil, err
+	}
+
+	// merge the URI conditions of the referenced route with the canary ones
+	match := make([]istiov1alpha3.HTTPMatchRequest, 0, len(canary.GetAnalysis().Match))
+	for _, m := range canary.GetAnalysis().Match {
+		match = append(match, *m.DeepCopy())
+	}
+	match = mergeMatchConditions(match, route.Match)
+
+	canaryRoute := runtime.DeepCopyJSONValue(obj).(map[string]interface{})
+	canaryRoute["name"] = canaryRouteName
+	if err := setRouteDestinations(canaryRoute, istioRouteDestinations{Match: match, Route: weighted}); err != nil {
+		return nil, err
+	}
+	if err := setRouteDestinations(obj, istioRouteDestinations{
+		Route: []istiov1alpha3.DestinationWeight{makeDestination(canary, primaryName, 100)},
+	}); err != nil {
+		return nil, err
+	}
+
+	routes = append(routes[:index], append([]interface{}{canaryRoute}, routes[index:]...)...)
+	return routes, nil
+}
+
+// setRouteDestinations replaces the destinations and mirror of a HTTP route,
+// the match conditions are replaced only if specified
+func setRouteDestinations(route interface{}, dest istioRouteDestinations) error {
+	obj, ok := route.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid HTTP route %v", route)
+	}
+
+	// the JSON round trip converts the port numbers to int64 as expected by the unstructured objects
+	b, err := json.Marshal(dest)
+	if err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := utiljson.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	keys := []string{"route", "mirror", "mirrorPercentage"}
+	if len(dest.Match) > 0 {
+		keys = append(keys, "match")
+	}
+	for _, key := range keys {
+		if v, ok := fields[key]; ok {
+			obj[key] = v
+		} else {
+			delete(obj, key)
+		}
+	}
+	return nil
+}
+
+// findHTTPRoute returns the index of the named HTTP route or -1 if not found
+func findHTTPRoute(routes []interface{}, name string) int {
+	for i, r := range routes {
+		if obj, ok := r.(map[string]interface{}); ok && obj["name"] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeHTTPRoute removes the named HTTP route and returns the new index of the route at index
+func removeHTTPRoute(routes []interface{}, index int, name string) ([]interface{}, int) {
+	i := findHTTPRoute(routes, name)
+	if i < 0 {
+		return routes, index
+	}
+	if i < index {
+		index--
+	}
+	return append(routes[:i], routes[i+1:]...), index
+}
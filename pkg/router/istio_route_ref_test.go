@@ -0,0 +1,213 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	istiov1alpha1 "github.com/weaveworks/flagger/pkg/apis/istio/common/v1alpha1"
+	istiov1alpha3 "github.com/weaveworks/flagger/pkg/apis/istio/v1alpha3"
+)
+
+func newRouteRefFixture() (fixture, *IstioRouter) {
+	mocks := newFixture(nil)
+	mocks.canary.Spec.Service.VirtualServiceRef = &flaggerv1.VirtualServiceRouteRef{
+		Name:      "public",
+		RouteName: "podinfo",
+	}
+
+	// a virtual service owned by another team with a route for podinfo
+	vs := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "networking.istio.io/v1alpha3",
+			"kind":       "VirtualService",
+			"metadata": map[string]interface{}{
+				"name":      "public",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"hosts":    []interface{}{"app.example.com"},
+				"gateways": []interface{}{"public-gateway.istio-system.svc.cluster.local"},
+				"http": []interface{}{
+					map[string]interface{}{
+						"name": "frontend",
+						"match": []interface{}{
+							map[string]interface{}{"uri": map[string]interface{}{"prefix": "/"}},
+						},
+						"route": []interface{}{
+							map[string]interface{}{"destination": map[string]interface{}{"host": "frontend"}},
+						},
+					},
+					map[string]interface{}{
+						"name": "podinfo",
+						"match": []interface{}{
+							map[string]interface{}{"uri": map[string]interface{}{"prefix": "/podinfo"}},
+						},
+						"route": []interface{}{
+							map[string]interface{}{"destination": map[string]interface{}{"host": "podinfo"}},
+						},
+						"directResponse": map[string]interface{}{"status": int64(503)},
+					},
+				},
+			},
+		},
+	}
+
+	router := &IstioRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		istioClient:   mocks.meshClient,
+		kubeClient:    mocks.kubeClient,
+		dynamicClient: fakeDynamic.NewSimpleDynamicClient(runtime.NewScheme(), vs),
+	}
+	return mocks, router
+}
+
+func getRouteRefRoutes(t *testing.T, router *IstioRouter) []map[string]interface{} {
+	vs, err := router.dynamicClient.Resource(istioVirtualServiceGVR).Namespace("default").Get("public", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	http, _, err := unstructured.NestedSlice(vs.Object, "spec", "http")
+	require.NoError(t, err)
+	var routes []map[string]interface{}
+	for _, r := range http {
+		routes = append(routes, r.(map[string]interface{}))
+	}
+	return routes
+}
+
+func routeHosts(route map[string]interface{}) []string {
+	var hosts []string
+	dests, _, _ := unstructured.NestedSlice(route, "route")
+	for _, d := range dests {
+		host, _, _ := unstructured.NestedString(d.(map[string]interface{}), "destination", "host")
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+func TestIstioRouter_VirtualServiceRef(t *testing.T) {
+	mocks, router := newRouteRefFixture()
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	// the generated virtual service is not created
+	_, err = mocks.meshClient.NetworkingV1alpha3().VirtualServices("default").Get("podinfo", metav1.GetOptions{})
+	require.Error(t, err)
+
+	routes := getRouteRefRoutes(t, router)
+	require.Len(t, routes, 2)
+	assert.Equal(t, []string{"frontend"}, routeHosts(routes[0]))
+	assert.Equal(t, []string{"podinfo-primary", "podinfo-canary"}, routeHosts(routes[1]))
+	assert.Contains(t, routes[1], "directResponse")
+
+	p, c, m, err := router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 100, p)
+	assert.Equal(t, 0, c)
+	assert.False(t, m)
+
+	err = router.SetRoutes(mocks.canary, 50, 50, true)
+	require.NoError(t, err)
+
+	p, c, m, err = router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 50, p)
+	assert.Equal(t, 50, c)
+	assert.True(t, m)
+
+	// the weights are kept on reconciliation
+	err = router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	p, c, _, err = router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 50, p)
+	assert.Equal(t, 50, c)
+
+	// the original route is restored on finalization
+	err = router.Finalize(mocks.canary)
+	require.NoError(t, err)
+
+	routes = getRouteRefRoutes(t, router)
+	require.Len(t, routes, 2)
+	assert.Equal(t, []string{"podinfo"}, routeHosts(routes[1]))
+	assert.NotContains(t, routes[1], "mirror")
+
+	// the referenced route must exist
+	mocks.canary.Spec.Service.VirtualServiceRef.RouteName = "backend"
+	err = router.Reconcile(mocks.canary)
+	require.Error(t, err)
+}
+
+func TestIstioRouter_VirtualServiceRefABTest(t *testing.T) {
+	mocks, router := newRouteRefFixture()
+	mocks.canary.Spec.Analysis.Iterations = 10
+	mocks.canary.Spec.Analysis.Match = []istiov1alpha3.HTTPMatchRequest{
+		{
+			Headers: map[string]istiov1alpha1.StringMatch{
+				"x-canary": {
+					Exact: "insider",
+				},
+			},
+		},
+	}
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	err = router.SetRoutes(mocks.canary, 0, 100, false)
+	require.NoError(t, err)
+
+	// the canary route is inserted in front of the referenced route
+	routes := getRouteRefRoutes(t, router)
+	require.Len(t, routes, 3)
+	assert.Equal(t, "podinfo-canary", routes[1]["name"])
+	assert.Equal(t, []string{"podinfo-primary", "podinfo-canary"}, routeHosts(routes[1]))
+	header, _, _ := unstructured.NestedString(routes[1]["match"].([]interface{})[0].(map[string]interface{}), "headers", "x-canary", "exact")
+	assert.Equal(t, "insider", header)
+	uri, _, _ := unstructured.NestedString(routes[1]["match"].([]interface{})[0].(map[string]interface{}), "uri", "prefix")
+	assert.Equal(t, "/podinfo", uri)
+	assert.Equal(t, "podinfo", routes[2]["name"])
+	assert.Equal(t, []string{"podinfo-primary"}, routeHosts(routes[2]))
+
+	p, c, _, err := router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 0, p)
+	assert.Equal(t, 100, c)
+
+	err = router.Finalize(mocks.canary)
+	require.NoError(t, err)
+
+	routes = getRouteRefRoutes(t, router)
+	require.Len(t, routes, 2)
+	assert.Equal(t, "podinfo", routes[1]["name"])
+}
+
+func TestIstioRouter_Delegation(t *testing.T) {
+	mocks := newFixture(nil)
+	router := &IstioRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		istioClient:   mocks.meshClient,
+		kubeClient:    mocks.kubeClient,
+	}
+	mocks.canary.Spec.Service.Delegation = true
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	vs, err := mocks.meshClient.NetworkingV1alpha3().VirtualServices("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, vs.Spec.Hosts, 0)
+	assert.Len(t, vs.Spec.Gateways, 0)
+	require.Len(t, vs.Spec.Http, 1)
+	assert.Len(t, vs.Spec.Http[0].Route, 2)
+}
@@ -332,6 +332,84 @@ func TestIstioRouter_GatewayPort(t *testing.T) {
 	assert.Equal(t, uint32(mocks.canary.Spec.Service.Port), port)
 }
 
+func TestIstioRouter_TCP(t *testing.T) {
+	mocks := newFixture(nil)
+	router := &IstioRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		istioClient:   mocks.meshClient,
+		kubeClient:    mocks.kubeClient,
+	}
+
+	canary := mocks.canary.DeepCopy()
+	canary.Spec.Service.AppProtocol = v1beta1.AppProtocolTCP
+
+	err := router.Reconcile(canary)
+	require.NoError(t, err)
+
+	vs, err := mocks.meshClient.NetworkingV1alpha3().VirtualServices("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, vs.Spec.Http, 0)
+	require.Len(t, vs.Spec.Tcp, 1)
+	assert.Equal(t, 9898, vs.Spec.Tcp[0].Match[0].Port)
+	require.Len(t, vs.Spec.Tcp[0].Route, 2)
+
+	err = router.SetRoutes(canary, 60, 40, false)
+	require.NoError(t, err)
+
+	p, c, m, err := router.GetRoutes(canary)
+	require.NoError(t, err)
+	assert.Equal(t, 60, p)
+	assert.Equal(t, 40, c)
+	assert.False(t, m)
+
+	// the weights are kept when the virtual service is updated
+	err = router.Reconcile(canary)
+	require.NoError(t, err)
+
+	p, c, _, err = router.GetRoutes(canary)
+	require.NoError(t, err)
+	assert.Equal(t, 60, p)
+	assert.Equal(t, 40, c)
+}
+
+func TestIstioRouter_TLS(t *testing.T) {
+	mocks := newFixture(nil)
+	router := &IstioRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		istioClient:   mocks.meshClient,
+		kubeClient:    mocks.kubeClient,
+	}
+
+	canary := mocks.canary.DeepCopy()
+	canary.Spec.Service.AppProtocol = v1beta1.AppProtocolTLS
+
+	err := router.Reconcile(canary)
+	require.NoError(t, err)
+
+	vs, err := mocks.meshClient.NetworkingV1alpha3().VirtualServices("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, vs.Spec.Http, 0)
+	assert.Len(t, vs.Spec.Tcp, 0)
+	require.Len(t, vs.Spec.Tls, 1)
+	assert.Equal(t, vs.Spec.Hosts, vs.Spec.Tls[0].Match[0].SniHosts)
+
+	err = router.SetRoutes(canary, 0, 100, false)
+	require.NoError(t, err)
+
+	p, c, _, err := router.GetRoutes(canary)
+	require.NoError(t, err)
+	assert.Equal(t, 0, p)
+	assert.Equal(t, 100, c)
+
+	vs, err = mocks.meshClient.NetworkingV1alpha3().VirtualServices("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, vs.Spec.Http, 0)
+	require.Len(t, vs.Spec.Tls, 1)
+	assert.Equal(t, "podinfo-canary", vs.Spec.Tls[0].Route[1].Destination.Host)
+}
+
 func TestIstioRouter_Finalize(t *testing.T) {
 	mocks := newFixture(nil)
 	router := &IstioRouter{
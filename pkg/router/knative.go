@@ -0,0 +1,217 @@
+package router
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
+)
+
+// knativeServiceGVR is the Knative Serving service that splits the traffic between its revisions
+var knativeServiceGVR = schema.GroupVersionResource{
+	Group:    "serving.knative.dev",
+	Version:  "v1",
+	Resource: "services",
+}
+
+// knativeTraffic holds the traffic targets of a Knative service
+type knativeTraffic struct {
+	Traffic []knativeTrafficTarget `json:"traffic,omitempty"`
+}
+
+type knativeTrafficTarget struct {
+	RevisionName   string `json:"revisionName,omitempty"`
+	LatestRevision *bool  `json:"latestRevision,omitempty"`
+	Percent        *int64 `json:"percent,omitempty"`
+}
+
+// KnativeRouter is managing the traffic targets of Knative services,
+// the primary weight goes to the last promoted revision and the canary weight to the latest ready revision
+type KnativeRouter struct {
+	kubeClient    kubernetes.Interface
+	knativeClient dynamic.Interface
+	flaggerClient clientset.Interface
+	logger        *zap.SugaredLogger
+}
+
+// Reconcile pins the traffic to the primary revision if the service routes are not managed by Flagger
+func (kr *KnativeRouter) Reconcile(canary *flaggerv1.Canary) error {
+	svc, primaryRevision, err := kr.getService(canary)
+	if err != nil {
+		return err
+	}
+
+	traffic, err := getKnativeTraffic(svc)
+	if err != nil {
+		return fmt.Errorf("knative service %s.%s traffic decode error: %w", svc.GetName(), canary.Namespace, err)
+	}
+	if isKnativeTrafficManaged(traffic, primaryRevision) {
+		return nil
+	}
+
+	if err := kr.updateTraffic(canary, svc, makeKnativeTraffic(primaryRevision, 100, 0)); err != nil {
+		return err
+	}
+
+	kr.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+		Infof("Knative service %s.%s traffic pinned to revision %s", svc.GetName(), canary.Namespace, primaryRevision)
+	return nil
+}
+
+// GetRoutes returns the traffic percentage of the primary revision and of the latest revision
+func (kr *KnativeRouter) GetRoutes(canary *flaggerv1.Canary) (
+	primaryWeight int,
+	canaryWeight int,
+	mirrored bool,
+	err error,
+) {
+	svc, primaryRevision, err := kr.getService(canary)
+	if err != nil {
+		return
+	}
+
+	traffic, err := getKnativeTraffic(svc)
+	if err != nil {
+		err = fmt.Errorf("knative service %s.%s traffic decode error: %w", svc.GetName(), canary.Namespace, err)
+		return
+	}
+	if !isKnativeTrafficManaged(traffic, primaryRevision) {
+		err = fmt.Errorf("knative service %s.%s traffic targets for revision %s not found",
+			svc.GetName(), canary.Namespace, primaryRevision)
+		return
+	}
+
+	for _, target := range traffic.Traffic {
+		if target.Percent == nil {
+			continue
+		}
+		if target.LatestRevision != nil && *target.LatestRevision {
+			canaryWeight = int(*target.Percent)
+		} else {
+			primaryWeight = int(*target.Percent)
+		}
+	}
+	return
+}
+
+// SetRoutes updates the traffic percentage of the primary revision and of the latest revision,
+// a rollback pins all the traffic to the primary revision
+func (kr *KnativeRouter) SetRoutes(
+	canary *flaggerv1.Canary,
+	primaryWeight int,
+	canaryWeight int,
+	_ bool,
+) error {
+	svc, primaryRevision, err := kr.getService(canary)
+	if err != nil {
+		return err
+	}
+	return kr.updateTraffic(canary, svc, makeKnativeTraffic(primaryRevision, primaryWeight, canaryWeight))
+}
+
+// Finalize routes all the traffic to the latest revision
+func (kr *KnativeRouter) Finalize(canary *flaggerv1.Canary) error {
+	if kr.knativeClient == nil {
+		return fmt.Errorf("Knative dynamic client is not configured")
+	}
+
+	svc, err := kr.knativeClient.Resource(knativeServiceGVR).Namespace(canary.Namespace).
+		Get(canary.Spec.TargetRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("knative service %s.%s get query error: %w", canary.Spec.TargetRef.Name, canary.Namespace, err)
+	}
+
+	latest := true
+	percent := int64(100)
+	traffic := knativeTraffic{
+		Traffic: []knativeTrafficTarget{{LatestRevision: &latest, Percent: &percent}},
+	}
+	return kr.updateTraffic(canary, svc, traffic)
+}
+
+// getService returns the Knative service and its primary revision
+func (kr *KnativeRouter) getService(canary *flaggerv1.Canary) (*unstructured.Unstructured, string, error) {
+	if kr.knativeClient == nil {
+		return nil, "", fmt.Errorf("Knative dynamic client is not configured")
+	}
+
+	targetName := canary.Spec.TargetRef.Name
+	svc, err := kr.knativeClient.Resource(knativeServiceGVR).Namespace(canary.Namespace).Get(targetName, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("knative service %s.%s get query error: %w", targetName, canary.Namespace, err)
+	}
+
+	primaryRevision := svc.GetAnnotations()[flaggerv1.KnativePrimaryRevisionAnnotation]
+	if primaryRevision == "" {
+		return nil, "", fmt.Errorf("knative service %s.%s has no primary revision", targetName, canary.Namespace)
+	}
+	return svc, primaryRevision, nil
+}
+
+func (kr *KnativeRouter) updateTraffic(canary *flaggerv1.Canary, svc *unstructured.Unstructured, traffic knativeTraffic) error {
+	if err := setKnativeTraffic(svc, traffic); err != nil {
+		return fmt.Errorf("knative service %s.%s traffic encode error: %w", svc.GetName(), canary.Namespace, err)
+	}
+
+	_, err := kr.knativeClient.Resource(knativeServiceGVR).Namespace(canary.Namespace).Update(svc, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("knative service %s.%s update error: %w", svc.GetName(), canary.Namespace, err)
+	}
+	return nil
+}
+
+// makeKnativeTraffic splits the traffic between the primary revision and the latest ready revision
+func makeKnativeTraffic(primaryRevision string, primaryWeight int, canaryWeight int) knativeTraffic {
+	latest := true
+	primaryPercent := int64(primaryWeight)
+	canaryPercent := int64(canaryWeight)
+	return knativeTraffic{
+		Traffic: []knativeTrafficTarget{
+			{
+				RevisionName: primaryRevision,
+				Percent:      &primaryPercent,
+			},
+			{
+				LatestRevision: &latest,
+				Percent:        &canaryPercent,
+			},
+		},
+	}
+}
+
+// isKnativeTrafficManaged returns true if the traffic is split between the primary revision and the latest revision,
+// Knative defaults latestRevision to false for the targets pinned to a revision
+func isKnativeTrafficManaged(traffic knativeTraffic, primaryRevision string) bool {
+	if len(traffic.Traffic) != 2 {
+		return false
+	}
+	primary, latest := traffic.Traffic[0], traffic.Traffic[1]
+	return primary.RevisionName == primaryRevision && (primary.LatestRevision == nil || !*primary.LatestRevision) &&
+		latest.LatestRevision != nil && *latest.LatestRevision
+}
+
+func getKnativeTraffic(svc *unstructured.Unstructured) (knativeTraffic, error) {
+	var traffic knativeTraffic
+	obj, ok, err := unstructured.NestedMap(svc.Object, "spec")
+	if err != nil || !ok {
+		return traffic, err
+	}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &traffic)
+	return traffic, err
+}
+
+func setKnativeTraffic(svc *unstructured.Unstructured, traffic knativeTraffic) error {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&traffic)
+	if err != nil {
+		return err
+	}
+	return unstructured.SetNestedField(svc.Object, obj["traffic"], "spec", "traffic")
+}
@@ -0,0 +1,165 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+)
+
+func newKnativeRouterFixture() (fixture, *KnativeRouter) {
+	mocks := newFixture(nil)
+	mocks.canary.Spec.Provider = "knative"
+	mocks.canary.Spec.TargetRef = flaggerv1.CrossNamespaceObjectReference{
+		Name:       "podinfo",
+		APIVersion: "serving.knative.dev/v1",
+		Kind:       "Service",
+	}
+
+	// a Knative service that routes all the traffic to the latest revision
+	svc := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "serving.knative.dev/v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name":      "podinfo",
+				"namespace": "default",
+				"annotations": map[string]interface{}{
+					flaggerv1.KnativePrimaryRevisionAnnotation: "podinfo-00001",
+				},
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"image": "ghcr.io/stefanprodan/podinfo:6.0.1"},
+						},
+					},
+				},
+				"traffic": []interface{}{
+					map[string]interface{}{"latestRevision": true, "percent": int64(100)},
+				},
+			},
+		},
+	}
+
+	router := &KnativeRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		kubeClient:    mocks.kubeClient,
+		knativeClient: fakeDynamic.NewSimpleDynamicClient(runtime.NewScheme(), svc),
+	}
+	return mocks, router
+}
+
+func getKnativeTestTraffic(t *testing.T, router *KnativeRouter) []interface{} {
+	svc, err := router.knativeClient.Resource(knativeServiceGVR).Namespace("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	traffic, _, err := unstructured.NestedSlice(svc.Object, "spec", "traffic")
+	require.NoError(t, err)
+	return traffic
+}
+
+func TestKnativeRouter_Reconcile(t *testing.T) {
+	mocks, router := newKnativeRouterFixture()
+
+	// GetRoutes fails until the traffic is pinned to the primary revision
+	_, _, _, err := router.GetRoutes(mocks.canary)
+	require.Error(t, err)
+
+	err = router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	traffic := getKnativeTestTraffic(t, router)
+	require.Len(t, traffic, 2)
+	assert.Equal(t, map[string]interface{}{"revisionName": "podinfo-00001", "percent": int64(100)}, traffic[0])
+	assert.Equal(t, map[string]interface{}{"latestRevision": true, "percent": int64(0)}, traffic[1])
+
+	p, c, m, err := router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 100, p)
+	assert.Equal(t, 0, c)
+	assert.False(t, m)
+
+	// the template of the service is left untouched
+	svc, err := router.knativeClient.Resource(knativeServiceGVR).Namespace("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	containers, _, _ := unstructured.NestedSlice(svc.Object, "spec", "template", "spec", "containers")
+	assert.Len(t, containers, 1)
+
+	// the primary revision is required
+	svc.SetAnnotations(nil)
+	_, err = router.knativeClient.Resource(knativeServiceGVR).Namespace("default").Update(svc, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	err = router.Reconcile(mocks.canary)
+	require.Error(t, err)
+}
+
+func TestKnativeRouter_Routes(t *testing.T) {
+	mocks, router := newKnativeRouterFixture()
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	err = router.SetRoutes(mocks.canary, 60, 40, false)
+	require.NoError(t, err)
+
+	p, c, _, err := router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 60, p)
+	assert.Equal(t, 40, c)
+
+	// the weights are kept on reconciliation
+	err = router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	p, c, _, err = router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 60, p)
+	assert.Equal(t, 40, c)
+
+	// Knative defaults latestRevision to false for the primary target
+	svc, err := router.knativeClient.Resource(knativeServiceGVR).Namespace("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	traffic, _, _ := unstructured.NestedSlice(svc.Object, "spec", "traffic")
+	traffic[0].(map[string]interface{})["latestRevision"] = false
+	require.NoError(t, unstructured.SetNestedSlice(svc.Object, traffic, "spec", "traffic"))
+	_, err = router.knativeClient.Resource(knativeServiceGVR).Namespace("default").Update(svc, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	p, c, _, err = router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 60, p)
+	assert.Equal(t, 40, c)
+
+	// rollback pins the traffic to the primary revision
+	err = router.SetRoutes(mocks.canary, 100, 0, false)
+	require.NoError(t, err)
+
+	traffic = getKnativeTestTraffic(t, router)
+	require.Len(t, traffic, 2)
+	assert.Equal(t, map[string]interface{}{"revisionName": "podinfo-00001", "percent": int64(100)}, traffic[0])
+	assert.Equal(t, map[string]interface{}{"latestRevision": true, "percent": int64(0)}, traffic[1])
+}
+
+func TestKnativeRouter_Finalize(t *testing.T) {
+	mocks, router := newKnativeRouterFixture()
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	err = router.Finalize(mocks.canary)
+	require.NoError(t, err)
+
+	traffic := getKnativeTestTraffic(t, router)
+	require.Len(t, traffic, 1)
+	assert.Equal(t, map[string]interface{}{"latestRevision": true, "percent": int64(100)}, traffic[0])
+}
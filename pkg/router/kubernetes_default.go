@@ -26,6 +26,8 @@ type KubernetesDefaultRouter struct {
 	labelSelector string
 	annotations   map[string]string
 	ports         map[string]int32
+	// keepApexSelector leaves the apex service selector to the kubernetes-replicas router
+	keepApexSelector bool
 }
 
 // Initialize creates the primary and canary services
@@ -71,7 +73,7 @@ func (c *KubernetesDefaultRouter) GetRoutes(_ *flaggerv1.Canary) (primaryRoute i
 func (c *KubernetesDefaultRouter) reconcileService(canary *flaggerv1.Canary, name string, podSelector string) error {
 	portName := canary.Spec.Service.PortName
 	if portName == "" {
-		portName = "http"
+		portName = string(canary.GetAppProtocol())
 	}
 
 	targetPort := intstr.IntOrString{
@@ -160,6 +162,10 @@ func (c *KubernetesDefaultRouter) reconcileService(canary *flaggerv1.Canary, nam
 			}
 		}
 
+		if apexName, _, _ := canary.GetServiceNames(); c.keepApexSelector && name == apexName {
+			svcSpec.Selector = svc.Spec.Selector
+		}
+
 		portsDiff := cmp.Diff(svcSpec.Ports, svc.Spec.Ports, cmpopts.SortSlices(sortPorts))
 		selectorsDiff := cmp.Diff(svcSpec.Selector, svc.Spec.Selector)
 		if portsDiff != "" || selectorsDiff != "" {
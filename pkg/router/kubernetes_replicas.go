@@ -0,0 +1,404 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
+	"github.com/weaveworks/flagger/pkg/internal"
+)
+
+// replicasStateAnnotation stores on the primary deployment the primary replicas and the HPA min and max
+// from before the analysis, the HPAs are restored from it when all the traffic is routed to primary
+const replicasStateAnnotation = "flagger.kubernetes.io/replicas-state"
+
+type replicasState struct {
+	Replicas    int32  `json:"replicas"`
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	MaxReplicas int32  `json:"maxReplicas,omitempty"`
+}
+
+// KubernetesReplicasRouter approximates the canary weight by scaling the primary and canary deployments
+// selected by the apex service with the pod labels both deployments have in common
+type KubernetesReplicasRouter struct {
+	kubeClient    kubernetes.Interface
+	flaggerClient clientset.Interface
+	logger        *zap.SugaredLogger
+}
+
+// Reconcile creates or updates the apex service with a selector that matches both primary and canary pods
+func (kr *KubernetesReplicasRouter) Reconcile(canary *flaggerv1.Canary) error {
+	if canary.Spec.TargetRef.Kind != "Deployment" {
+		return fmt.Errorf("kubernetes-replicas provider doesn't support %s targets", canary.Spec.TargetRef.Kind)
+	}
+
+	apexName, primaryName, _ := canary.GetServiceNames()
+	primaryDeploymentName := fmt.Sprintf("%s-primary", canary.Spec.TargetRef.Name)
+
+	canaryDep, err := kr.kubeClient.AppsV1().Deployments(canary.Namespace).Get(canary.Spec.TargetRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("deployment %s.%s get query error: %w", canary.Spec.TargetRef.Name, canary.Namespace, err)
+	}
+	primaryDep, err := kr.kubeClient.AppsV1().Deployments(canary.Namespace).Get(primaryDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("deployment %s.%s get query error: %w", primaryDeploymentName, canary.Namespace, err)
+	}
+
+	selector, err := kr.makeSelector(canary, canaryDep.Spec.Template.Labels, primaryDep.Spec.Template.Labels)
+	if err != nil {
+		return err
+	}
+
+	// the apex service exposes the same ports as the primary service
+	primarySvc, err := kr.kubeClient.CoreV1().Services(canary.Namespace).Get(primaryName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("service %s.%s get query error: %w", primaryName, canary.Namespace, err)
+	}
+
+	svc, err := kr.kubeClient.CoreV1().Services(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		svc = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      apexName,
+				Namespace: canary.Namespace,
+				Labels:    primarySvc.Labels,
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(canary, schema.GroupVersionKind{
+						Group:   flaggerv1.SchemeGroupVersion.Group,
+						Version: flaggerv1.SchemeGroupVersion.Version,
+						Kind:    flaggerv1.CanaryKind,
+					}),
+				},
+			},
+			Spec: corev1.ServiceSpec{
+				Type:     corev1.ServiceTypeClusterIP,
+				Selector: selector,
+				Ports:    primarySvc.Spec.Ports,
+			},
+		}
+
+		_, err := kr.kubeClient.CoreV1().Services(canary.Namespace).Create(svc)
+		if err != nil {
+			return fmt.Errorf("service %s.%s create error: %w", apexName, canary.Namespace, err)
+		}
+		kr.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+			Infof("Service %s.%s created", apexName, canary.Namespace)
+	} else if err != nil {
+		return fmt.Errorf("service %s.%s get query error: %w", apexName, canary.Namespace, err)
+	} else {
+		sortPorts := func(a, b interface{}) bool {
+			return a.(corev1.ServicePort).Port < b.(corev1.ServicePort).Port
+		}
+		portsDiff := cmp.Diff(primarySvc.Spec.Ports, svc.Spec.Ports, cmpopts.SortSlices(sortPorts))
+		selectorsDiff := cmp.Diff(selector, svc.Spec.Selector)
+		if portsDiff != "" || selectorsDiff != "" {
+			svcClone := svc.DeepCopy()
+			svcClone.Spec.Ports = primarySvc.Spec.Ports
+			svcClone.Spec.Selector = selector
+			_, err = kr.kubeClient.CoreV1().Services(canary.Namespace).Update(svcClone)
+			if err != nil {
+				return fmt.Errorf("service %s.%s update error: %w", apexName, canary.Namespace, err)
+			}
+			kr.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+				Infof("Service %s.%s updated", apexName, canary.Namespace)
+		}
+	}
+
+	// keep the canary out of the apex service until the analysis shifts traffic to it
+	if canary.Status.Phase == flaggerv1.CanaryPhaseProgressing && canary.Status.CanaryWeight == 0 &&
+		canary.GetAnalysis().StepWeight > 0 && int32Default(canaryDep.Spec.Replicas) > 0 {
+		if err := kr.scaleDeployment(canary, canary.Spec.TargetRef.Name, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetRoutes returns the effective weights computed from the ready replicas of primary and canary
+func (kr *KubernetesReplicasRouter) GetRoutes(canary *flaggerv1.Canary) (
+	primaryWeight int,
+	canaryWeight int,
+	mirrored bool,
+	err error,
+) {
+	primaryDeploymentName := fmt.Sprintf("%s-primary", canary.Spec.TargetRef.Name)
+
+	canaryDep, err := kr.kubeClient.AppsV1().Deployments(canary.Namespace).Get(canary.Spec.TargetRef.Name, metav1.GetOptions{})
+	if err != nil {
+		err = fmt.Errorf("deployment %s.%s get query error: %w", canary.Spec.TargetRef.Name, canary.Namespace, err)
+		return
+	}
+	primaryDep, err := kr.kubeClient.AppsV1().Deployments(canary.Namespace).Get(primaryDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		err = fmt.Errorf("deployment %s.%s get query error: %w", primaryDeploymentName, canary.Namespace, err)
+		return
+	}
+
+	canaryReady := int(canaryDep.Status.ReadyReplicas)
+	total := canaryReady + int(primaryDep.Status.ReadyReplicas)
+	if total == 0 {
+		return hundred, 0, false, nil
+	}
+
+	canaryWeight = percentOf(canaryReady, total)
+	primaryWeight = hundred - canaryWeight
+	return
+}
+
+// SetRoutes scales the primary and canary deployments to match the weights,
+// the replicas of a deployment are kept within the min and max of its HPA
+// and both HPAs are pinned to the deployment replicas during the analysis
+func (kr *KubernetesReplicasRouter) SetRoutes(
+	canary *flaggerv1.Canary,
+	primaryWeight int,
+	canaryWeight int,
+	_ bool,
+) error {
+	if primaryWeight == 0 && canaryWeight == 0 {
+		return fmt.Errorf("deployments %s.%s scaling failed: no valid weights", canary.Spec.TargetRef.Name, canary.Namespace)
+	}
+
+	state, saved, err := kr.getState(canary)
+	if err != nil {
+		return err
+	}
+
+	total := state.Replicas
+	if canary.GetAnalysis().MaxReplicas > 0 {
+		total = int32(canary.GetAnalysis().MaxReplicas)
+	}
+	if total < 1 {
+		return fmt.Errorf("deployment %s-primary.%s has no replicas, analysis.maxReplicas is required",
+			canary.Spec.TargetRef.Name, canary.Namespace)
+	}
+
+	canaryReplicas := int32(percent(canaryWeight, int(total)))
+	primaryReplicas := total - canaryReplicas
+	if primaryWeight > 0 && primaryReplicas < 1 {
+		primaryReplicas = 1
+	}
+	if canary.Spec.AutoscalerRef != nil && canaryReplicas > 0 {
+		canaryReplicas = clampReplicas(canaryReplicas, state.MinReplicas, state.MaxReplicas)
+	}
+
+	if primaryWeight == hundred {
+		if err := kr.restore(canary, state, saved); err != nil {
+			return err
+		}
+	} else {
+		if !saved {
+			if err := kr.setState(canary, &state); err != nil {
+				return err
+			}
+		}
+		if canary.Spec.AutoscalerRef != nil {
+			if err := kr.pinHpa(canary, fmt.Sprintf("%s-primary", canary.Spec.AutoscalerRef.Name), int32p(primaryReplicas), primaryReplicas); err != nil {
+				return err
+			}
+			if err := kr.pinHpa(canary, canary.Spec.AutoscalerRef.Name, int32p(canaryReplicas), canaryReplicas); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := kr.scaleDeployment(canary, canary.Spec.TargetRef.Name, canaryReplicas); err != nil {
+		return err
+	}
+	return kr.scaleDeployment(canary, fmt.Sprintf("%s-primary", canary.Spec.TargetRef.Name), primaryReplicas)
+}
+
+// Finalize restores the min and max replicas of the primary and canary HPAs
+func (kr *KubernetesReplicasRouter) Finalize(canary *flaggerv1.Canary) error {
+	state, saved, err := kr.getState(canary)
+	if err != nil {
+		return err
+	}
+	return kr.restore(canary, state, saved)
+}
+
+// makeSelector returns the general labels if specified, otherwise the labels
+// with the same value in the primary and canary pod templates
+func (kr *KubernetesReplicasRouter) makeSelector(canary *flaggerv1.Canary, canaryLabels map[string]string, primaryLabels map[string]string) (map[string]string, error) {
+	selector := make(map[string]string)
+	if labels, ok := internal.CanaryGeneralLabelsExisted(canary); ok {
+		for _, l := range labels {
+			l = strings.TrimSpace(l)
+			v, ok := canaryLabels[l]
+			if !ok || primaryLabels[l] != v {
+				return nil, fmt.Errorf("label %s must have the same value in the %s and %s-primary pods",
+					l, canary.Spec.TargetRef.Name, canary.Spec.TargetRef.Name)
+			}
+			selector[l] = v
+		}
+		return selector, nil
+	}
+
+	for k, v := range canaryLabels {
+		if pv, ok := primaryLabels[k]; ok && pv == v {
+			selector[k] = v
+		}
+	}
+	if len(selector) == 0 {
+		return nil, fmt.Errorf("the %s and %s-primary pods don't have any labels in common",
+			canary.Spec.TargetRef.Name, canary.Spec.TargetRef.Name)
+	}
+	return selector, nil
+}
+
+// getState returns the replicas state saved on the primary deployment,
+// if the state is not saved it returns the current primary replicas and canary HPA min and max
+func (kr *KubernetesReplicasRouter) getState(canary *flaggerv1.Canary) (replicasState, bool, error) {
+	var state replicasState
+	primaryName := fmt.Sprintf("%s-primary", canary.Spec.TargetRef.Name)
+	primaryDep, err := kr.kubeClient.AppsV1().Deployments(canary.Namespace).Get(primaryName, metav1.GetOptions{})
+	if err != nil {
+		return state, false, fmt.Errorf("deployment %s.%s get query error: %w", primaryName, canary.Namespace, err)
+	}
+
+	if a, ok := primaryDep.Annotations[replicasStateAnnotation]; ok {
+		if err := json.Unmarshal([]byte(a), &state); err != nil {
+			return state, false, fmt.Errorf("deployment %s.%s failed to unMarshal annotation %s",
+				primaryName, canary.Namespace, replicasStateAnnotation)
+		}
+		return state, true, nil
+	}
+
+	state.Replicas = int32Default(primaryDep.Spec.Replicas)
+	if canary.Spec.AutoscalerRef != nil {
+		hpa, err := kr.kubeClient.AutoscalingV2beta1().HorizontalPodAutoscalers(canary.Namespace).Get(canary.Spec.AutoscalerRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return state, false, fmt.Errorf("HorizontalPodAutoscaler %s.%s get query error: %w", canary.Spec.AutoscalerRef.Name, canary.Namespace, err)
+		}
+		state.MinReplicas = hpa.Spec.MinReplicas
+		state.MaxReplicas = hpa.Spec.MaxReplicas
+	}
+	return state, false, nil
+}
+
+// setState saves the replicas state on the primary deployment
+func (kr *KubernetesReplicasRouter) setState(canary *flaggerv1.Canary, state *replicasState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("replicas state marshal error: %w", err)
+	}
+	return kr.annotatePrimary(canary, func(annotations map[string]string) {
+		annotations[replicasStateAnnotation] = string(b)
+	})
+}
+
+// restore sets the min and max replicas of both HPAs from the state and removes the saved state
+func (kr *KubernetesReplicasRouter) restore(canary *flaggerv1.Canary, state replicasState, saved bool) error {
+	if !saved {
+		return nil
+	}
+
+	if canary.Spec.AutoscalerRef != nil && state.MaxReplicas > 0 {
+		for _, name := range []string{fmt.Sprintf("%s-primary", canary.Spec.AutoscalerRef.Name), canary.Spec.AutoscalerRef.Name} {
+			if err := kr.pinHpa(canary, name, state.MinReplicas, state.MaxReplicas); err != nil {
+				return err
+			}
+		}
+	}
+
+	return kr.annotatePrimary(canary, func(annotations map[string]string) {
+		delete(annotations, replicasStateAnnotation)
+	})
+}
+
+func (kr *KubernetesReplicasRouter) annotatePrimary(canary *flaggerv1.Canary, mutate func(annotations map[string]string)) error {
+	primaryName := fmt.Sprintf("%s-primary", canary.Spec.TargetRef.Name)
+	primaryDep, err := kr.kubeClient.AppsV1().Deployments(canary.Namespace).Get(primaryName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("deployment %s.%s get query error: %w", primaryName, canary.Namespace, err)
+	}
+
+	depCopy := primaryDep.DeepCopy()
+	if depCopy.Annotations == nil {
+		depCopy.Annotations = make(map[string]string)
+	}
+	mutate(depCopy.Annotations)
+	_, err = kr.kubeClient.AppsV1().Deployments(canary.Namespace).Update(depCopy)
+	if err != nil {
+		return fmt.Errorf("deployment %s.%s update error: %w", primaryName, canary.Namespace, err)
+	}
+	return nil
+}
+
+// pinHpa sets the min and max replicas of a HPA, the HPAs can't go below one replica
+// and don't scale the deployments with zero replicas
+func (kr *KubernetesReplicasRouter) pinHpa(canary *flaggerv1.Canary, name string, minReplicas *int32, maxReplicas int32) error {
+	if maxReplicas < 1 {
+		minReplicas, maxReplicas = int32p(1), 1
+	}
+
+	hpa, err := kr.kubeClient.AutoscalingV2beta1().HorizontalPodAutoscalers(canary.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("HorizontalPodAutoscaler %s.%s get query error: %w", name, canary.Namespace, err)
+	}
+
+	if int32Default(hpa.Spec.MinReplicas) == int32Default(minReplicas) && hpa.Spec.MaxReplicas == maxReplicas {
+		return nil
+	}
+
+	hpaClone := hpa.DeepCopy()
+	hpaClone.Spec.MinReplicas = minReplicas
+	hpaClone.Spec.MaxReplicas = maxReplicas
+	_, err = kr.kubeClient.AutoscalingV2beta1().HorizontalPodAutoscalers(canary.Namespace).Update(hpaClone)
+	if err != nil {
+		return fmt.Errorf("HorizontalPodAutoscaler %s.%s update error: %w", name, canary.Namespace, err)
+	}
+	return nil
+}
+
+func (kr *KubernetesReplicasRouter) scaleDeployment(canary *flaggerv1.Canary, name string, replicas int32) error {
+	dep, err := kr.kubeClient.AppsV1().Deployments(canary.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("deployment %s.%s get query error: %w", name, canary.Namespace, err)
+	}
+
+	if dep.Spec.Replicas != nil && *dep.Spec.Replicas == replicas {
+		return nil
+	}
+
+	depCopy := dep.DeepCopy()
+	depCopy.Spec.Replicas = int32p(replicas)
+	_, err = kr.kubeClient.AppsV1().Deployments(canary.Namespace).Update(depCopy)
+	if err != nil {
+		return fmt.Errorf("scaling %s.%s to %v failed: %w", name, canary.Namespace, replicas, err)
+	}
+	return nil
+}
+
+// clampReplicas keeps the replicas within the HPA min and max
+func clampReplicas(replicas int32, minReplicas *int32, maxReplicas int32) int32 {
+	if replicas < int32Default(minReplicas) {
+		replicas = int32Default(minReplicas)
+	}
+	if replicas > maxReplicas {
+		replicas = maxReplicas
+	}
+	return replicas
+}
+
+func int32p(i int32) *int32 {
+	return &i
+}
+
+func int32Default(i *int32) int32 {
+	if i == nil {
+		return 1
+	}
+	return *i
+}
@@ -0,0 +1,224 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hpav1 "k8s.io/api/autoscaling/v2beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+)
+
+func newReplicasFixture(t *testing.T) (fixture, *KubernetesReplicasRouter) {
+	mocks := newFixture(nil)
+	mocks.canary.Spec.Analysis.MaxReplicas = 4
+
+	// pods of primary and canary share the part-of label
+	dep, err := mocks.kubeClient.AppsV1().Deployments("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	dep.Spec.Template.Labels["app.kubernetes.io/part-of"] = "podinfo"
+	dep, err = mocks.kubeClient.AppsV1().Deployments("default").Update(dep)
+	require.NoError(t, err)
+
+	primary := dep.DeepCopy()
+	primary.ObjectMeta = metav1.ObjectMeta{Name: "podinfo-primary", Namespace: "default"}
+	primary.Spec.Selector.MatchLabels = map[string]string{"app": "podinfo-primary"}
+	primary.Spec.Template.Labels = map[string]string{"app": "podinfo-primary", "app.kubernetes.io/part-of": "podinfo"}
+	_, err = mocks.kubeClient.AppsV1().Deployments("default").Create(primary)
+	require.NoError(t, err)
+
+	kubeRouter := &KubernetesDefaultRouter{
+		kubeClient:    mocks.kubeClient,
+		flaggerClient: mocks.flaggerClient,
+		logger:        mocks.logger,
+		labelSelector: "app",
+	}
+	require.NoError(t, kubeRouter.Initialize(mocks.canary))
+
+	return mocks, &KubernetesReplicasRouter{
+		kubeClient:    mocks.kubeClient,
+		flaggerClient: mocks.flaggerClient,
+		logger:        mocks.logger,
+	}
+}
+
+func setReadyReplicas(t *testing.T, mocks fixture, name string, ready int32) {
+	dep, err := mocks.kubeClient.AppsV1().Deployments("default").Get(name, metav1.GetOptions{})
+	require.NoError(t, err)
+	dep.Status.ReadyReplicas = ready
+	_, err = mocks.kubeClient.AppsV1().Deployments("default").UpdateStatus(dep)
+	require.NoError(t, err)
+}
+
+func getReplicas(t *testing.T, mocks fixture, name string) int32 {
+	dep, err := mocks.kubeClient.AppsV1().Deployments("default").Get(name, metav1.GetOptions{})
+	require.NoError(t, err)
+	return *dep.Spec.Replicas
+}
+
+func TestKubernetesReplicasRouter_Reconcile(t *testing.T) {
+	mocks, router := newReplicasFixture(t)
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	apex, err := mocks.kubeClient.CoreV1().Services("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app.kubernetes.io/part-of": "podinfo"}, apex.Spec.Selector)
+	assert.Equal(t, int32(9898), apex.Spec.Ports[0].Port)
+
+	// the Kubernetes router keeps the selector set by the replicas router
+	kubeRouter := &ExtKubernetesDefaultRouter{
+		innerK8sRouter: &KubernetesDefaultRouter{
+			kubeClient:       mocks.kubeClient,
+			flaggerClient:    mocks.flaggerClient,
+			logger:           mocks.logger,
+			labelSelector:    "app",
+			keepApexSelector: true,
+		},
+	}
+	err = kubeRouter.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	apex, err = mocks.kubeClient.CoreV1().Services("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app.kubernetes.io/part-of": "podinfo"}, apex.Spec.Selector)
+
+	// the general labels must have the same value in the primary and canary pods
+	cd := mocks.canary.DeepCopy()
+	cd.Annotations = map[string]string{"alicloud.canary.general.labels": "app"}
+	err = router.Reconcile(cd)
+	require.Error(t, err)
+}
+
+func TestKubernetesReplicasRouter_Routes(t *testing.T) {
+	mocks, router := newReplicasFixture(t)
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	err = router.SetRoutes(mocks.canary, 75, 25, false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), getReplicas(t, mocks, "podinfo"))
+	assert.Equal(t, int32(3), getReplicas(t, mocks, "podinfo-primary"))
+
+	// the effective weight is computed from ready replicas
+	setReadyReplicas(t, mocks, "podinfo-primary", 3)
+	p, c, m, err := router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 100, p)
+	assert.Equal(t, 0, c)
+	assert.False(t, m)
+
+	setReadyReplicas(t, mocks, "podinfo", 1)
+	p, c, _, err = router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 75, p)
+	assert.Equal(t, 25, c)
+
+	// primary keeps at least one replica while it receives traffic
+	err = router.SetRoutes(mocks.canary, 10, 90, false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), getReplicas(t, mocks, "podinfo"))
+	assert.Equal(t, int32(1), getReplicas(t, mocks, "podinfo-primary"))
+
+	err = router.SetRoutes(mocks.canary, 100, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), getReplicas(t, mocks, "podinfo"))
+	assert.Equal(t, int32(4), getReplicas(t, mocks, "podinfo-primary"))
+
+	err = router.SetRoutes(mocks.canary, 0, 0, false)
+	require.Error(t, err)
+}
+
+func TestKubernetesReplicasRouter_HPA(t *testing.T) {
+	mocks, router := newReplicasFixture(t)
+	mocks.canary.Spec.Analysis.MaxReplicas = 0
+	mocks.canary.Spec.AutoscalerRef = &flaggerv1.CrossNamespaceObjectReference{Name: "podinfo"}
+
+	minReplicas := int32(2)
+	for _, name := range []string{"podinfo", "podinfo-primary"} {
+		_, err := mocks.kubeClient.AutoscalingV2beta1().HorizontalPodAutoscalers("default").Create(&hpav1.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: hpav1.HorizontalPodAutoscalerSpec{
+				MinReplicas: &minReplicas,
+				MaxReplicas: 10,
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	// the total replicas are the primary replicas from before the analysis
+	dep, err := mocks.kubeClient.AppsV1().Deployments("default").Get("podinfo-primary", metav1.GetOptions{})
+	require.NoError(t, err)
+	dep.Spec.Replicas = int32p(10)
+	_, err = mocks.kubeClient.AppsV1().Deployments("default").Update(dep)
+	require.NoError(t, err)
+
+	err = router.SetRoutes(mocks.canary, 90, 10, false)
+	require.NoError(t, err)
+
+	// the canary replicas are kept within the HPA min and max
+	assert.Equal(t, int32(2), getReplicas(t, mocks, "podinfo"))
+	assert.Equal(t, int32(9), getReplicas(t, mocks, "podinfo-primary"))
+
+	// both HPAs are pinned to the deployment replicas
+	assertHpaReplicas(t, mocks, "podinfo-primary", 9, 9)
+	assertHpaReplicas(t, mocks, "podinfo", 2, 2)
+
+	// the total doesn't change while the primary is scaled down
+	err = router.SetRoutes(mocks.canary, 50, 50, false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(5), getReplicas(t, mocks, "podinfo"))
+	assert.Equal(t, int32(5), getReplicas(t, mocks, "podinfo-primary"))
+	assertHpaReplicas(t, mocks, "podinfo-primary", 5, 5)
+	assertHpaReplicas(t, mocks, "podinfo", 5, 5)
+
+	// the HPAs are restored when all traffic is routed to primary
+	err = router.SetRoutes(mocks.canary, 100, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), getReplicas(t, mocks, "podinfo"))
+	assert.Equal(t, int32(10), getReplicas(t, mocks, "podinfo-primary"))
+	assertHpaReplicas(t, mocks, "podinfo-primary", 2, 10)
+	assertHpaReplicas(t, mocks, "podinfo", 2, 10)
+
+	// the HPAs are restored on finalization
+	err = router.SetRoutes(mocks.canary, 80, 20, false)
+	require.NoError(t, err)
+	assertHpaReplicas(t, mocks, "podinfo", 2, 2)
+
+	meshRouter := &RouterScalableWrapper{innerRouter: router}
+	err = meshRouter.Finalize(mocks.canary)
+	require.NoError(t, err)
+	assertHpaReplicas(t, mocks, "podinfo-primary", 2, 10)
+	assertHpaReplicas(t, mocks, "podinfo", 2, 10)
+
+	dep, err = mocks.kubeClient.AppsV1().Deployments("default").Get("podinfo-primary", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, dep.Annotations, replicasStateAnnotation)
+}
+
+func assertHpaReplicas(t *testing.T, mocks fixture, name string, minReplicas int32, maxReplicas int32) {
+	hpa, err := mocks.kubeClient.AutoscalingV2beta1().HorizontalPodAutoscalers("default").Get(name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, minReplicas, *hpa.Spec.MinReplicas)
+	assert.Equal(t, maxReplicas, hpa.Spec.MaxReplicas)
+}
+
+func TestKubernetesReplicasRouter_ScaleCanaryToZero(t *testing.T) {
+	mocks, router := newReplicasFixture(t)
+	mocks.canary.Status.Phase = flaggerv1.CanaryPhaseProgressing
+
+	dep, err := mocks.kubeClient.AppsV1().Deployments("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	dep.Spec.Replicas = int32p(2)
+	_, err = mocks.kubeClient.AppsV1().Deployments("default").Update(dep)
+	require.NoError(t, err)
+
+	// the canary doesn't receive traffic before the first step
+	err = router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), getReplicas(t, mocks, "podinfo"))
+}
@@ -155,6 +155,10 @@ func (r *RouterScalableWrapper) GetRoutes(canary *v1beta1.Canary) (primaryWeight
 }
 
 func (r *RouterScalableWrapper) Finalize(canary *v1beta1.Canary) error {
+	// the kubernetes-replicas router restores the HPAs pinned during the analysis
+	if kr, ok := r.innerRouter.(*KubernetesReplicasRouter); ok {
+		return kr.Finalize(canary)
+	}
 	return nil
 }
 
@@ -25,32 +25,25 @@ type SmiRouter struct {
 	smiClient     clientset.Interface
 	logger        *zap.SugaredLogger
 	targetMesh    string
+	applier       *serverSideApplier
+	// splitGVR is the TrafficSplit version applied server-side
+	splitGVR schema.GroupVersionResource
 }
 
+var (
+	smiTrafficSplitGVR         = smiv1alpha1.SchemeGroupVersion.WithResource("trafficsplits")
+	smiV1alpha2TrafficSplitGVR = smiv1alpha2.SchemeGroupVersion.WithResource("trafficsplits")
+)
+
 // Reconcile creates or updates the SMI traffic split
 func (sr *SmiRouter) Reconcile(canary *flaggerv1.Canary) error {
-	apexName, primaryName, canaryName := canary.GetServiceNames()
+	apexName, _, _ := canary.GetServiceNames()
 
-	var host string
-	if len(canary.Spec.Service.Hosts) > 0 {
-		host = canary.Spec.Service.Hosts[0]
-	} else {
-		host = apexName
+	if sr.applier != nil {
+		return sr.applyTrafficSplit(canary, 0, 0, false)
 	}
 
-	tsSpec := smiv1alpha1.TrafficSplitSpec{
-		Service: host,
-		Backends: []smiv1alpha1.TrafficSplitBackend{
-			{
-				Service: canaryName,
-				Weight:  resource.NewQuantity(0, resource.DecimalExponent),
-			},
-			{
-				Service: primaryName,
-				Weight:  resource.NewQuantity(100, resource.DecimalExponent),
-			},
-		},
-	}
+	tsSpec := sr.makeSpec(canary, 100, 0)
 
 	ts, err := sr.smiClient.SplitV1alpha1().TrafficSplits(canary.Namespace).Get(apexName, metav1.GetOptions{})
 	// create traffic split
@@ -142,25 +135,18 @@ func (sr *SmiRouter) SetRoutes(
 	canaryWeight int,
 	_ bool,
 ) error {
-	apexName, primaryName, canaryName := canary.GetServiceNames()
+	if sr.applier != nil {
+		return sr.applyTrafficSplit(canary, primaryWeight, canaryWeight, true)
+	}
+
+	apexName, _, _ := canary.GetServiceNames()
 	ts, err := sr.smiClient.SplitV1alpha1().TrafficSplits(canary.Namespace).Get(apexName, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("TrafficSplit %s.%s get query error %v", apexName, canary.Namespace, err)
 	}
 
-	backends := []smiv1alpha1.TrafficSplitBackend{
-		{
-			Service: canaryName,
-			Weight:  resource.NewQuantity(int64(canaryWeight), resource.DecimalExponent),
-		},
-		{
-			Service: primaryName,
-			Weight:  resource.NewQuantity(int64(primaryWeight), resource.DecimalExponent),
-		},
-	}
-
 	tsClone := ts.DeepCopy()
-	tsClone.Spec.Backends = backends
+	tsClone.Spec.Backends = sr.makeSpec(canary, primaryWeight, canaryWeight).Backends
 
 	_, err = sr.smiClient.SplitV1alpha1().TrafficSplits(canary.Namespace).Update(tsClone)
 	if err != nil {
@@ -170,6 +156,85 @@ func (sr *SmiRouter) SetRoutes(
 	return nil
 }
 
+// makeSpec returns the traffic split spec with the specified weights
+func (sr *SmiRouter) makeSpec(canary *flaggerv1.Canary, primaryWeight int, canaryWeight int) smiv1alpha1.TrafficSplitSpec {
+	apexName, primaryName, canaryName := canary.GetServiceNames()
+
+	var host string
+	if len(canary.Spec.Service.Hosts) > 0 {
+		host = canary.Spec.Service.Hosts[0]
+	} else {
+		host = apexName
+	}
+
+	return smiv1alpha1.TrafficSplitSpec{
+		Service: host,
+		Backends: []smiv1alpha1.TrafficSplitBackend{
+			{
+				Service: canaryName,
+				Weight:  resource.NewQuantity(int64(canaryWeight), resource.DecimalExponent),
+			},
+			{
+				Service: primaryName,
+				Weight:  resource.NewQuantity(int64(primaryWeight), resource.DecimalExponent),
+			},
+		},
+	}
+}
+
+// applyTrafficSplit applies the generated traffic split with server-side apply,
+// the weights are taken from the canary status when no weights are specified
+func (sr *SmiRouter) applyTrafficSplit(canary *flaggerv1.Canary, primaryWeight int, canaryWeight int, force bool) error {
+	apexName, _, _ := canary.GetServiceNames()
+
+	var live metav1.Object
+	ts, err := sr.smiClient.SplitV1alpha1().TrafficSplits(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if err == nil {
+		live = ts
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("TrafficSplit %s.%s get query error: %w", apexName, canary.Namespace, err)
+	}
+
+	if primaryWeight == 0 && canaryWeight == 0 {
+		primaryWeight, canaryWeight, _ = statusWeights(canary)
+	}
+
+	objectMeta := makeApplyObjectMeta(canary, apexName, live)
+	objectMeta.Annotations = sr.makeAnnotations(canary.Spec.Service.Gateways)
+	spec := sr.makeSpec(canary, primaryWeight, canaryWeight)
+
+	if sr.splitGVR == smiV1alpha2TrafficSplitGVR {
+		obj := &smiv1alpha2.TrafficSplit{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: smiv1alpha2.SchemeGroupVersion.String(),
+				Kind:       "TrafficSplit",
+			},
+			ObjectMeta: objectMeta,
+			Spec: smiv1alpha2.TrafficSplitSpec{
+				Service: spec.Service,
+			},
+		}
+		for _, backend := range spec.Backends {
+			weight, _ := backend.Weight.AsInt64()
+			obj.Spec.Backends = append(obj.Spec.Backends, smiv1alpha2.TrafficSplitBackend{
+				Service: backend.Service,
+				Weight:  int(weight),
+			})
+		}
+		return sr.applier.apply(canary, smiV1alpha2TrafficSplitGVR, obj, live, force)
+	}
+
+	obj := &smiv1alpha1.TrafficSplit{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: smiv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "TrafficSplit",
+		},
+		ObjectMeta: objectMeta,
+		Spec:       spec,
+	}
+	return sr.applier.apply(canary, smiTrafficSplitGVR, obj, live, force)
+}
+
 func (sr *SmiRouter) makeAnnotations(gateways []string) map[string]string {
 	res := make(map[string]string)
 	if sr.targetMesh == "istio" && len(gateways) > 0 {
@@ -0,0 +1,286 @@
+package router
+
+import (
+	"fmt"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	flaggerv1 "github.com/weaveworks/flagger/pkg/apis/flagger/v1beta1"
+	clientset "github.com/weaveworks/flagger/pkg/client/clientset/versioned"
+)
+
+// traefikServiceGVR is the Traefik CRD used for weighted and mirrored load balancing
+var traefikServiceGVR = schema.GroupVersionResource{
+	Group:    "traefik.io",
+	Version:  "v1alpha1",
+	Resource: "traefikservices",
+}
+
+// traefikServiceSpec holds the weighted or the mirroring load balancer of a TraefikService
+type traefikServiceSpec struct {
+	Weighted  *traefikWeighted  `json:"weighted,omitempty"`
+	Mirroring *traefikMirroring `json:"mirroring,omitempty"`
+}
+
+type traefikWeighted struct {
+	Services []traefikService `json:"services,omitempty"`
+}
+
+type traefikMirroring struct {
+	Name    string          `json:"name"`
+	Port    int32           `json:"port,omitempty"`
+	Mirrors []traefikMirror `json:"mirrors,omitempty"`
+}
+
+type traefikService struct {
+	Name   string `json:"name"`
+	Port   int32  `json:"port,omitempty"`
+	Weight *int   `json:"weight,omitempty"`
+}
+
+type traefikMirror struct {
+	Name    string `json:"name"`
+	Port    int32  `json:"port,omitempty"`
+	Percent int    `json:"percent,omitempty"`
+}
+
+// TraefikRouter is managing TraefikService objects
+type TraefikRouter struct {
+	kubeClient    kubernetes.Interface
+	traefikClient dynamic.Interface
+	flaggerClient clientset.Interface
+	logger        *zap.SugaredLogger
+}
+
+// Reconcile creates or updates the TraefikService
+func (tr *TraefikRouter) Reconcile(canary *flaggerv1.Canary) error {
+	if tr.traefikClient == nil {
+		return fmt.Errorf("TraefikService dynamic client is not configured")
+	}
+
+	apexName, _, _ := canary.GetServiceNames()
+
+	ts, err := tr.traefikClient.Resource(traefikServiceGVR).Namespace(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		ts = &unstructured.Unstructured{}
+		ts.SetAPIVersion(traefikServiceGVR.GroupVersion().String())
+		ts.SetKind("TraefikService")
+		ts.SetName(apexName)
+		ts.SetNamespace(canary.Namespace)
+		ts.SetOwnerReferences([]metav1.OwnerReference{
+			*metav1.NewControllerRef(canary, schema.GroupVersionKind{
+				Group:   flaggerv1.SchemeGroupVersion.Group,
+				Version: flaggerv1.SchemeGroupVersion.Version,
+				Kind:    flaggerv1.CanaryKind,
+			}),
+		})
+		if err := setTraefikServiceSpec(ts, tr.makeSpec(canary, 100, 0, false)); err != nil {
+			return fmt.Errorf("TraefikService %s.%s create error: %w", apexName, canary.Namespace, err)
+		}
+
+		_, err = tr.traefikClient.Resource(traefikServiceGVR).Namespace(canary.Namespace).Create(ts, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("TraefikService %s.%s create error: %w", apexName, canary.Namespace, err)
+		}
+		tr.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+			Infof("TraefikService %s.%s created", apexName, canary.Namespace)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("TraefikService %s.%s get query error: %w", apexName, canary.Namespace, err)
+	}
+
+	// update the TraefikService but keep the current weights and mirroring
+	spec, err := getTraefikServiceSpec(ts)
+	if err != nil {
+		return fmt.Errorf("TraefikService %s.%s spec error: %w", apexName, canary.Namespace, err)
+	}
+	primaryWeight, canaryWeight, mirrored := traefikWeights(canary, spec)
+	if primaryWeight == 0 && canaryWeight == 0 && !mirrored {
+		primaryWeight = 100
+	}
+
+	newSpec := tr.makeSpec(canary, primaryWeight, canaryWeight, mirrored)
+	if diff := cmp.Diff(newSpec, spec); diff != "" {
+		clone := ts.DeepCopy()
+		if err := setTraefikServiceSpec(clone, newSpec); err != nil {
+			return fmt.Errorf("TraefikService %s.%s update error: %w", apexName, canary.Namespace, err)
+		}
+
+		_, err = tr.traefikClient.Resource(traefikServiceGVR).Namespace(canary.Namespace).Update(clone, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("TraefikService %s.%s update error: %w", apexName, canary.Namespace, err)
+		}
+		tr.logger.With("canary", fmt.Sprintf("%s.%s", canary.Name, canary.Namespace)).
+			Infof("TraefikService %s.%s updated", apexName, canary.Namespace)
+	}
+
+	return nil
+}
+
+// GetRoutes returns the service weight for primary and canary
+func (tr *TraefikRouter) GetRoutes(canary *flaggerv1.Canary) (
+	primaryWeight int,
+	canaryWeight int,
+	mirrored bool,
+	err error,
+) {
+	apexName, _, _ := canary.GetServiceNames()
+
+	ts, err := tr.traefikClient.Resource(traefikServiceGVR).Namespace(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if err != nil {
+		err = fmt.Errorf("TraefikService %s.%s get query error: %w", apexName, canary.Namespace, err)
+		return
+	}
+
+	spec, err := getTraefikServiceSpec(ts)
+	if err != nil {
+		err = fmt.Errorf("TraefikService %s.%s spec error: %w", apexName, canary.Namespace, err)
+		return
+	}
+
+	primaryWeight, canaryWeight, mirrored = traefikWeights(canary, spec)
+	if primaryWeight == 0 && canaryWeight == 0 && !mirrored {
+		err = fmt.Errorf("TraefikService %s.%s does not contain services for %s-primary and %s-canary",
+			apexName, canary.Namespace, apexName, apexName)
+	}
+	return
+}
+
+// SetRoutes updates the service weight for primary and canary,
+// when mirroring is enabled the canary receives a copy of the primary traffic
+func (tr *TraefikRouter) SetRoutes(
+	canary *flaggerv1.Canary,
+	primaryWeight int,
+	canaryWeight int,
+	mirrored bool,
+) error {
+	apexName, _, _ := canary.GetServiceNames()
+
+	if primaryWeight == 0 && canaryWeight == 0 {
+		return fmt.Errorf("TraefikService %s.%s update failed: no valid weights", apexName, canary.Namespace)
+	}
+
+	ts, err := tr.traefikClient.Resource(traefikServiceGVR).Namespace(canary.Namespace).Get(apexName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("TraefikService %s.%s query error: %w", apexName, canary.Namespace, err)
+	}
+
+	clone := ts.DeepCopy()
+	if err := setTraefikServiceSpec(clone, tr.makeSpec(canary, primaryWeight, canaryWeight, mirrored)); err != nil {
+		return fmt.Errorf("TraefikService %s.%s update error: %w", apexName, canary.Namespace, err)
+	}
+
+	_, err = tr.traefikClient.Resource(traefikServiceGVR).Namespace(canary.Namespace).Update(clone, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("TraefikService %s.%s update error: %w", apexName, canary.Namespace, err)
+	}
+	return nil
+}
+
+func (tr *TraefikRouter) Finalize(_ *flaggerv1.Canary) error {
+	return nil
+}
+
+// makeSpec returns the weighted load balancer or, when mirroring, a mirroring
+// load balancer that sends all traffic to primary and a copy of it to canary
+func (tr *TraefikRouter) makeSpec(canary *flaggerv1.Canary, primaryWeight int, canaryWeight int, mirrored bool) traefikServiceSpec {
+	_, primaryName, canaryName := canary.GetServiceNames()
+	port := canary.Spec.Service.Port
+
+	if mirrored {
+		percent := 100
+		if mw := canary.GetAnalysis().MirrorWeight; mw > 0 {
+			percent = mw
+		}
+		return traefikServiceSpec{
+			Mirroring: &traefikMirroring{
+				Name: primaryName,
+				Port: port,
+				Mirrors: []traefikMirror{
+					{
+						Name:    canaryName,
+						Port:    port,
+						Percent: percent,
+					},
+				},
+			},
+		}
+	}
+
+	return traefikServiceSpec{
+		Weighted: &traefikWeighted{
+			Services: []traefikService{
+				{
+					Name:   primaryName,
+					Port:   port,
+					Weight: &primaryWeight,
+				},
+				{
+					Name:   canaryName,
+					Port:   port,
+					Weight: &canaryWeight,
+				},
+			},
+		},
+	}
+}
+
+// traefikWeights reads the primary and canary weights of a TraefikService,
+// a mirroring load balancer routes all traffic to primary
+func traefikWeights(canary *flaggerv1.Canary, spec traefikServiceSpec) (primaryWeight int, canaryWeight int, mirrored bool) {
+	_, primaryName, canaryName := canary.GetServiceNames()
+
+	if spec.Mirroring != nil {
+		for _, m := range spec.Mirroring.Mirrors {
+			if m.Name == canaryName {
+				mirrored = true
+			}
+		}
+		if spec.Mirroring.Name == primaryName {
+			primaryWeight = 100
+		}
+		return
+	}
+
+	if spec.Weighted != nil {
+		for _, s := range spec.Weighted.Services {
+			weight := 1
+			if s.Weight != nil {
+				weight = *s.Weight
+			}
+			switch s.Name {
+			case primaryName:
+				primaryWeight = weight
+			case canaryName:
+				canaryWeight = weight
+			}
+		}
+	}
+	return
+}
+
+func getTraefikServiceSpec(ts *unstructured.Unstructured) (traefikServiceSpec, error) {
+	var spec traefikServiceSpec
+	obj, ok, err := unstructured.NestedMap(ts.Object, "spec")
+	if err != nil || !ok {
+		return spec, err
+	}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &spec)
+	return spec, err
+}
+
+func setTraefikServiceSpec(ts *unstructured.Unstructured, spec traefikServiceSpec) error {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	if err != nil {
+		return err
+	}
+	return unstructured.SetNestedMap(ts.Object, obj, "spec")
+}
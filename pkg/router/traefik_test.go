@@ -0,0 +1,135 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+)
+
+func newTraefikRouter(mocks fixture, objects ...runtime.Object) *TraefikRouter {
+	return &TraefikRouter{
+		logger:        mocks.logger,
+		flaggerClient: mocks.flaggerClient,
+		kubeClient:    mocks.kubeClient,
+		traefikClient: fakeDynamic.NewSimpleDynamicClient(runtime.NewScheme(), objects...),
+	}
+}
+
+func TestTraefikRouter_Reconcile(t *testing.T) {
+	mocks := newFixture(nil)
+	router := newTraefikRouter(mocks)
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	// test insert
+	ts, err := router.traefikClient.Resource(traefikServiceGVR).Namespace("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	services, _, err := unstructured.NestedSlice(ts.Object, "spec", "weighted", "services")
+	require.NoError(t, err)
+	require.Len(t, services, 2)
+	assert.Equal(t, "podinfo-primary", services[0].(map[string]interface{})["name"])
+	assert.Equal(t, int64(100), services[0].(map[string]interface{})["weight"])
+	assert.Equal(t, "podinfo-canary", services[1].(map[string]interface{})["name"])
+	assert.Equal(t, int64(0), services[1].(map[string]interface{})["weight"])
+	assert.Equal(t, "podinfo", ts.GetOwnerReferences()[0].Name)
+
+	// test update keeps the weights
+	err = router.SetRoutes(mocks.canary, 70, 30, false)
+	require.NoError(t, err)
+
+	cd := mocks.canary.DeepCopy()
+	cd.Spec.Service.Port = 8080
+	err = router.Reconcile(cd)
+	require.NoError(t, err)
+
+	ts, err = router.traefikClient.Resource(traefikServiceGVR).Namespace("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+	services, _, err = unstructured.NestedSlice(ts.Object, "spec", "weighted", "services")
+	require.NoError(t, err)
+	assert.Equal(t, int64(8080), services[0].(map[string]interface{})["port"])
+	assert.Equal(t, int64(70), services[0].(map[string]interface{})["weight"])
+	assert.Equal(t, int64(30), services[1].(map[string]interface{})["weight"])
+}
+
+func TestTraefikRouter_Routes(t *testing.T) {
+	mocks := newFixture(nil)
+	router := newTraefikRouter(mocks)
+
+	err := router.Reconcile(mocks.canary)
+	require.NoError(t, err)
+
+	p, c, m, err := router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 100, p)
+	assert.Equal(t, 0, c)
+	assert.False(t, m)
+
+	err = router.SetRoutes(mocks.canary, 50, 50, false)
+	require.NoError(t, err)
+
+	p, c, m, err = router.GetRoutes(mocks.canary)
+	require.NoError(t, err)
+	assert.Equal(t, 50, p)
+	assert.Equal(t, 50, c)
+	assert.False(t, m)
+
+	err = router.SetRoutes(mocks.canary, 0, 0, false)
+	require.Error(t, err)
+}
+
+func TestTraefikRouter_Mirroring(t *testing.T) {
+	mocks := newFixture(nil)
+	router := newTraefikRouter(mocks)
+
+	canary := mocks.canary.DeepCopy()
+	canary.Spec.Analysis.Mirror = true
+	canary.Spec.Analysis.MirrorWeight = 25
+
+	err := router.Reconcile(canary)
+	require.NoError(t, err)
+
+	err = router.SetRoutes(canary, 100, 0, true)
+	require.NoError(t, err)
+
+	ts, err := router.traefikClient.Resource(traefikServiceGVR).Namespace("default").Get("podinfo", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	name, _, _ := unstructured.NestedString(ts.Object, "spec", "mirroring", "name")
+	assert.Equal(t, "podinfo-primary", name)
+	mirrors, _, err := unstructured.NestedSlice(ts.Object, "spec", "mirroring", "mirrors")
+	require.NoError(t, err)
+	require.Len(t, mirrors, 1)
+	assert.Equal(t, "podinfo-canary", mirrors[0].(map[string]interface{})["name"])
+	assert.Equal(t, int64(25), mirrors[0].(map[string]interface{})["percent"])
+	_, found, _ := unstructured.NestedMap(ts.Object, "spec", "weighted")
+	assert.False(t, found)
+
+	p, c, m, err := router.GetRoutes(canary)
+	require.NoError(t, err)
+	assert.Equal(t, 100, p)
+	assert.Equal(t, 0, c)
+	assert.True(t, m)
+
+	// the reconciliation keeps mirroring enabled
+	err = router.Reconcile(canary)
+	require.NoError(t, err)
+	_, _, m, err = router.GetRoutes(canary)
+	require.NoError(t, err)
+	assert.True(t, m)
+
+	err = router.SetRoutes(canary, 90, 10, false)
+	require.NoError(t, err)
+
+	p, c, m, err = router.GetRoutes(canary)
+	require.NoError(t, err)
+	assert.Equal(t, 90, p)
+	assert.Equal(t, 10, c)
+	assert.False(t, m)
+}
@@ -0,0 +1,67 @@
+// Package signature signs and verifies the webhook requests sent by Flagger
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader contains the HMAC-SHA256 signature of the timestamp and the request body
+	SignatureHeader = "X-Flagger-Signature"
+
+	// TimestampHeader contains the Unix time in seconds at which the request was signed
+	TimestampHeader = "X-Flagger-Timestamp"
+
+	// DefaultTolerance is the maximum age of a signed request
+	DefaultTolerance = 5 * time.Minute
+)
+
+// Sign returns the signature of the timestamp and body in the sha256=<hex> format
+func Sign(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest sets the timestamp and signature headers of a request
+func SignRequest(req *http.Request, key []byte, body []byte, now time.Time) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(key, timestamp, body))
+}
+
+// Verify checks that the request was signed with the key within the tolerance
+func Verify(key []byte, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	timestamp := header.Get(TimestampHeader)
+	sig := header.Get(SignatureHeader)
+	if timestamp == "" || sig == "" {
+		return errors.New("missing signature headers")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s", timestamp)
+	}
+	age := now.Sub(time.Unix(ts, 0))
+	if age > tolerance || age < -tolerance {
+		return fmt.Errorf("timestamp %s is outside of the %v tolerance", timestamp, tolerance)
+	}
+
+	if !strings.HasPrefix(sig, "sha256=") {
+		return errors.New("unsupported signature algorithm")
+	}
+	if !hmac.Equal([]byte(sig), []byte(Sign(key, timestamp, body))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package signature
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	key := []byte("secret")
+	body := []byte(`{"name":"podinfo","namespace":"test","phase":"Progressing"}`)
+	now := time.Now()
+
+	req, err := http.NewRequest("POST", "http://loadtester/gate/check", nil)
+	require.NoError(t, err)
+	SignRequest(req, key, body, now)
+
+	require.NoError(t, Verify(key, req.Header, body, DefaultTolerance, now.Add(time.Minute)))
+
+	err = Verify([]byte("other"), req.Header, body, DefaultTolerance, now)
+	require.EqualError(t, err, "signature mismatch")
+
+	err = Verify(key, req.Header, []byte(`{}`), DefaultTolerance, now)
+	require.EqualError(t, err, "signature mismatch")
+
+	err = Verify(key, req.Header, body, DefaultTolerance, now.Add(10*time.Minute))
+	require.Error(t, err)
+
+	err = Verify(key, http.Header{}, body, DefaultTolerance, now)
+	require.EqualError(t, err, "missing signature headers")
+}